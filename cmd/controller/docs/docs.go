@@ -303,6 +303,118 @@ const docTemplate = `{
                 }
             }
         },
+        "/api/v1/admin/audit": {
+            "get": {
+                "description": "list the audit trail of admin, user and gateway actions, newest first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin-audit"
+                ],
+                "summary": "ListAuditTrails",
+                "operationId": "ListAuditTrails",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "page number, starts at 1",
+                        "name": "page",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "entries per page, at most 500",
+                        "name": "pageSize",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "action e.g. user.create",
+                        "name": "action",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "uuid of the user or gateway performing the action",
+                        "name": "actorUuid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "affected user id",
+                        "name": "userUuid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "affected group id",
+                        "name": "groupUuid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "affected gateway id",
+                        "name": "gatewayUuid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "affected client id",
+                        "name": "clientUuid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC3339 start time",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "RFC3339 end time",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.AuditTrailListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
         "/api/v1/admin/client/expired": {
             "delete": {
                 "description": "DeleteExpiredClients",
@@ -523,9 +635,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/admin/config/sso": {
-            "post": {
-                "description": "Add SSO Configuration",
+        "/api/v1/admin/config/scim-token": {
+            "put": {
+                "description": "Issue a new bearer token for the scim client at /scim/v2, the previous token stops working. The token is only shown once",
                 "consumes": [
                     "application/json"
                 ],
@@ -535,8 +647,8 @@ const docTemplate = `{
                 "tags": [
                     "admin-config"
                 ],
-                "summary": "Add SSO Config",
-                "operationId": "AddSsoConfig",
+                "summary": "RotateSCIMToken",
+                "operationId": "RotateSCIMToken",
                 "parameters": [
                     {
                         "type": "string",
@@ -545,15 +657,6 @@ const docTemplate = `{
                         "name": "Authorization",
                         "in": "header",
                         "required": true
-                    },
-                    {
-                        "description": "SSO Config Details",
-                        "name": "AddSsoConfigRequest",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.AddSsoConfigRequest"
-                        }
                     }
                 ],
                 "responses": {
@@ -576,11 +679,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/v1/admin/config/sso-login": {
-            "put": {
-                "description": "update whether sso login should be allowed or not",
+            },
+            "delete": {
+                "description": "Revoke the bearer token of the scim client, which disables scim provisioning",
                 "consumes": [
                     "application/json"
                 ],
@@ -590,8 +691,8 @@ const docTemplate = `{
                 "tags": [
                     "admin-config"
                 ],
-                "summary": "UpdateAllowSSOLogin",
-                "operationId": "UpdateAllowSSOLogin",
+                "summary": "RevokeSCIMToken",
+                "operationId": "RevokeSCIMToken",
                 "parameters": [
                     {
                         "type": "string",
@@ -600,15 +701,6 @@ const docTemplate = `{
                         "name": "Authorization",
                         "in": "header",
                         "required": true
-                    },
-                    {
-                        "description": "true or false",
-                        "name": "UpdateAllowSSOLoginRequest",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.UpdateAllowSSOLoginRequest"
-                        }
                     }
                 ],
                 "responses": {
@@ -633,9 +725,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/admin/config/sso/{id}": {
-            "delete": {
-                "description": "delete sso configuration",
+        "/api/v1/admin/config/sso": {
+            "post": {
+                "description": "Add the SSO Configuration of an OIDC provider, its endpoints are discovered from the issuer url",
                 "consumes": [
                     "application/json"
                 ],
@@ -645,8 +737,8 @@ const docTemplate = `{
                 "tags": [
                     "admin-config"
                 ],
-                "summary": "DeleteSsoConfig",
-                "operationId": "DeleteSsoConfig",
+                "summary": "Add SSO Config",
+                "operationId": "AddSsoConfig",
                 "parameters": [
                     {
                         "type": "string",
@@ -657,11 +749,13 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "sso id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "description": "SSO Config Details",
+                        "name": "AddSsoConfigRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.AddSsoConfigRequest"
+                        }
                     }
                 ],
                 "responses": {
@@ -686,9 +780,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/admin/gateway": {
-            "post": {
-                "description": "CreateGateway",
+        "/api/v1/admin/config/sso-login": {
+            "put": {
+                "description": "update whether sso login should be allowed or not",
                 "consumes": [
                     "application/json"
                 ],
@@ -696,9 +790,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "admin-gateway"
+                    "admin-config"
                 ],
-                "summary": "CreateGateway",
+                "summary": "UpdateAllowSSOLogin",
+                "operationId": "UpdateAllowSSOLogin",
                 "parameters": [
                     {
                         "type": "string",
@@ -709,12 +804,12 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Gateway details",
-                        "name": "VpnGatewayCreateRequest",
+                        "description": "true or false",
+                        "name": "UpdateAllowSSOLoginRequest",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.VpnGatewayCreateRequest"
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.UpdateAllowSSOLoginRequest"
                         }
                     }
                 ],
@@ -725,12 +820,6 @@ const docTemplate = `{
                             "type": "object"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "object"
-                        }
-                    },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
@@ -746,9 +835,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/admin/gateway/list": {
-            "get": {
-                "description": "list all the gateways",
+        "/api/v1/admin/config/sso/saml": {
+            "post": {
+                "description": "Add a SAML identity provider from its metadata, the service provider metadata is then served at /api/v1/auth/saml/{provider}/metadata",
                 "consumes": [
                     "application/json"
                 ],
@@ -756,10 +845,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "admin-gateway"
+                    "admin-config"
                 ],
-                "summary": "List gateways",
-                "operationId": "list-gateways",
+                "summary": "AddSamlSsoConfig",
+                "operationId": "AddSamlSsoConfig",
                 "parameters": [
                     {
                         "type": "string",
@@ -770,24 +859,26 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "multi",
-                        "description": "string collections",
-                        "name": "include",
-                        "in": "query"
+                        "description": "SAML SSO Config Details",
+                        "name": "AddSamlSsoConfigRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.AddSamlSsoConfigRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.VpnGateway"
-                            }
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object"
                         }
                     },
                     "401": {
@@ -805,9 +896,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/admin/gateway/{id}": {
-            "get": {
-                "description": "GetVpnGatewayDetails",
+        "/api/v1/admin/config/sso/{id}": {
+            "delete": {
+                "description": "delete sso configuration",
                 "consumes": [
                     "application/json"
                 ],
@@ -815,9 +906,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "admin-gateway"
+                    "admin-config"
                 ],
-                "summary": "GetVpnGatewayDetails",
+                "summary": "DeleteSsoConfig",
+                "operationId": "DeleteSsoConfig",
                 "parameters": [
                     {
                         "type": "string",
@@ -829,31 +921,15 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "gateway id",
+                        "description": "sso id",
                         "name": "id",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "multi",
-                        "description": "string collections",
-                        "name": "include",
-                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.VpnGateway"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
                         "schema": {
                             "type": "object"
                         }
@@ -871,9 +947,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "UpdateGateway",
+            }
+        },
+        "/api/v1/admin/config/sso/{id}/group-mappings": {
+            "get": {
+                "description": "List the group mappings of the sso config",
                 "consumes": [
                     "application/json"
                 ],
@@ -881,9 +959,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "admin-gateway"
+                    "admin-config"
                 ],
-                "summary": "UpdateGateway",
+                "summary": "ListSSOGroupMappings",
+                "operationId": "ListSSOGroupMappings",
                 "parameters": [
                     {
                         "type": "string",
@@ -893,18 +972,9 @@ const docTemplate = `{
                         "in": "header",
                         "required": true
                     },
-                    {
-                        "description": "Gateway details",
-                        "name": "VpnGatewayUpdateRequest",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.VpnGatewayUpdateRequest"
-                        }
-                    },
                     {
                         "type": "string",
-                        "description": "gateway id",
+                        "description": "sso id",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -914,13 +984,10 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "object"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SSOGroupMapping"
+                            }
                         }
                     },
                     "401": {
@@ -937,8 +1004,8 @@ const docTemplate = `{
                     }
                 }
             },
-            "delete": {
-                "description": "DeleteGateway",
+            "post": {
+                "description": "Map a value of the group claim of the sso config to a group, the members of the mapped groups are synced on every login through the config",
                 "consumes": [
                     "application/json"
                 ],
@@ -946,9 +1013,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "admin-gateway"
+                    "admin-config"
                 ],
-                "summary": "DeleteGateway",
+                "summary": "CreateSSOGroupMapping",
+                "operationId": "CreateSSOGroupMapping",
                 "parameters": [
                     {
                         "type": "string",
@@ -960,17 +1028,26 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "gateway id",
+                        "description": "sso id",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Claim value and group",
+                        "name": "SSOGroupMappingCreateRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SSOGroupMappingCreateRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "type": "object"
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SSOGroupMapping"
                         }
                     },
                     "400": {
@@ -994,9 +1071,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/admin/gateway/{id}/deployment-config": {
-            "get": {
-                "description": "GetVpnGatewayDeploymentConfig",
+        "/api/v1/admin/config/sso/{id}/group-mappings/{mappingId}": {
+            "delete": {
+                "description": "Delete a group mapping of the sso config, the members of the group are kept",
                 "consumes": [
                     "application/json"
                 ],
@@ -1004,9 +1081,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "admin-gateway"
+                    "admin-config"
                 ],
-                "summary": "GetVpnGatewayDeploymentConfig",
+                "summary": "DeleteSSOGroupMapping",
+                "operationId": "DeleteSSOGroupMapping",
                 "parameters": [
                     {
                         "type": "string",
@@ -1018,10 +1096,17 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "gateway id",
+                        "description": "sso id",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "group mapping id",
+                        "name": "mappingId",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -1031,12 +1116,6 @@ const docTemplate = `{
                             "type": "object"
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "object"
-                        }
-                    },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
@@ -1052,9 +1131,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/admin/gateway/{id}/reset": {
-            "delete": {
-                "description": "Clear Gateway Clients And IPPool",
+        "/api/v1/admin/config/sso/{id}/jit": {
+            "put": {
+                "description": "Set whether the sso config creates the users of its domain on their first login, with the default role and groups",
                 "consumes": [
                     "application/json"
                 ],
@@ -1062,9 +1141,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "admin-gateway"
+                    "admin-config"
                 ],
-                "summary": "Reset Gateway",
+                "summary": "UpdateSSOJITProvisioning",
+                "operationId": "UpdateSSOJITProvisioning",
                 "parameters": [
                     {
                         "type": "string",
@@ -1076,10 +1156,19 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "gateway id",
+                        "description": "sso id",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "JIT provisioning settings",
+                        "name": "UpdateSSOJITProvisioningRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.UpdateSSOJITProvisioningRequest"
+                        }
                     }
                 ],
                 "responses": {
@@ -1110,13 +1199,20 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/admin/group": {
-            "post": {
-                "description": "CreateGroup",
+        "/api/v1/admin/config/sso/{id}/saml-metadata": {
+            "put": {
+                "description": "Replace the identity provider metadata of a SAML sso configuration",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "admin-group"
+                    "admin-config"
                 ],
-                "summary": "CreateGroup",
+                "summary": "UpdateSamlIdpMetadata",
+                "operationId": "UpdateSamlIdpMetadata",
                 "parameters": [
                     {
                         "type": "string",
@@ -1127,16 +1223,29 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Group details",
-                        "name": "GroupCreateRequest",
+                        "type": "string",
+                        "description": "sso id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "IdP metadata xml",
+                        "name": "UpdateSamlIdpMetadataRequest",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.GroupCreateRequest"
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.UpdateSamlIdpMetadataRequest"
                         }
                     }
                 ],
                 "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
                     "400": {
                         "description": "Bad Request",
                         "schema": {
@@ -1158,9 +1267,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/admin/group/list": {
+        "/api/v1/admin/device/list": {
             "get": {
-                "description": "list all the groups",
+                "description": "List the registered devices, revoked ones only when includeRevoked is true",
                 "consumes": [
                     "application/json"
                 ],
@@ -1168,10 +1277,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "admin-group"
+                    "admin-user"
                 ],
-                "summary": "ListGroups",
-                "operationId": "ListGroups",
+                "summary": "ListDevicesByAdmin",
                 "parameters": [
                     {
                         "type": "string",
@@ -1182,13 +1290,15 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "multi",
-                        "description": "string collections",
-                        "name": "include",
+                        "type": "string",
+                        "description": "user id",
+                        "name": "userUuid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "include revoked devices",
+                        "name": "includeRevoked",
                         "in": "query"
                     }
                 ],
@@ -1198,7 +1308,7 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.Group"
+                                "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.Device"
                             }
                         }
                     },
@@ -1217,9 +1327,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/admin/group/{id}": {
-            "get": {
-                "description": "Get Group By UUID",
+        "/api/v1/admin/device/{id}": {
+            "delete": {
+                "description": "Revoke a device along with its active clients",
                 "consumes": [
                     "application/json"
                 ],
@@ -1227,10 +1337,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "admin-group"
+                    "admin-user"
                 ],
-                "summary": "Get Group",
-                "operationId": "GetGroup",
+                "summary": "RevokeDeviceByAdmin",
                 "parameters": [
                     {
                         "type": "string",
@@ -1240,19 +1349,9 @@ const docTemplate = `{
                         "in": "header",
                         "required": true
                     },
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "multi",
-                        "description": "string collections",
-                        "name": "include",
-                        "in": "query"
-                    },
                     {
                         "type": "string",
-                        "description": "group id",
+                        "description": "device id",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -1262,10 +1361,13 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.Group"
-                            }
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object"
                         }
                     },
                     "401": {
@@ -1281,9 +1383,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "UpdateGroup",
+            }
+        },
+        "/api/v1/admin/gateway": {
+            "post": {
+                "description": "CreateGateway",
                 "consumes": [
                     "application/json"
                 ],
@@ -1291,9 +1395,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "admin-group"
+                    "admin-gateway"
                 ],
-                "summary": "UpdateGroup",
+                "summary": "CreateGateway",
                 "parameters": [
                     {
                         "type": "string",
@@ -1304,20 +1408,13 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Group details",
-                        "name": "GroupUpdateRequest",
+                        "description": "Gateway details",
+                        "name": "VpnGatewayCreateRequest",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.GroupUpdateRequest"
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.VpnGatewayCreateRequest"
                         }
-                    },
-                    {
-                        "type": "string",
-                        "description": "group id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
                     }
                 ],
                 "responses": {
@@ -1346,9 +1443,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "DeleteGroup",
+            }
+        },
+        "/api/v1/admin/gateway/list": {
+            "get": {
+                "description": "list all the gateways",
                 "consumes": [
                     "application/json"
                 ],
@@ -1356,9 +1455,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "admin-group"
+                    "admin-gateway"
                 ],
-                "summary": "DeleteGroup",
+                "summary": "List gateways",
+                "operationId": "list-gateways",
                 "parameters": [
                     {
                         "type": "string",
@@ -1369,24 +1469,24 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "group id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "string collections",
+                        "name": "include",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "object"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.VpnGateway"
+                            }
                         }
                     },
                     "401": {
@@ -1404,9 +1504,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/admin/group/{id}/{action}/users": {
-            "put": {
-                "description": "AddRemoveUsersInGroup",
+        "/api/v1/admin/gateway/{id}": {
+            "get": {
+                "description": "GetVpnGatewayDetails",
                 "consumes": [
                     "application/json"
                 ],
@@ -1414,9 +1514,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "admin-group"
+                    "admin-gateway"
                 ],
-                "summary": "AddRemoveUsersInGroup",
+                "summary": "GetVpnGatewayDetails",
                 "parameters": [
                     {
                         "type": "string",
@@ -1426,35 +1526,29 @@ const docTemplate = `{
                         "in": "header",
                         "required": true
                     },
-                    {
-                        "description": "Provide details",
-                        "name": "GroupUpdateUserRequest",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.GroupUpdateUserRequest"
-                        }
-                    },
                     {
                         "type": "string",
-                        "description": "group id",
+                        "description": "gateway id",
                         "name": "id",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "action",
-                        "name": "action",
-                        "in": "path",
-                        "required": true
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "string collections",
+                        "name": "include",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object"
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.VpnGateway"
                         }
                     },
                     "400": {
@@ -1476,11 +1570,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/v1/admin/user": {
-            "post": {
-                "description": "RegisterUser",
+            },
+            "put": {
+                "description": "UpdateGateway",
                 "consumes": [
                     "application/json"
                 ],
@@ -1488,9 +1580,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "admin-user"
+                    "admin-gateway"
                 ],
-                "summary": "RegisterUser",
+                "summary": "UpdateGateway",
                 "parameters": [
                     {
                         "type": "string",
@@ -1501,13 +1593,20 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "User details",
-                        "name": "RegisterUserRequest",
+                        "description": "Gateway details",
+                        "name": "VpnGatewayUpdateRequest",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.RegisterUserRequest"
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.VpnGatewayUpdateRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "description": "gateway id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -1536,11 +1635,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/v1/admin/user/list": {
-            "get": {
-                "description": "ListUsers",
+            },
+            "delete": {
+                "description": "DeleteGateway",
                 "consumes": [
                     "application/json"
                 ],
@@ -1548,9 +1645,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "admin-user"
+                    "admin-gateway"
                 ],
-                "summary": "ListUsers",
+                "summary": "DeleteGateway",
                 "parameters": [
                     {
                         "type": "string",
@@ -1561,24 +1658,18 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "multi",
-                        "description": "string collections",
-                        "name": "include",
-                        "in": "query"
+                        "type": "string",
+                        "description": "gateway id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.User"
-                            }
+                            "type": "object"
                         }
                     },
                     "400": {
@@ -1602,9 +1693,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/admin/user/{id}": {
+        "/api/v1/admin/gateway/{id}/deployment-config": {
             "get": {
-                "description": "GetUserDetails",
+                "description": "GetVpnGatewayDeploymentConfig",
                 "consumes": [
                     "application/json"
                 ],
@@ -1612,9 +1703,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "admin-user"
+                    "admin-gateway"
                 ],
-                "summary": "GetUserDetails",
+                "summary": "GetVpnGatewayDeploymentConfig",
                 "parameters": [
                     {
                         "type": "string",
@@ -1624,19 +1715,9 @@ const docTemplate = `{
                         "in": "header",
                         "required": true
                     },
-                    {
-                        "type": "array",
-                        "items": {
-                            "type": "string"
-                        },
-                        "collectionFormat": "multi",
-                        "description": "string collections",
-                        "name": "include",
-                        "in": "query"
-                    },
                     {
                         "type": "string",
-                        "description": "user id",
+                        "description": "gateway id",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -1646,7 +1727,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.User"
+                            "type": "object"
                         }
                     },
                     "400": {
@@ -1668,9 +1749,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "put": {
-                "description": "UpdateUser",
+            }
+        },
+        "/api/v1/admin/gateway/{id}/drift": {
+            "get": {
+                "description": "Get the latest comparison of the peers held by the gateway with its active clients",
                 "consumes": [
                     "application/json"
                 ],
@@ -1678,9 +1761,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "admin-user"
+                    "admin-gateway"
                 ],
-                "summary": "UpdateUser",
+                "summary": "GetGatewayDriftReport",
                 "parameters": [
                     {
                         "type": "string",
@@ -1690,18 +1773,9 @@ const docTemplate = `{
                         "in": "header",
                         "required": true
                     },
-                    {
-                        "description": "User details",
-                        "name": "UpdateUserRequest",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.UpdateUserRequest"
-                        }
-                    },
                     {
                         "type": "string",
-                        "description": "user id",
+                        "description": "gateway id",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -1711,7 +1785,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object"
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.VpnGatewayDriftReport"
                         }
                     },
                     "400": {
@@ -1733,9 +1807,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
-                "description": "DeleteUser",
+            }
+        },
+        "/api/v1/admin/gateway/{id}/ip-allocations/repair": {
+            "put": {
+                "description": "Link the ip allocations of the gateway to their clients and reclaim the addresses leaked by inactive clients",
                 "consumes": [
                     "application/json"
                 ],
@@ -1743,9 +1819,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "admin-user"
+                    "admin-gateway"
                 ],
-                "summary": "DeleteUser",
+                "summary": "RepairGatewayIPAllocations",
                 "parameters": [
                     {
                         "type": "string",
@@ -1757,7 +1833,7 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "user id",
+                        "description": "gateway id",
                         "name": "id",
                         "in": "path",
                         "required": true
@@ -1767,7 +1843,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object"
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.IPAllocationRepairReport"
                         }
                     },
                     "400": {
@@ -1791,9 +1867,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/auth/login": {
-            "post": {
-                "description": "get auth token for given username and password",
+        "/api/v1/admin/gateway/{id}/operations": {
+            "get": {
+                "description": "List the operations queued for delivery to the gateway, newest first",
                 "consumes": [
                     "application/json"
                 ],
@@ -1801,24 +1877,44 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "public"
+                    "admin-gateway"
                 ],
-                "summary": "Auth for User and Admin",
-                "operationId": "auth-for-user-and-admin",
+                "summary": "ListGatewayOperations",
                 "parameters": [
                     {
-                        "description": "Insert your email and password",
-                        "name": "UserLoginRequest",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.UserLoginRequest"
-                        }
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "gateway id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Pending, Succeeded, Failed or Dismissed",
+                        "name": "status",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.GatewayOperation"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object"
                         }
@@ -1838,9 +1934,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/auth/{provider}/sso/callback": {
-            "get": {
-                "description": "UserAuthSSOCallback",
+        "/api/v1/admin/gateway/{id}/operations/{operationId}/dismiss": {
+            "put": {
+                "description": "Give up on a failed gateway operation so that the later operations of the gateway are delivered",
                 "consumes": [
                     "application/json"
                 ],
@@ -1848,15 +1944,29 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "public"
+                    "admin-gateway"
                 ],
-                "summary": "UserAuthSSOCallback",
-                "operationId": "UserAuthSSOCallback",
+                "summary": "DismissGatewayOperation",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "provider",
-                        "name": "provider",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "gateway id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "operation id",
+                        "name": "operationId",
                         "in": "path",
                         "required": true
                     }
@@ -1868,6 +1978,12 @@ const docTemplate = `{
                             "type": "object"
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
@@ -1883,9 +1999,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/auth/{provider}/sso/initiate": {
-            "get": {
-                "description": "InitiateSSOAuth",
+        "/api/v1/admin/gateway/{id}/operations/{operationId}/retry": {
+            "put": {
+                "description": "Queue a failed gateway operation for delivery again",
                 "consumes": [
                     "application/json"
                 ],
@@ -1893,15 +2009,29 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "public"
+                    "admin-gateway"
                 ],
-                "summary": "InitiateSSOAuth",
-                "operationId": "InitiateSSOAuth",
+                "summary": "RetryGatewayOperation",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "provider",
-                        "name": "provider",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "gateway id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "operation id",
+                        "name": "operationId",
                         "in": "path",
                         "required": true
                     }
@@ -1913,6 +2043,12 @@ const docTemplate = `{
                             "type": "object"
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
@@ -1928,9 +2064,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/auth/{provider}/sso/token": {
-            "get": {
-                "description": "UserAuthVerifySSOToken",
+        "/api/v1/admin/gateway/{id}/reconcile": {
+            "put": {
+                "description": "Compare the peers held by the gateway with its active clients now and queue the corrections",
                 "consumes": [
                     "application/json"
                 ],
@@ -1938,15 +2074,22 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "public"
+                    "admin-gateway"
                 ],
-                "summary": "UserAuthVerifySSOToken",
-                "operationId": "UserAuthVerifySSOToken",
+                "summary": "ReconcileGateway",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "provider",
-                        "name": "provider",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "gateway id",
+                        "name": "id",
                         "in": "path",
                         "required": true
                     }
@@ -1954,6 +2097,12 @@ const docTemplate = `{
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.VpnGatewayDriftReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object"
                         }
@@ -1973,9 +2122,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/auth/{provider}/web/sso/initiate": {
+        "/api/v1/admin/gateway/{id}/reservations": {
             "get": {
-                "description": "WebGoogleLoginInitiate",
+                "description": "ListGatewayIPReservations",
                 "consumes": [
                     "application/json"
                 ],
@@ -1983,29 +2132,38 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "public"
+                    "admin-gateway"
                 ],
-                "summary": "WebGoogleLoginInitiate",
-                "operationId": "WebGoogleLoginInitiate",
+                "summary": "ListGatewayIPReservations",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "provider",
-                        "name": "provider",
-                        "in": "path",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "string",
-                        "name": "code_challenge",
-                        "in": "query",
+                        "description": "gateway id",
+                        "name": "id",
+                        "in": "path",
                         "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.IPReservation"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object"
                         }
@@ -2023,11 +2181,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/v1/auth/{provider}/web/sso/token": {
-            "get": {
-                "description": "WebGoogleLoginToken",
+            },
+            "post": {
+                "description": "Reserve an address of the gateway's vpn cidr for the clients of a user",
                 "consumes": [
                     "application/json"
                 ],
@@ -2035,36 +2191,44 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "public"
+                    "admin-gateway"
                 ],
-                "summary": "WebGoogleLoginToken",
-                "operationId": "WebGoogleLoginToken",
+                "summary": "CreateGatewayIPReservation",
                 "parameters": [
                     {
                         "type": "string",
-                        "description": "provider",
-                        "name": "provider",
-                        "in": "path",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "string",
-                        "name": "code_verifier",
-                        "in": "query",
-                        "required": true
+                        "description": "Reservation details",
+                        "name": "IPReservationCreateRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.IPReservationCreateRequest"
+                        }
                     },
                     {
                         "type": "string",
-                        "description": "string",
-                        "name": "code_challenge",
-                        "in": "query",
-                        "required": true
-                    }
+                        "description": "gateway id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.IPReservation"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
                         "schema": {
                             "type": "object"
                         }
@@ -2084,9 +2248,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/client/{id}": {
+        "/api/v1/admin/gateway/{id}/reservations/{reservationId}": {
             "delete": {
-                "description": "DeleteVpnClient",
+                "description": "Return a reserved address to the pool, a client holding it keeps it until deleted",
                 "consumes": [
                     "application/json"
                 ],
@@ -2094,9 +2258,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "admin-gateway"
                 ],
-                "summary": "DeleteVpnClient",
+                "summary": "DeleteGatewayIPReservation",
                 "parameters": [
                     {
                         "type": "string",
@@ -2108,10 +2272,17 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "description": "client id",
+                        "description": "gateway id",
                         "name": "id",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "reservation id",
+                        "name": "reservationId",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -2142,9 +2313,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/gateway/get-gateway-config": {
-            "get": {
-                "description": "GetVpnGatewayWGConfigByGW",
+        "/api/v1/admin/gateway/{id}/reset": {
+            "delete": {
+                "description": "Clear Gateway Clients And IPPool",
                 "consumes": [
                     "application/json"
                 ],
@@ -2152,9 +2323,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "gateway"
+                    "admin-gateway"
                 ],
-                "summary": "GetVpnGatewayWGConfigByGW",
+                "summary": "Reset Gateway",
                 "parameters": [
                     {
                         "type": "string",
@@ -2163,13 +2334,20 @@ const docTemplate = `{
                         "name": "Authorization",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "gateway id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.WGServerConfig"
+                            "type": "object"
                         }
                     },
                     "400": {
@@ -2193,9 +2371,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/gateway/list": {
+        "/api/v1/admin/gateway/{id}/sessions": {
             "get": {
-                "description": "GetVpnGatewaysAccessibleByUser",
+                "description": "List the clients of the gateway with a recent handshake",
                 "consumes": [
                     "application/json"
                 ],
@@ -2203,9 +2381,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "admin-gateway"
                 ],
-                "summary": "GetVpnGatewaysAccessibleByUser",
+                "summary": "ListGatewayActiveSessions",
                 "parameters": [
                     {
                         "type": "string",
@@ -2214,6 +2392,13 @@ const docTemplate = `{
                         "name": "Authorization",
                         "in": "header",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "gateway id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -2222,10 +2407,16 @@ const docTemplate = `{
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.VpnGatewayUserResponse"
+                                "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.ActiveSession"
                             }
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
@@ -2241,9 +2432,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/gateway/{id}/client": {
+        "/api/v1/admin/gateway/{id}/utilization": {
             "get": {
-                "description": "GetVpnClientConfig",
+                "description": "Count the allocated, reserved and available client addresses of the gateway's vpn cidr",
                 "consumes": [
                     "application/json"
                 ],
@@ -2251,9 +2442,9 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "admin-gateway"
                 ],
-                "summary": "GetVpnClientConfig",
+                "summary": "GetGatewayIPUtilization",
                 "parameters": [
                     {
                         "type": "string",
@@ -2275,7 +2466,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.WGClientConfig"
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.IPUtilizationReport"
                         }
                     },
                     "400": {
@@ -2299,9 +2490,57 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/gateway/{id}/health": {
+        "/api/v1/admin/group": {
+            "post": {
+                "description": "CreateGroup",
+                "tags": [
+                    "admin-group"
+                ],
+                "summary": "CreateGroup",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Group details",
+                        "name": "GroupCreateRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.GroupCreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/group/list": {
             "get": {
-                "description": "get health of the gateway by vpnGatewayUuid",
+                "description": "list all the groups",
                 "consumes": [
                     "application/json"
                 ],
@@ -2309,10 +2548,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user"
+                    "admin-group"
                 ],
-                "summary": "Gateway Health Check",
-                "operationId": "gateway-health-check",
+                "summary": "ListGroups",
+                "operationId": "ListGroups",
                 "parameters": [
                     {
                         "type": "string",
@@ -2323,24 +2562,24 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "gateway id",
-                        "name": "id",
-                        "in": "path",
-                        "required": true
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "string collections",
+                        "name": "include",
+                        "in": "query"
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object"
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "object"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.Group"
+                            }
                         }
                     },
                     "401": {
@@ -2349,12 +2588,6 @@ const docTemplate = `{
                             "type": "object"
                         }
                     },
-                    "404": {
-                        "description": "Not Found",
-                        "schema": {
-                            "type": "object"
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -2364,9 +2597,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/v1/health": {
+        "/api/v1/admin/group/{id}": {
             "get": {
-                "description": "get health of the controller",
+                "description": "Get Group By UUID",
                 "consumes": [
                     "application/json"
                 ],
@@ -2374,23 +2607,63 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "public"
+                    "admin-group"
+                ],
+                "summary": "Get Group",
+                "operationId": "GetGroup",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "string collections",
+                        "name": "include",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "group id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "Controller Health Check",
-                "operationId": "controller-health-check",
                 "responses": {
                     "200": {
                         "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.Group"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "type": "object"
                         }
                     }
                 }
-            }
-        },
-        "/api/v1/sso-config": {
-            "get": {
-                "description": "get-client-ids-of-sso-allowed",
+            },
+            "put": {
+                "description": "UpdateGroup",
                 "consumes": [
                     "application/json"
                 ],
@@ -2398,10 +2671,35 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "public"
+                    "admin-group"
+                ],
+                "summary": "UpdateGroup",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Group details",
+                        "name": "GroupUpdateRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.GroupUpdateRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "group id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
                 ],
-                "summary": "SSO Configs",
-                "operationId": "sso-configs",
                 "responses": {
                     "200": {
                         "description": "OK",
@@ -2409,6 +2707,12 @@ const docTemplate = `{
                             "type": "object"
                         }
                     },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
                     "401": {
                         "description": "Unauthorized",
                         "schema": {
@@ -2422,74 +2726,3870 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        }
-    },
-    "definitions": {
+            },
+            "delete": {
+                "description": "DeleteGroup",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin-group"
+                ],
+                "summary": "DeleteGroup",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "group id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/group/{id}/{action}/users": {
+            "put": {
+                "description": "AddRemoveUsersInGroup",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin-group"
+                ],
+                "summary": "AddRemoveUsersInGroup",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Provide details",
+                        "name": "GroupUpdateUserRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.GroupUpdateUserRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "group id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "action",
+                        "name": "action",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/policy": {
+            "post": {
+                "description": "Allow or deny a user or group traffic to a destination through a gateway",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin-policy"
+                ],
+                "summary": "CreateAccessPolicy",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Policy details",
+                        "name": "AccessPolicyCreateRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.AccessPolicyCreateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.AccessPolicy"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/policy/list": {
+            "get": {
+                "description": "List the access policies, ordered by gateway and evaluation priority",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin-policy"
+                ],
+                "summary": "ListAccessPolicies",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "gateway id",
+                        "name": "gatewayUuid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "user id",
+                        "name": "userUuid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "group id",
+                        "name": "groupUuid",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.AccessPolicy"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/policy/{id}": {
+            "get": {
+                "description": "GetAccessPolicyByUUID",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin-policy"
+                ],
+                "summary": "GetAccessPolicyByUUID",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "policy id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.AccessPolicy"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "UpdateAccessPolicy",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin-policy"
+                ],
+                "summary": "UpdateAccessPolicy",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Policy details",
+                        "name": "AccessPolicyUpdateRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.AccessPolicyUpdateRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "policy id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "DeleteAccessPolicy",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin-policy"
+                ],
+                "summary": "DeleteAccessPolicy",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "policy id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/user": {
+            "post": {
+                "description": "RegisterUser",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin-user"
+                ],
+                "summary": "RegisterUser",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "User details",
+                        "name": "RegisterUserRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.RegisterUserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/user/list": {
+            "get": {
+                "description": "ListUsers",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin-user"
+                ],
+                "summary": "ListUsers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "string collections",
+                        "name": "include",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.User"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/user/{id}": {
+            "get": {
+                "description": "GetUserDetails",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin-user"
+                ],
+                "summary": "GetUserDetails",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "array",
+                        "items": {
+                            "type": "string"
+                        },
+                        "collectionFormat": "multi",
+                        "description": "string collections",
+                        "name": "include",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "UpdateUser",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin-user"
+                ],
+                "summary": "UpdateUser",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "User details",
+                        "name": "UpdateUserRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.UpdateUserRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "DeleteUser",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin-user"
+                ],
+                "summary": "DeleteUser",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/admin/user/{id}/sessions": {
+            "get": {
+                "description": "List the clients of the user with a recent handshake",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "admin-user"
+                ],
+                "summary": "ListUserActiveSessions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.ActiveSession"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/auth/login": {
+            "post": {
+                "description": "get auth token for given username and password",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "public"
+                ],
+                "summary": "Auth for User and Admin",
+                "operationId": "auth-for-user-and-admin",
+                "parameters": [
+                    {
+                        "description": "Insert your email and password",
+                        "name": "UserLoginRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.UserLoginRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/auth/saml/{provider}/acs": {
+            "post": {
+                "description": "Assertion consumer service receiving the SAML response of the identity provider",
+                "consumes": [
+                    "application/x-www-form-urlencoded"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "public"
+                ],
+                "summary": "SAMLAssertionConsumer",
+                "operationId": "SAMLAssertionConsumer",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "provider",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "SAML response",
+                        "name": "SAMLResponse",
+                        "in": "formData",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "relay state",
+                        "name": "RelayState",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/auth/saml/{provider}/login": {
+            "get": {
+                "description": "Redirect to the SAML identity provider, the login completes through /api/v1/auth/{provider}/web/sso/token",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "public"
+                ],
+                "summary": "SAMLLoginInitiate",
+                "operationId": "SAMLLoginInitiate",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "provider",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "string",
+                        "name": "code_challenge",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "302": {
+                        "description": "Found",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/auth/saml/{provider}/metadata": {
+            "get": {
+                "description": "Service provider metadata to register the controller with the SAML identity provider",
+                "produces": [
+                    "text/xml"
+                ],
+                "tags": [
+                    "public"
+                ],
+                "summary": "SAMLMetadata",
+                "operationId": "SAMLMetadata",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "provider",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/auth/{provider}/sso/callback": {
+            "get": {
+                "description": "UserAuthSSOCallback",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "public"
+                ],
+                "summary": "UserAuthSSOCallback",
+                "operationId": "UserAuthSSOCallback",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "provider",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "authorization code",
+                        "name": "code",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "state",
+                        "name": "state",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "code verifier",
+                        "name": "code_verifier",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/auth/{provider}/sso/initiate": {
+            "get": {
+                "description": "Redirect to the authorization endpoint of the OIDC provider for an app doing PKCE",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "public"
+                ],
+                "summary": "InitiateSSOAuth",
+                "operationId": "InitiateSSOAuth",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "provider",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "platform",
+                        "name": "platform",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "code challenge",
+                        "name": "code_challenge",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "redirect uri",
+                        "name": "redirect_uri",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "client id of the sso config of the app",
+                        "name": "client_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "S256 by default",
+                        "name": "code_challenge_method",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/auth/{provider}/sso/token": {
+            "get": {
+                "description": "UserAuthVerifySSOToken",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "public"
+                ],
+                "summary": "UserAuthVerifySSOToken",
+                "operationId": "UserAuthVerifySSOToken",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "provider",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "id token issued by the provider",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/auth/{provider}/web/sso/initiate": {
+            "get": {
+                "description": "Redirect to the authorization endpoint of the OIDC provider with the Website sso config",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "public"
+                ],
+                "summary": "WebSSOLoginInitiate",
+                "operationId": "WebSSOLoginInitiate",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "provider",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "string",
+                        "name": "code_challenge",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/auth/{provider}/web/sso/token": {
+            "get": {
+                "description": "WebSSOLoginToken",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "public"
+                ],
+                "summary": "WebSSOLoginToken",
+                "operationId": "WebSSOLoginToken",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "provider",
+                        "name": "provider",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "string",
+                        "name": "code_verifier",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "string",
+                        "name": "code_challenge",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/client/{id}": {
+            "delete": {
+                "description": "DeleteVpnClient",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "DeleteVpnClient",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "client id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/client/{id}/renew": {
+            "post": {
+                "description": "Extend the expiry of a client within the lifetime limits, keeping its key and address.\nRequires a recent login.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "RenewVpnClient",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "client id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Requested lifetime",
+                        "name": "ClientRenewRequest",
+                        "in": "body",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.ClientRenewRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.ClientRenewResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/device": {
+            "post": {
+                "description": "Register a device with the public key of a wireguard key pair generated on it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "RegisterDevice",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Device details",
+                        "name": "DeviceRegisterRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.DeviceRegisterRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.Device"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/device/list": {
+            "get": {
+                "description": "List the devices of the logged in user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "ListDevices",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.Device"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/device/{id}": {
+            "delete": {
+                "description": "Revoke a device of the logged in user along with its active clients",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "RevokeDevice",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "device id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/gateway/get-gateway-config": {
+            "get": {
+                "description": "GetVpnGatewayWGConfigByGW",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "gateway"
+                ],
+                "summary": "GetVpnGatewayWGConfigByGW",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.WGServerConfig"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/gateway/heartbeat": {
+            "post": {
+                "description": "Report the version, uptime, public ip and wireguard interface state of the gateway",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "gateway"
+                ],
+                "summary": "VpnGatewayHeartbeat",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Gateway state",
+                        "name": "VpnGatewayHeartbeatRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.VpnGatewayHeartbeatRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/gateway/list": {
+            "get": {
+                "description": "GetVpnGatewaysAccessibleByUser",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "GetVpnGatewaysAccessibleByUser",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.VpnGatewayUserResponse"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/gateway/peer-stats": {
+            "post": {
+                "description": "Report the latest handshake, traffic and endpoint of the peers of the gateway",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "gateway"
+                ],
+                "summary": "VpnGatewayPeerStats",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "Peer stats",
+                        "name": "VpnGatewayPeerStatsRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.VpnGatewayPeerStatsRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.VpnGatewayPeerStatsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/gateway/{id}/client": {
+            "get": {
+                "description": "Create a client on the gateway and return its config as json, a wg-quick config file or a png qr code.\nThe format is taken from the format query parameter, or else negotiated from the Accept header.\nA client over the client limits of the gateway is rejected with 409, unless the gateway revokes the oldest clients of the user.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json",
+                    "text/plain",
+                    "image/png"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "GetVpnClientConfig",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "gateway id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "json, conf or qr",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "registered device id, the config then carries no private key",
+                        "name": "device",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "requested lifetime in minutes, capped by the gateway, group and user limits",
+                        "name": "lifetime",
+                        "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "issue a preshared key on a gateway where it is optional, always issued where it is required",
+                        "name": "presharedKey",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.WGClientConfig"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "503": {
+                        "description": "Service Unavailable",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/gateway/{id}/health": {
+            "get": {
+                "description": "get health of the gateway by vpnGatewayUuid",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "Gateway Health Check",
+                "operationId": "gateway-health-check",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "gateway id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/health": {
+            "get": {
+                "description": "get health of the controller",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "public"
+                ],
+                "summary": "Controller Health Check",
+                "operationId": "controller-health-check",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/sessions": {
+            "get": {
+                "description": "List the clients of the logged in user with a recent handshake",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user"
+                ],
+                "summary": "GetActiveSessions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.ActiveSession"
+                            }
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/api/v1/sso-config": {
+            "get": {
+                "description": "get-client-ids-of-sso-allowed",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "public"
+                ],
+                "summary": "SSO Configs",
+                "operationId": "sso-configs",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object"
+                        }
+                    }
+                }
+            }
+        },
+        "/scim/v2/Groups": {
+            "get": {
+                "description": "list the groups, filtered with attribute expressions joined by and, e.g. displayName eq \"Oncall\"",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "ListSCIMGroups",
+                "operationId": "ListSCIMGroups",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your scim token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "scim filter",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "1-based index of the first result",
+                        "name": "startIndex",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "results per page, at most 1000",
+                        "name": "count",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "attributes left out of the results",
+                        "name": "excludedAttributes",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "provision a group with its members, the members are user ids",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "CreateSCIMGroup",
+                "operationId": "CreateSCIMGroup",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your scim token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "group",
+                        "name": "SCIMGroup",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMGroup"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMGroup"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scim/v2/Groups/{id}": {
+            "get": {
+                "description": "GetSCIMGroup",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "GetSCIMGroup",
+                "operationId": "GetSCIMGroup",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your scim token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "group id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMGroup"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "ReplaceSCIMGroup",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "ReplaceSCIMGroup",
+                "operationId": "ReplaceSCIMGroup",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your scim token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "group id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "group",
+                        "name": "SCIMGroup",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMGroup"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMGroup"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "DeleteSCIMGroup",
+                "tags": [
+                    "scim"
+                ],
+                "summary": "DeleteSCIMGroup",
+                "operationId": "DeleteSCIMGroup",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your scim token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "group id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "PatchSCIMGroup",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "PatchSCIMGroup",
+                "operationId": "PatchSCIMGroup",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your scim token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "group id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "patch operations",
+                        "name": "SCIMPatchRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMPatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMGroup"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scim/v2/ServiceProviderConfig": {
+            "get": {
+                "description": "scim features supported by the controller",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "SCIMServiceProviderConfig",
+                "operationId": "SCIMServiceProviderConfig",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your scim token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scim/v2/Users": {
+            "get": {
+                "description": "list the users, filtered with attribute expressions joined by and, e.g. userName eq \"jane@example.com\"",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "ListSCIMUsers",
+                "operationId": "ListSCIMUsers",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your scim token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "scim filter",
+                        "name": "filter",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "1-based index of the first result",
+                        "name": "startIndex",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "results per page, at most 1000",
+                        "name": "count",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "attributes left out of the results",
+                        "name": "excludedAttributes",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMListResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "provision a user signing in with sso, the userName is the email of the user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "CreateSCIMUser",
+                "operationId": "CreateSCIMUser",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your scim token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "description": "user",
+                        "name": "SCIMUser",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMUser"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMUser"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/scim/v2/Users/{id}": {
+            "get": {
+                "description": "GetSCIMUser",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "GetSCIMUser",
+                "operationId": "GetSCIMUser",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your scim token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMUser"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "ReplaceSCIMUser",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "ReplaceSCIMUser",
+                "operationId": "ReplaceSCIMUser",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your scim token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "user",
+                        "name": "SCIMUser",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMUser"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMUser"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "deprovision the user, its active clients are revoked on every gateway",
+                "tags": [
+                    "scim"
+                ],
+                "summary": "DeleteSCIMUser",
+                "operationId": "DeleteSCIMUser",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your scim token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content",
+                        "schema": {
+                            "type": "object"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "description": "patch the user, setting active to false deactivates the user and revokes its active clients on every gateway",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "scim"
+                ],
+                "summary": "PatchSCIMUser",
+                "operationId": "PatchSCIMUser",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "Bearer \u003ctoken\u003e",
+                        "description": "Insert your scim token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "user id",
+                        "name": "id",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "patch operations",
+                        "name": "SCIMPatchRequest",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMPatchRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMUser"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    },
+                    "401": {
+                        "description": "Unauthorized",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "github_com_leetsecure_qryptic-controller_internal_models.AccessPolicy": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.AccessPolicyActionEnum"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "destination": {
+                    "type": "string"
+                },
+                "group": {
+                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.Group"
+                },
+                "groupId": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "portFrom": {
+                    "description": "tcp and udp only, 0 for all ports",
+                    "type": "integer"
+                },
+                "portTo": {
+                    "type": "integer"
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "protocol": {
+                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.AccessPolicyProtocolEnum"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.User"
+                },
+                "userId": {
+                    "type": "integer"
+                },
+                "uuid": {
+                    "type": "string"
+                },
+                "vpnGateway": {
+                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.VpnGateway"
+                },
+                "vpnGatewayId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.AccessPolicyActionEnum": {
+            "type": "string",
+            "enum": [
+                "Allow",
+                "Deny"
+            ],
+            "x-enum-varnames": [
+                "AccessPolicyAllow",
+                "AccessPolicyDeny"
+            ]
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.AccessPolicyCreateRequest": {
+            "type": "object",
+            "required": [
+                "action",
+                "destination",
+                "name",
+                "vpnGatewayUuid"
+            ],
+            "properties": {
+                "action": {
+                    "enum": [
+                        "Allow",
+                        "Deny"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.AccessPolicyActionEnum"
+                        }
+                    ]
+                },
+                "destination": {
+                    "type": "string"
+                },
+                "groupUuid": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "portFrom": {
+                    "type": "integer",
+                    "maximum": 65535,
+                    "minimum": 0
+                },
+                "portTo": {
+                    "type": "integer",
+                    "maximum": 65535,
+                    "minimum": 0
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "protocol": {
+                    "enum": [
+                        "any",
+                        "tcp",
+                        "udp",
+                        "icmp"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.AccessPolicyProtocolEnum"
+                        }
+                    ]
+                },
+                "userUuid": {
+                    "type": "string"
+                },
+                "vpnGatewayUuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.AccessPolicyProtocolEnum": {
+            "type": "string",
+            "enum": [
+                "any",
+                "tcp",
+                "udp",
+                "icmp"
+            ],
+            "x-enum-varnames": [
+                "AccessPolicyProtocolAny",
+                "AccessPolicyProtocolTCP",
+                "AccessPolicyProtocolUDP",
+                "AccessPolicyProtocolICMP"
+            ]
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.AccessPolicyUpdateRequest": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "enum": [
+                        "Allow",
+                        "Deny"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.AccessPolicyActionEnum"
+                        }
+                    ]
+                },
+                "destination": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "portFrom": {
+                    "type": "integer",
+                    "maximum": 65535,
+                    "minimum": 0
+                },
+                "portTo": {
+                    "type": "integer",
+                    "maximum": 65535,
+                    "minimum": 0
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "protocol": {
+                    "enum": [
+                        "any",
+                        "tcp",
+                        "udp",
+                        "icmp"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.AccessPolicyProtocolEnum"
+                        }
+                    ]
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.ActiveSession": {
+            "type": "object",
+            "properties": {
+                "allocatedIP": {
+                    "type": "string"
+                },
+                "clientUuid": {
+                    "type": "string"
+                },
+                "endpoint": {
+                    "type": "string"
+                },
+                "lastHandshakeAt": {
+                    "type": "string"
+                },
+                "rxBytes": {
+                    "type": "integer"
+                },
+                "txBytes": {
+                    "type": "integer"
+                },
+                "userEmail": {
+                    "type": "string"
+                },
+                "userName": {
+                    "type": "string"
+                },
+                "userUuid": {
+                    "type": "string"
+                },
+                "vpnGatewayName": {
+                    "type": "string"
+                },
+                "vpnGatewayUuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.AddSamlSsoConfigRequest": {
+            "type": "object",
+            "required": [
+                "domain",
+                "idpMetadata",
+                "provider"
+            ],
+            "properties": {
+                "domain": {
+                    "type": "string"
+                },
+                "emailAttribute": {
+                    "description": "EmailAttribute and NameAttribute name the assertion attributes mapped to the user, the email falls\nback to the common email attributes and then to the name id",
+                    "type": "string"
+                },
+                "groupAttribute": {
+                    "description": "the common group attributes are tried when empty",
+                    "type": "string"
+                },
+                "idpMetadata": {
+                    "description": "IdpMetadata is the metadata xml downloaded from the identity provider",
+                    "type": "string"
+                },
+                "nameAttribute": {
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string"
+                }
+            }
+        },
         "github_com_leetsecure_qryptic-controller_internal_models.AddSsoConfigRequest": {
             "type": "object",
-            "required": [
-                "clientID",
-                "clientSecret",
-                "domain",
-                "platform",
-                "provider"
-            ],
+            "required": [
+                "clientID",
+                "clientSecret",
+                "domain",
+                "platform",
+                "provider"
+            ],
+            "properties": {
+                "clientID": {
+                    "type": "string"
+                },
+                "clientSecret": {
+                    "type": "string"
+                },
+                "domain": {
+                    "type": "string"
+                },
+                "emailClaim": {
+                    "type": "string"
+                },
+                "groupClaim": {
+                    "description": "claim holding the groups of the user, groups when empty",
+                    "type": "string"
+                },
+                "issuerURL": {
+                    "description": "IssuerURL is required for every provider but google",
+                    "type": "string"
+                },
+                "platform": {
+                    "type": "string"
+                },
+                "provider": {
+                    "type": "string"
+                },
+                "scopes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "trustEmailClaim": {
+                    "description": "TrustEmailClaim accepts emails without an email_verified claim, only for providers that don't let\nusers set their own email",
+                    "type": "boolean"
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.AuditActionEnum": {
+            "type": "string",
+            "enum": [
+                "auth.login",
+                "auth.sso-login",
+                "user.create",
+                "user.jit-create",
+                "user.update",
+                "user.delete",
+                "group.create",
+                "group.update",
+                "group.delete",
+                "group.add-user",
+                "group.remove-user",
+                "gateway.create",
+                "gateway.update",
+                "gateway.delete",
+                "gateway.reset",
+                "access.grant-user",
+                "access.revoke-user",
+                "access.grant-group",
+                "access.revoke-group",
+                "client.create",
+                "client.delete",
+                "client.expire",
+                "client.renew",
+                "client.limit-reject",
+                "client.limit-revoke",
+                "config.sso-add",
+                "config.sso-delete",
+                "config.saml-metadata-update",
+                "config.sso-group-mapping-add",
+                "config.sso-group-mapping-delete",
+                "config.sso-jit-update",
+                "config.scim-token-rotate",
+                "config.scim-token-revoke",
+                "user.deactivate",
+                "user.activate",
+                "config.password-login-update",
+                "config.sso-login-update",
+                "gateway.operation-retry",
+                "gateway.operation-dismiss",
+                "gateway.reconcile",
+                "gateway.register",
+                "gateway.version-change",
+                "gateway.key-rotate",
+                "policy.create",
+                "policy.update",
+                "policy.delete",
+                "reservation.create",
+                "reservation.delete",
+                "gateway.ip-allocation-repair",
+                "device.register",
+                "device.revoke"
+            ],
+            "x-enum-varnames": [
+                "AuditActionLogin",
+                "AuditActionSSOLogin",
+                "AuditActionUserCreate",
+                "AuditActionUserJITCreate",
+                "AuditActionUserUpdate",
+                "AuditActionUserDelete",
+                "AuditActionGroupCreate",
+                "AuditActionGroupUpdate",
+                "AuditActionGroupDelete",
+                "AuditActionGroupAddUser",
+                "AuditActionGroupRemoveUser",
+                "AuditActionGatewayCreate",
+                "AuditActionGatewayUpdate",
+                "AuditActionGatewayDelete",
+                "AuditActionGatewayReset",
+                "AuditActionAccessGrantUser",
+                "AuditActionAccessRevokeUser",
+                "AuditActionAccessGrantGroup",
+                "AuditActionAccessRevokeGroup",
+                "AuditActionClientCreate",
+                "AuditActionClientDelete",
+                "AuditActionClientExpire",
+                "AuditActionClientRenew",
+                "AuditActionClientLimitReject",
+                "AuditActionClientLimitRevoke",
+                "AuditActionSSOConfigAdd",
+                "AuditActionSSOConfigDelete",
+                "AuditActionSAMLMetadataUpdate",
+                "AuditActionSSOGroupMappingAdd",
+                "AuditActionSSOGroupMappingDelete",
+                "AuditActionSSOJITConfigUpdate",
+                "AuditActionSCIMTokenRotate",
+                "AuditActionSCIMTokenRevoke",
+                "AuditActionUserDeactivate",
+                "AuditActionUserActivate",
+                "AuditActionPasswordLoginConfigUpdate",
+                "AuditActionSSOLoginConfigUpdate",
+                "AuditActionGatewayOperationRetry",
+                "AuditActionGatewayOperationDismiss",
+                "AuditActionGatewayReconcile",
+                "AuditActionGatewayRegister",
+                "AuditActionGatewayVersionChange",
+                "AuditActionGatewayKeyRotate",
+                "AuditActionPolicyCreate",
+                "AuditActionPolicyUpdate",
+                "AuditActionPolicyDelete",
+                "AuditActionReservationCreate",
+                "AuditActionReservationDelete",
+                "AuditActionIPAllocationRepair",
+                "AuditActionDeviceRegister",
+                "AuditActionDeviceRevoke"
+            ]
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.AuditActorTypeEnum": {
+            "type": "string",
+            "enum": [
+                "User",
+                "Gateway",
+                "System",
+                "SCIM"
+            ],
+            "x-enum-varnames": [
+                "AuditActorUser",
+                "AuditActorGateway",
+                "AuditActorSystem",
+                "AuditActorSCIM"
+            ]
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.AuditTrail": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.AuditActionEnum"
+                },
+                "actorType": {
+                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.AuditActorTypeEnum"
+                },
+                "actorUuid": {
+                    "description": "uuid of the user or gateway performing the action, empty for system",
+                    "type": "string"
+                },
+                "client": {
+                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.Client"
+                },
+                "clientId": {
+                    "type": "integer"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "group": {
+                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.Group"
+                },
+                "groupId": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "timestamp": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.User"
+                },
+                "userId": {
+                    "description": "user affected by the action",
+                    "type": "integer"
+                },
+                "uuid": {
+                    "type": "string"
+                },
+                "vpnGateway": {
+                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.VpnGateway"
+                },
+                "vpnGatewayId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.AuditTrailListResponse": {
+            "type": "object",
+            "properties": {
+                "auditTrails": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.AuditTrail"
+                    }
+                },
+                "page": {
+                    "type": "integer"
+                },
+                "pageSize": {
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.Client": {
+            "type": "object",
+            "properties": {
+                "allocatedIP": {
+                    "type": "string"
+                },
+                "allocatedIPv6": {
+                    "type": "string"
+                },
+                "allowedIPs": {
+                    "type": "string"
+                },
+                "clientPublicKey": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "device": {
+                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.Device"
+                },
+                "deviceId": {
+                    "description": "set when issued against a registered device",
+                    "type": "integer"
+                },
+                "dnsServer": {
+                    "type": "string"
+                },
+                "endpoint": {
+                    "type": "string"
+                },
+                "expiryTime": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ipAllocated": {
+                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.IPAllocation"
+                },
+                "is_active": {
+                    "type": "boolean"
+                },
+                "lastHandshakeAt": {
+                    "description": "reported by the gateway in its peer stats",
+                    "type": "string"
+                },
+                "lastUsedAt": {
+                    "description": "last time the handshake or the traffic counters moved",
+                    "type": "string"
+                },
+                "preshared_key": {
+                    "type": "string"
+                },
+                "rxBytes": {
+                    "type": "integer"
+                },
+                "txBytes": {
+                    "type": "integer"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.User"
+                },
+                "userId": {
+                    "type": "integer"
+                },
+                "uuid": {
+                    "type": "string"
+                },
+                "vpnGateway": {
+                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.VpnGateway"
+                },
+                "vpnGatewayId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.ClientLimitPolicyEnum": {
+            "type": "string",
+            "enum": [
+                "Reject",
+                "RevokeOldest"
+            ],
+            "x-enum-comments": {
+                "ClientLimitRevokeOldest": "revokes the oldest clients of the same user only"
+            },
+            "x-enum-varnames": [
+                "ClientLimitReject",
+                "ClientLimitRevokeOldest"
+            ]
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.ClientRenewRequest": {
+            "type": "object",
+            "properties": {
+                "lifetime": {
+                    "description": "Lifetime in minutes from now, the longest lifetime allowed when 0",
+                    "type": "integer",
+                    "minimum": 0
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.ClientRenewResponse": {
+            "type": "object",
+            "properties": {
+                "clientUuid": {
+                    "type": "string"
+                },
+                "expiryTime": {
+                    "type": "string"
+                },
+                "previousExpiryTime": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.Device": {
+            "type": "object",
+            "properties": {
+                "clients": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.Client"
+                    }
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastSeenAt": {
+                    "description": "last client issued or handshake of one of its clients",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "os": {
+                    "type": "string"
+                },
+                "publicKey": {
+                    "type": "string"
+                },
+                "revokedAt": {
+                    "type": "string"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "user": {
+                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.User"
+                },
+                "userId": {
+                    "type": "integer"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.DeviceRegisterRequest": {
+            "type": "object",
+            "required": [
+                "name",
+                "publicKey"
+            ],
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "os": {
+                    "type": "string"
+                },
+                "publicKey": {
+                    "description": "PublicKey is the wireguard public key generated on the device, the private key never leaves it",
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.DriftStatusEnum": {
+            "type": "string",
+            "enum": [
+                "InSync",
+                "Drifted",
+                "Unreachable"
+            ],
+            "x-enum-varnames": [
+                "DriftStatusInSync",
+                "DriftStatusDrifted",
+                "DriftStatusUnreachable"
+            ]
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.FirewallRule": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.AccessPolicyActionEnum"
+                },
+                "destination": {
+                    "type": "string"
+                },
+                "portFrom": {
+                    "type": "integer"
+                },
+                "portTo": {
+                    "type": "integer"
+                },
+                "protocol": {
+                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.AccessPolicyProtocolEnum"
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.GatewayHealthStatusEnum": {
+            "type": "string",
+            "enum": [
+                "Unknown",
+                "Up",
+                "Down"
+            ],
+            "x-enum-varnames": [
+                "GatewayHealthUnknown",
+                "GatewayHealthUp",
+                "GatewayHealthDown"
+            ]
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.GatewayOperation": {
+            "type": "object",
+            "properties": {
+                "attempts": {
+                    "type": "integer"
+                },
+                "completedAt": {
+                    "type": "string"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "lastError": {
+                    "type": "string"
+                },
+                "nextAttemptAt": {
+                    "type": "string"
+                },
+                "operation": {
+                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.GatewayOperationTypeEnum"
+                },
+                "peers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.WGServerPeerConfig"
+                    }
+                },
+                "status": {
+                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.GatewayOperationStatusEnum"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                },
+                "vpnGatewayId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.GatewayOperationStatusEnum": {
+            "type": "string",
+            "enum": [
+                "Pending",
+                "Succeeded",
+                "Failed",
+                "Dismissed"
+            ],
+            "x-enum-varnames": [
+                "GatewayOperationPending",
+                "GatewayOperationSucceeded",
+                "GatewayOperationFailed",
+                "GatewayOperationDismissed"
+            ]
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.GatewayOperationTypeEnum": {
+            "type": "string",
+            "enum": [
+                "add-peers",
+                "delete-peers",
+                "restart",
+                "update-peers"
+            ],
+            "x-enum-varnames": [
+                "GatewayOperationAddPeers",
+                "GatewayOperationDeletePeers",
+                "GatewayOperationRestart",
+                "GatewayOperationUpdatePeers"
+            ]
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.GatewayUpdateGroupRequest": {
+            "type": "object",
+            "required": [
+                "groupUuids"
+            ],
+            "properties": {
+                "groupUuids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.Group": {
+            "type": "object",
+            "properties": {
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "externalId": {
+                    "description": "id of the group in the provisioning client",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "maxClientLifetime": {
+                    "description": "MaxClientLifetime in minutes caps the clients on the gateways granted through the group, 0 for no cap",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "routes": {
+                    "description": "narrows the routes of the gateways granted through the group",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.User"
+                    }
+                },
+                "uuid": {
+                    "type": "string"
+                },
+                "vpnGateways": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.VpnGateway"
+                    }
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.GroupCreateRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "maxClientLifetime": {
+                    "description": "MaxClientLifetime in minutes caps the clients on the gateways granted through the group",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "name": {
+                    "type": "string"
+                },
+                "routes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.GroupUpdateRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "maxClientLifetime": {
+                    "description": "MaxClientLifetime replaces the cap when present, 0 removes it",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "name": {
+                    "type": "string"
+                },
+                "routes": {
+                    "description": "Routes replace the routes of the group when present, an empty list removes the narrowing",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.GroupUpdateUserRequest": {
+            "type": "object",
+            "required": [
+                "userUuids"
+            ],
+            "properties": {
+                "userUuids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.IPAllocation": {
+            "type": "object",
             "properties": {
-                "clientID": {
-                    "type": "string"
+                "client": {
+                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.Client"
                 },
-                "clientSecret": {
-                    "type": "string"
+                "clientId": {
+                    "description": "set in the transaction creating the client",
+                    "type": "integer"
                 },
-                "domain": {
+                "createdAt": {
                     "type": "string"
                 },
-                "platform": {
-                    "type": "string"
+                "id": {
+                    "type": "integer"
                 },
-                "provider": {
+                "ip": {
                     "type": "string"
+                },
+                "vpngatewayID": {
+                    "type": "integer"
                 }
             }
         },
-        "github_com_leetsecure_qryptic-controller_internal_models.Client": {
+        "github_com_leetsecure_qryptic-controller_internal_models.IPAllocationRepairReport": {
             "type": "object",
             "properties": {
-                "allocatedIP": {
-                    "type": "string"
+                "linked": {
+                    "description": "allocations matched to the active client holding the address",
+                    "type": "integer"
                 },
-                "allowedIPs": {
-                    "type": "string"
+                "reclaimed": {
+                    "description": "allocations of inactive or deleted clients released",
+                    "type": "integer"
                 },
-                "clientPrivateKey": {
-                    "type": "string"
+                "restored": {
+                    "description": "allocations recorded for active clients missing one",
+                    "type": "integer"
                 },
-                "clientPublicKey": {
+                "vpnGatewayUuid": {
                     "type": "string"
-                },
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.IPReservation": {
+            "type": "object",
+            "properties": {
                 "createdAt": {
                     "type": "string"
                 },
-                "deletedAt": {
-                    "$ref": "#/definitions/gorm.DeletedAt"
-                },
-                "dnsServer": {
+                "description": {
                     "type": "string"
                 },
-                "expiryTime": {
-                    "type": "string"
+                "device": {
+                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.Device"
                 },
-                "id": {
+                "deviceId": {
+                    "description": "set for reservations of a device",
                     "type": "integer"
                 },
-                "is_active": {
-                    "type": "boolean"
-                },
-                "preshared_key": {
-                    "type": "string"
+                "id": {
+                    "type": "integer"
                 },
-                "updatedAt": {
+                "ip": {
                     "type": "string"
                 },
                 "user": {
@@ -2501,92 +6601,257 @@ const docTemplate = `{
                 "uuid": {
                     "type": "string"
                 },
-                "vpnGateway": {
-                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.VpnGateway"
-                },
                 "vpnGatewayId": {
                     "type": "integer"
                 }
             }
         },
-        "github_com_leetsecure_qryptic-controller_internal_models.GatewayUpdateGroupRequest": {
+        "github_com_leetsecure_qryptic-controller_internal_models.IPReservationCreateRequest": {
             "type": "object",
             "required": [
-                "groupUuids"
+                "ip"
             ],
             "properties": {
-                "groupUuids": {
+                "description": {
+                    "type": "string"
+                },
+                "deviceUuid": {
+                    "type": "string"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "userUuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.IPUtilizationReport": {
+            "type": "object",
+            "properties": {
+                "allocated": {
+                    "type": "integer"
+                },
+                "available": {
+                    "type": "integer"
+                },
+                "reserved": {
+                    "description": "addresses in reserved ranges",
+                    "type": "integer"
+                },
+                "reservedRanges": {
                     "type": "array",
                     "items": {
                         "type": "string"
                     }
+                },
+                "staticReservations": {
+                    "description": "reservations outside reserved ranges, in use or not",
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "integer"
+                },
+                "utilizationPercent": {
+                    "type": "number"
+                },
+                "vpnCIDR": {
+                    "type": "string"
+                },
+                "vpnGatewayUuid": {
+                    "type": "string"
                 }
             }
         },
-        "github_com_leetsecure_qryptic-controller_internal_models.Group": {
+        "github_com_leetsecure_qryptic-controller_internal_models.PresharedKeyPolicyEnum": {
+            "type": "string",
+            "enum": [
+                "Required",
+                "Optional"
+            ],
+            "x-enum-varnames": [
+                "PresharedKeyRequired",
+                "PresharedKeyOptional"
+            ]
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.RegisterUserRequest": {
             "type": "object",
+            "required": [
+                "email",
+                "role"
+            ],
             "properties": {
-                "createdAt": {
+                "email": {
                     "type": "string"
                 },
-                "deletedAt": {
-                    "$ref": "#/definitions/gorm.DeletedAt"
+                "isPasswordSet": {
+                    "type": "boolean"
                 },
-                "id": {
-                    "type": "integer"
+                "maxClientLifetime": {
+                    "description": "MaxClientLifetime in minutes caps the clients of the user, 0 for no cap",
+                    "type": "integer",
+                    "minimum": 0
                 },
-                "name": {
+                "password": {
                     "type": "string"
                 },
-                "updatedAt": {
+                "role": {
+                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.UserRoleEnum"
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.SCIMErrorResponse": {
+            "type": "object",
+            "properties": {
+                "detail": {
                     "type": "string"
                 },
-                "users": {
+                "schemas": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.User"
+                        "type": "string"
                     }
                 },
-                "uuid": {
+                "scimType": {
                     "type": "string"
                 },
-                "vpnGateways": {
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.SCIMGroup": {
+            "type": "object",
+            "properties": {
+                "displayName": {
+                    "type": "string"
+                },
+                "externalId": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string"
+                },
+                "members": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.VpnGateway"
+                        "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMMultiValuedAttribute"
+                    }
+                },
+                "meta": {
+                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMMeta"
+                },
+                "schemas": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
                     }
                 }
             }
         },
-        "github_com_leetsecure_qryptic-controller_internal_models.GroupCreateRequest": {
+        "github_com_leetsecure_qryptic-controller_internal_models.SCIMListResponse": {
             "type": "object",
-            "required": [
-                "name"
-            ],
             "properties": {
-                "name": {
+                "Resources": {
+                    "type": "array",
+                    "items": {}
+                },
+                "itemsPerPage": {
+                    "type": "integer"
+                },
+                "schemas": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "startIndex": {
+                    "type": "integer"
+                },
+                "totalResults": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.SCIMMeta": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "string"
+                },
+                "lastModified": {
+                    "type": "string"
+                },
+                "location": {
+                    "type": "string"
+                },
+                "resourceType": {
                     "type": "string"
                 }
             }
         },
-        "github_com_leetsecure_qryptic-controller_internal_models.GroupUpdateRequest": {
+        "github_com_leetsecure_qryptic-controller_internal_models.SCIMMultiValuedAttribute": {
+            "type": "object",
+            "properties": {
+                "$ref": {
+                    "type": "string"
+                },
+                "display": {
+                    "type": "string"
+                },
+                "primary": {
+                    "type": "boolean"
+                },
+                "type": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.SCIMName": {
+            "type": "object",
+            "properties": {
+                "familyName": {
+                    "type": "string"
+                },
+                "formatted": {
+                    "type": "string"
+                },
+                "givenName": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.SCIMPatchOperation": {
             "type": "object",
             "required": [
-                "name"
+                "op"
             ],
             "properties": {
-                "name": {
+                "op": {
                     "type": "string"
-                }
+                },
+                "path": {
+                    "type": "string"
+                },
+                "value": {}
             }
         },
-        "github_com_leetsecure_qryptic-controller_internal_models.GroupUpdateUserRequest": {
+        "github_com_leetsecure_qryptic-controller_internal_models.SCIMPatchRequest": {
             "type": "object",
             "required": [
-                "userUuids"
+                "Operations"
             ],
             "properties": {
-                "userUuids": {
+                "Operations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMPatchOperation"
+                    }
+                },
+                "schemas": {
                     "type": "array",
                     "items": {
                         "type": "string"
@@ -2594,54 +6859,91 @@ const docTemplate = `{
                 }
             }
         },
-        "github_com_leetsecure_qryptic-controller_internal_models.IPPool": {
+        "github_com_leetsecure_qryptic-controller_internal_models.SCIMUser": {
             "type": "object",
             "properties": {
-                "assigned": {
+                "active": {
+                    "description": "true when left out",
                     "type": "boolean"
                 },
-                "createdAt": {
+                "displayName": {
                     "type": "string"
                 },
-                "deletedAt": {
-                    "$ref": "#/definitions/gorm.DeletedAt"
+                "emails": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMMultiValuedAttribute"
+                    }
                 },
-                "id": {
-                    "type": "integer"
+                "externalId": {
+                    "type": "string"
                 },
-                "ip": {
+                "groups": {
+                    "description": "read only, set through the groups",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMMultiValuedAttribute"
+                    }
+                },
+                "id": {
                     "type": "string"
                 },
-                "updatedAt": {
+                "meta": {
+                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMMeta"
+                },
+                "name": {
+                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.SCIMName"
+                },
+                "schemas": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "userName": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.SSOGroupMapping": {
+            "type": "object",
+            "properties": {
+                "claimValue": {
                     "type": "string"
                 },
-                "uuid": {
+                "createdAt": {
                     "type": "string"
                 },
-                "vpngatewayID": {
-                    "description": "Foreign key to VpnGateway",
+                "group": {
+                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.Group"
+                },
+                "groupId": {
                     "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ssoConfigId": {
+                    "type": "integer"
+                },
+                "uuid": {
+                    "type": "string"
                 }
             }
         },
-        "github_com_leetsecure_qryptic-controller_internal_models.RegisterUserRequest": {
+        "github_com_leetsecure_qryptic-controller_internal_models.SSOGroupMappingCreateRequest": {
             "type": "object",
             "required": [
-                "email",
-                "role"
+                "claimValue",
+                "groupUuid"
             ],
             "properties": {
-                "email": {
+                "claimValue": {
+                    "description": "value of the group claim, e.g. eng-oncall",
                     "type": "string"
                 },
-                "isPasswordSet": {
-                    "type": "boolean"
-                },
-                "password": {
+                "groupUuid": {
                     "type": "string"
-                },
-                "role": {
-                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.UserRoleEnum"
                 }
             }
         },
@@ -2667,6 +6969,47 @@ const docTemplate = `{
                 }
             }
         },
+        "github_com_leetsecure_qryptic-controller_internal_models.UpdateSSOJITProvisioningRequest": {
+            "type": "object",
+            "required": [
+                "jitProvisioning"
+            ],
+            "properties": {
+                "defaultGroupUuids": {
+                    "description": "DefaultGroupUuids replace the groups the provisioned users are added to",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "defaultRole": {
+                    "description": "DefaultRole is User when empty, provisioned users are promoted to admins one by one",
+                    "enum": [
+                        "User",
+                        "Default"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.UserRoleEnum"
+                        }
+                    ]
+                },
+                "jitProvisioning": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.UpdateSamlIdpMetadataRequest": {
+            "type": "object",
+            "required": [
+                "idpMetadata"
+            ],
+            "properties": {
+                "idpMetadata": {
+                    "type": "string"
+                }
+            }
+        },
         "github_com_leetsecure_qryptic-controller_internal_models.UpdateUserRequest": {
             "type": "object",
             "properties": {
@@ -2676,6 +7019,11 @@ const docTemplate = `{
                 "isPasswordSet": {
                     "type": "boolean"
                 },
+                "maxClientLifetime": {
+                    "description": "MaxClientLifetime in minutes replaces the cap of the user when present, 0 removes it",
+                    "type": "integer",
+                    "minimum": 0
+                },
                 "newPassword": {
                     "type": "string"
                 },
@@ -2702,6 +7050,10 @@ const docTemplate = `{
                 "email": {
                     "type": "string"
                 },
+                "externalId": {
+                    "description": "id of the user in the provisioning client",
+                    "type": "string"
+                },
                 "groups": {
                     "type": "array",
                     "items": {
@@ -2711,15 +7063,27 @@ const docTemplate = `{
                 "id": {
                     "type": "integer"
                 },
+                "isActive": {
+                    "description": "IsActive is cleared when the user is deprovisioned, inactive users cannot sign in",
+                    "type": "boolean"
+                },
                 "isPasswordSet": {
                     "type": "boolean"
                 },
+                "maxClientLifetime": {
+                    "description": "MaxClientLifetime in minutes caps the clients of the user on every gateway, 0 for no cap",
+                    "type": "integer"
+                },
                 "name": {
                     "type": "string"
                 },
                 "role": {
                     "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.UserRoleEnum"
                 },
+                "scimProvisioned": {
+                    "description": "SCIMProvisioned marks the users created through scim, the only ones scim can see and change",
+                    "type": "boolean"
+                },
                 "updatedAt": {
                     "type": "string"
                 },
@@ -2765,12 +7129,18 @@ const docTemplate = `{
         "github_com_leetsecure_qryptic-controller_internal_models.VpnGateway": {
             "type": "object",
             "properties": {
+                "clientLimitPolicy": {
+                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.ClientLimitPolicyEnum"
+                },
                 "clients": {
                     "type": "array",
                     "items": {
                         "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.Client"
                     }
                 },
+                "consecutiveHealthFailures": {
+                    "type": "integer"
+                },
                 "createdAt": {
                     "type": "string"
                 },
@@ -2783,23 +7153,38 @@ const docTemplate = `{
                 "domain": {
                     "type": "string"
                 },
+                "gatewayVersion": {
+                    "description": "reported by the gateway in its heartbeat",
+                    "type": "string"
+                },
                 "groups": {
                     "type": "array",
                     "items": {
                         "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.Group"
                     }
                 },
+                "healthChecks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.VpnGatewayHealthCheck"
+                    }
+                },
+                "healthLatencyMs": {
+                    "type": "integer"
+                },
+                "healthStatus": {
+                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.GatewayHealthStatusEnum"
+                },
                 "id": {
                     "type": "integer"
                 },
                 "ipAddressCIDR": {
                     "type": "string"
                 },
-                "ipPool": {
-                    "description": "IPAllocations    []*IPAllocation ` + "`" + `json:\"ipAllocations\"` + "`" + `",
+                "ipAllocations": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.IPPool"
+                        "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.IPAllocation"
                     }
                 },
                 "jwtAlgorithm": {
@@ -2808,12 +7193,53 @@ const docTemplate = `{
                 "jwtSecretKey": {
                     "type": "string"
                 },
+                "lastHealthCheckAt": {
+                    "type": "string"
+                },
+                "lastHeartbeatAt": {
+                    "type": "string"
+                },
+                "lastSeenAt": {
+                    "type": "string"
+                },
+                "maxClientLifetime": {
+                    "description": "minutes, 0 for no cap",
+                    "type": "integer"
+                },
+                "maxClients": {
+                    "description": "active clients on the gateway, 0 for no cap",
+                    "type": "integer"
+                },
+                "maxClientsPerUser": {
+                    "description": "active clients of a user on the gateway, 0 for no cap",
+                    "type": "integer"
+                },
                 "name": {
                     "type": "string"
                 },
                 "port": {
                     "type": "integer"
                 },
+                "presharedKeyPolicy": {
+                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.PresharedKeyPolicyEnum"
+                },
+                "reportedPublicIP": {
+                    "type": "string"
+                },
+                "reservedRanges": {
+                    "description": "cidrs of the vpn cidr never handed out to clients",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "routes": {
+                    "description": "cidrs advertised to clients, a full tunnel when empty",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "serverPrivateKey": {
                     "type": "string"
                 },
@@ -2823,6 +7249,9 @@ const docTemplate = `{
                 "updatedAt": {
                     "type": "string"
                 },
+                "uptimeSeconds": {
+                    "type": "integer"
+                },
                 "users": {
                     "type": "array",
                     "items": {
@@ -2834,6 +7263,19 @@ const docTemplate = `{
                 },
                 "vpnCIDR": {
                     "type": "string"
+                },
+                "vpnCIDRv6": {
+                    "description": "optional, makes the gateway dual-stack",
+                    "type": "string"
+                },
+                "wgInterfaceName": {
+                    "type": "string"
+                },
+                "wgInterfacePeerCount": {
+                    "type": "integer"
+                },
+                "wgInterfaceUp": {
+                    "type": "boolean"
                 }
             }
         },
@@ -2847,6 +7289,18 @@ const docTemplate = `{
                 "port"
             ],
             "properties": {
+                "clientLimitPolicy": {
+                    "description": "ClientLimitPolicy defaults to Reject",
+                    "enum": [
+                        "Reject",
+                        "RevokeOldest"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.ClientLimitPolicyEnum"
+                        }
+                    ]
+                },
                 "dnsServer": {
                     "type": "string"
                 },
@@ -2856,20 +7310,213 @@ const docTemplate = `{
                 "ipAddress": {
                     "type": "string"
                 },
+                "maxClientLifetime": {
+                    "description": "MaxClientLifetime in minutes caps the clients of the gateway, 0 for no cap",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "maxClients": {
+                    "description": "MaxClients and MaxClientsPerUser cap the active clients, 0 for no cap",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "maxClientsPerUser": {
+                    "type": "integer",
+                    "minimum": 0
+                },
                 "name": {
                     "type": "string"
                 },
                 "port": {
                     "type": "integer"
                 },
+                "presharedKeyPolicy": {
+                    "description": "PresharedKeyPolicy defaults to Required",
+                    "enum": [
+                        "Required",
+                        "Optional"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.PresharedKeyPolicyEnum"
+                        }
+                    ]
+                },
+                "reservedRanges": {
+                    "description": "ReservedRanges are cidrs of the vpn cidr never handed out to clients",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "routes": {
+                    "description": "Routes are the cidrs advertised to clients, a full tunnel when empty",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "serverPrivateKey": {
+                    "description": "ServerPrivateKey is optional, a key pair is generated when it is empty",
+                    "type": "string"
+                },
                 "vpnCIDR": {
                     "type": "string"
+                },
+                "vpnCIDRv6": {
+                    "type": "string"
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.VpnGatewayDriftReport": {
+            "type": "object",
+            "properties": {
+                "checkedAt": {
+                    "type": "string"
+                },
+                "corrected": {
+                    "type": "boolean"
+                },
+                "createdAt": {
+                    "type": "string"
+                },
+                "deletedAt": {
+                    "$ref": "#/definitions/gorm.DeletedAt"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "missingPeers": {
+                    "description": "active clients not present on the gateway",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.WGServerPeerConfig"
+                    }
+                },
+                "outdatedPeers": {
+                    "description": "active clients held by the gateway with another configuration",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.WGServerPeerConfig"
+                    }
+                },
+                "pendingOperations": {
+                    "description": "drift is not corrected while operations are pending or failed",
+                    "type": "integer"
+                },
+                "stalePeers": {
+                    "description": "peers on the gateway without an active client",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.WGServerPeerConfig"
+                    }
+                },
+                "status": {
+                    "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.DriftStatusEnum"
+                },
+                "updatedAt": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                },
+                "vpnGatewayId": {
+                    "type": "integer"
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.VpnGatewayHealthCheck": {
+            "type": "object",
+            "properties": {
+                "checkedAt": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "healthy": {
+                    "type": "boolean"
+                },
+                "latencyMs": {
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "gateway status after the probe",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.GatewayHealthStatusEnum"
+                        }
+                    ]
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.VpnGatewayHeartbeatRequest": {
+            "type": "object",
+            "required": [
+                "version"
+            ],
+            "properties": {
+                "publicIP": {
+                    "type": "string"
+                },
+                "uptimeSeconds": {
+                    "type": "integer"
+                },
+                "version": {
+                    "type": "string"
+                },
+                "wgInterfaceName": {
+                    "type": "string"
+                },
+                "wgInterfacePeerCount": {
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "wgInterfaceUp": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.VpnGatewayPeerStatsRequest": {
+            "type": "object",
+            "properties": {
+                "peers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.WGPeerStats"
+                    }
+                }
+            }
+        },
+        "github_com_leetsecure_qryptic-controller_internal_models.VpnGatewayPeerStatsResponse": {
+            "type": "object",
+            "properties": {
+                "unknown": {
+                    "description": "peers without an active client on the gateway",
+                    "type": "integer"
+                },
+                "updated": {
+                    "type": "integer"
                 }
             }
         },
         "github_com_leetsecure_qryptic-controller_internal_models.VpnGatewayUpdateRequest": {
             "type": "object",
             "properties": {
+                "clientLimitPolicy": {
+                    "enum": [
+                        "Reject",
+                        "RevokeOldest"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.ClientLimitPolicyEnum"
+                        }
+                    ]
+                },
                 "dnsServer": {
                     "type": "string"
                 },
@@ -2879,11 +7526,58 @@ const docTemplate = `{
                 "ipAddress": {
                     "type": "string"
                 },
+                "maxClientLifetime": {
+                    "description": "MaxClientLifetime replaces the cap when present, existing clients keep their expiry",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "maxClients": {
+                    "description": "MaxClients and MaxClientsPerUser replace the caps when present, existing clients are kept",
+                    "type": "integer",
+                    "minimum": 0
+                },
+                "maxClientsPerUser": {
+                    "type": "integer",
+                    "minimum": 0
+                },
                 "name": {
                     "type": "string"
                 },
                 "port": {
                     "type": "integer"
+                },
+                "presharedKeyPolicy": {
+                    "enum": [
+                        "Required",
+                        "Optional"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.PresharedKeyPolicyEnum"
+                        }
+                    ]
+                },
+                "reservedRanges": {
+                    "description": "ReservedRanges replace the reserved ranges when present, allocated addresses stay with their clients",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "routes": {
+                    "description": "Routes replace the advertised routes when present, an empty list makes the gateway a full tunnel",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "serverPrivateKey": {
+                    "description": "ServerPrivateKey rotates the key of the gateway, the configs of existing clients have to be fetched again",
+                    "type": "string"
+                },
+                "vpnCIDRv6": {
+                    "description": "VpnCIDRv6 can only be set on a gateway that is not dual-stack yet, existing clients stay ipv4 only",
+                    "type": "string"
                 }
             }
         },
@@ -2936,6 +7630,10 @@ const docTemplate = `{
                 "clientUuid": {
                     "type": "string"
                 },
+                "deviceUuid": {
+                    "description": "the private key is the one of the device when set",
+                    "type": "string"
+                },
                 "expiryTime": {
                     "type": "string"
                 }
@@ -2950,6 +7648,9 @@ const docTemplate = `{
                 "ipAddress": {
                     "type": "string"
                 },
+                "ipv6Address": {
+                    "type": "string"
+                },
                 "privateKey": {
                     "type": "string"
                 }
@@ -2965,6 +7666,10 @@ const docTemplate = `{
                     }
                 },
                 "persistantAlive": {
+                    "description": "Deprecated: misspelled, use PersistentKeepalive",
+                    "type": "integer"
+                },
+                "persistentKeepalive": {
                     "type": "integer"
                 },
                 "presharedKey": {
@@ -2984,6 +7689,30 @@ const docTemplate = `{
                 }
             }
         },
+        "github_com_leetsecure_qryptic-controller_internal_models.WGPeerStats": {
+            "type": "object",
+            "required": [
+                "clientPublicKey"
+            ],
+            "properties": {
+                "clientPublicKey": {
+                    "type": "string"
+                },
+                "endpoint": {
+                    "type": "string"
+                },
+                "latestHandshake": {
+                    "description": "unix seconds, 0 if the peer never completed a handshake",
+                    "type": "integer"
+                },
+                "rxBytes": {
+                    "type": "integer"
+                },
+                "txBytes": {
+                    "type": "integer"
+                }
+            }
+        },
         "github_com_leetsecure_qryptic-controller_internal_models.WGServerConfig": {
             "type": "object",
             "properties": {
@@ -3007,6 +7736,9 @@ const docTemplate = `{
                 "ipAddress": {
                     "type": "string"
                 },
+                "ipv6Address": {
+                    "type": "string"
+                },
                 "listenPort": {
                     "type": "integer"
                 },
@@ -3030,12 +7762,26 @@ const docTemplate = `{
         "github_com_leetsecure_qryptic-controller_internal_models.WGServerPeerConfig": {
             "type": "object",
             "properties": {
+                "allowedDestinations": {
+                    "description": "AllowedDestinations are the routes the peer may reach through the gateway",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
                 "clientAllowedIPs": {
                     "type": "string"
                 },
                 "clientPublicKey": {
                     "type": "string"
                 },
+                "firewallRules": {
+                    "description": "FirewallRules are evaluated in order and the first match wins. Without rules the allowed\ndestinations are reachable, with rules everything they do not allow is dropped.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/github_com_leetsecure_qryptic-controller_internal_models.FirewallRule"
+                    }
+                },
                 "presharedKey": {
                     "type": "string"
                 }
@@ -1,6 +1,12 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
 	"github.com/leetsecure/qryptic-controller/cmd/controller/docs"
@@ -10,6 +16,7 @@ import (
 	"github.com/leetsecure/qryptic-controller/internal/config"
 	"github.com/leetsecure/qryptic-controller/internal/database"
 	"github.com/leetsecure/qryptic-controller/internal/routes"
+	"github.com/leetsecure/qryptic-controller/internal/scheduler"
 	"github.com/leetsecure/qryptic-controller/internal/services"
 	"github.com/leetsecure/qryptic-controller/internal/utils/logger"
 
@@ -45,6 +52,20 @@ func main() {
 		return
 	}
 
+	err = services.MigrateIPPools()
+	if err != nil {
+		log.Error(err)
+		return
+	}
+
+	if config.RepairIPAllocationsOnStartup {
+		err = services.RepairIPAllocations()
+		if err != nil {
+			log.Error(err)
+			return
+		}
+	}
+
 	err = services.InitAdminConfig()
 	if err != nil {
 		log.Error(err)
@@ -76,6 +97,65 @@ func main() {
 		router.GET("/swagger/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
 	}
 
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// Start the background jobs
+	jobScheduler := scheduler.New()
+	jobScheduler.AddJob(scheduler.Job{
+		Name:     "expired-clients-cleanup",
+		Interval: config.ExpiredClientsCleanupInterval,
+		Run: func() error {
+			return services.DeleteExpiredClientsFromUserAndVpnGateway("")
+		},
+	})
+	jobScheduler.AddJob(scheduler.Job{
+		Name:     "gateway-operations",
+		Interval: config.GatewayOperationWorkerInterval,
+		Run:      services.ProcessGatewayOperations,
+		Wakeup:   services.GatewayOperationsWakeup(),
+	})
+	jobScheduler.AddJob(scheduler.Job{
+		Name:     "gateway-operations-cleanup",
+		Interval: 1 * time.Hour,
+		Run:      services.PruneGatewayOperations,
+	})
+	jobScheduler.AddJob(scheduler.Job{
+		Name:     "gateway-reconcile",
+		Interval: config.GatewayReconcileInterval,
+		Run:      services.ReconcileVpnGateways,
+	})
+	jobScheduler.AddJob(scheduler.Job{
+		Name:     "gateway-health",
+		Interval: config.GatewayHealthCheckInterval,
+		Run:      services.ProbeVpnGateways,
+	})
+	jobScheduler.AddJob(scheduler.Job{
+		Name:     "gateway-health-cleanup",
+		Interval: 1 * time.Hour,
+		Run:      services.PruneVpnGatewayHealthChecks,
+	})
+	jobScheduler.Start(ctx)
+
 	// Start the server
-	router.Run(":8080")
+	server := &http.Server{
+		Addr:    ":8080",
+		Handler: router,
+	}
+	go func() {
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Error(err)
+			stop()
+		}
+	}()
+
+	<-ctx.Done()
+	log.Info("shutting down controller")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Error(err)
+	}
+	jobScheduler.Wait()
 }
@@ -11,9 +11,23 @@ import (
 
 var Environment = "production"
 var ClientExpiry = 60 * 4 * time.Minute
+var ExpiredClientsCleanupInterval = 1 * time.Minute
+var GatewayOperationWorkerInterval = 10 * time.Second
+var GatewayOperationMaxAttempts = 12
+var GatewayOperationRetention = 7 * 24 * time.Hour
+var GatewayReconcileInterval = 5 * time.Minute
+var GatewayHealthCheckInterval = 30 * time.Second
+var GatewayHealthFailureThreshold = 3
+var GatewayHealthHistoryRetention = 7 * 24 * time.Hour
+var ActiveSessionHandshakeWindow = 3 * time.Minute
+var RequireClientDevices = false
+var RepairIPAllocationsOnStartup = false
 var JwtTokenTimeout = 60 * time.Minute
+var FreshAuthMaxAge = 10 * time.Minute
 var SSOStateJwtTokenTimeout = 5 * time.Minute
 var SSOCallbackTemplate = "https://%s/api/v1/auth/%s/web/sso/callback"
+var SAMLURLTemplate = "https://%s/api/v1/auth/saml/%s/%s"
+var SCIMURLTemplate = "https://%s/scim/v2/%s/%s"
 var VpnGatewayApplicationImageName = "940482412786.dkr.ecr.ap-south-1.amazonaws.com/qryptic/gateway:<version>"
 var GatewayHealthCheckUrlTemplate = "https://%s/health"
 var GatewayCallbackForConfigTemplate = "https://%s/api/v1/gateway/get-gateway-config"
@@ -25,10 +39,6 @@ var CORSAllowCredentials = false
 var AllowPasswordLogin = true
 var AllowSSOLogin = false
 
-var SsoConfig = []map[string]string{}
-var GoogleClientID = ""
-var GoogleClientSecret = ""
-
 var (
 	DBHost     string
 	DBPort     string
@@ -106,9 +116,20 @@ func UpdateEnvConfig() error {
 		JwtTokenTimeout = time.Duration(jwtTokenTimeout) * time.Minute
 	}
 
-	// ClientExpiry
-	clientExpiryString, exists := os.LookupEnv("ClientExpiry")
+	// FreshAuthMaxAge in minutes, the age of the login accepted for sensitive actions like client renewal
+	freshAuthMaxAgeString, exists := os.LookupEnv("FreshAuthMaxAge")
 	if exists {
+		freshAuthMaxAge, converr := strconv.Atoi(freshAuthMaxAgeString)
+		if converr != nil {
+			err = errors.Join(err, errors.New("integer expected:FreshAuthMaxAge"))
+
+		}
+		FreshAuthMaxAge = time.Duration(freshAuthMaxAge) * time.Minute
+	}
+
+	// ClientExpiry in minutes, the default of the environment applies when it is not set
+	clientExpiryString, clientExpiryExists := os.LookupEnv("ClientExpiry")
+	if clientExpiryExists {
 		clientExpiry, converr := strconv.Atoi(clientExpiryString)
 		if converr != nil {
 			err = errors.Join(err, errors.New("integer expected:ClientExpiry"))
@@ -117,6 +138,94 @@ func UpdateEnvConfig() error {
 		ClientExpiry = time.Duration(clientExpiry) * time.Minute
 	}
 
+	// ExpiredClientsCleanupInterval, 0 disables the scheduler
+	expiredClientsCleanupIntervalString, exists := os.LookupEnv("ExpiredClientsCleanupInterval")
+	if exists {
+		expiredClientsCleanupInterval, converr := strconv.Atoi(expiredClientsCleanupIntervalString)
+		if converr != nil {
+			err = errors.Join(err, errors.New("integer expected:ExpiredClientsCleanupInterval"))
+
+		}
+		ExpiredClientsCleanupInterval = time.Duration(expiredClientsCleanupInterval) * time.Minute
+	}
+
+	// GatewayOperationWorkerInterval in seconds
+	gatewayOperationWorkerIntervalString, exists := os.LookupEnv("GatewayOperationWorkerInterval")
+	if exists {
+		gatewayOperationWorkerInterval, converr := strconv.Atoi(gatewayOperationWorkerIntervalString)
+		if converr != nil {
+			err = errors.Join(err, errors.New("integer expected:GatewayOperationWorkerInterval"))
+
+		}
+		GatewayOperationWorkerInterval = time.Duration(gatewayOperationWorkerInterval) * time.Second
+	}
+
+	// GatewayOperationMaxAttempts
+	gatewayOperationMaxAttemptsString, exists := os.LookupEnv("GatewayOperationMaxAttempts")
+	if exists {
+		gatewayOperationMaxAttempts, converr := strconv.Atoi(gatewayOperationMaxAttemptsString)
+		if converr != nil {
+			err = errors.Join(err, errors.New("integer expected:GatewayOperationMaxAttempts"))
+
+		}
+		GatewayOperationMaxAttempts = gatewayOperationMaxAttempts
+	}
+
+	// GatewayReconcileInterval, 0 disables the reconciliation
+	gatewayReconcileIntervalString, exists := os.LookupEnv("GatewayReconcileInterval")
+	if exists {
+		gatewayReconcileInterval, converr := strconv.Atoi(gatewayReconcileIntervalString)
+		if converr != nil {
+			err = errors.Join(err, errors.New("integer expected:GatewayReconcileInterval"))
+
+		}
+		GatewayReconcileInterval = time.Duration(gatewayReconcileInterval) * time.Minute
+	}
+
+	// GatewayHealthCheckInterval in seconds, 0 disables the health prober
+	gatewayHealthCheckIntervalString, exists := os.LookupEnv("GatewayHealthCheckInterval")
+	if exists {
+		gatewayHealthCheckInterval, converr := strconv.Atoi(gatewayHealthCheckIntervalString)
+		if converr != nil {
+			err = errors.Join(err, errors.New("integer expected:GatewayHealthCheckInterval"))
+
+		}
+		GatewayHealthCheckInterval = time.Duration(gatewayHealthCheckInterval) * time.Second
+	}
+
+	// GatewayHealthFailureThreshold, consecutive failed probes before a gateway is marked down
+	gatewayHealthFailureThresholdString, exists := os.LookupEnv("GatewayHealthFailureThreshold")
+	if exists {
+		gatewayHealthFailureThreshold, converr := strconv.Atoi(gatewayHealthFailureThresholdString)
+		if converr != nil {
+			err = errors.Join(err, errors.New("integer expected:GatewayHealthFailureThreshold"))
+
+		}
+		GatewayHealthFailureThreshold = gatewayHealthFailureThreshold
+	}
+
+	// RequireClientDevices, clients are only issued against registered devices so that the controller never holds their private keys
+	requireClientDevicesString, exists := os.LookupEnv("RequireClientDevices")
+	if exists {
+		requireClientDevices, converr := strconv.ParseBool(requireClientDevicesString)
+		if converr != nil {
+			err = errors.Join(err, errors.New("boolean expected:RequireClientDevices"))
+
+		}
+		RequireClientDevices = requireClientDevices
+	}
+
+	// RepairIPAllocationsOnStartup, a one-off repair of the ip allocations of every gateway, the repair of a single gateway is also available to admins
+	repairIPAllocationsOnStartupString, exists := os.LookupEnv("RepairIPAllocationsOnStartup")
+	if exists {
+		repairIPAllocationsOnStartup, converr := strconv.ParseBool(repairIPAllocationsOnStartupString)
+		if converr != nil {
+			err = errors.Join(err, errors.New("boolean expected:RepairIPAllocationsOnStartup"))
+
+		}
+		RepairIPAllocationsOnStartup = repairIPAllocationsOnStartup
+	}
+
 	environment, exists := os.LookupEnv("Environment")
 	if exists {
 		if !((environment == "production") || (environment == "development") || (environment == "local")) {
@@ -143,17 +252,25 @@ func UpdateEnvConfig() error {
 	CORSAllowCredentials = true
 
 	if Environment == "local" {
-		ClientExpiry = 10 * time.Minute
+		if !clientExpiryExists {
+			ClientExpiry = 10 * time.Minute
+		}
 		JwtTokenTimeout = 2 * 60 * time.Minute
 		SSOCallbackTemplate = "http://%s/api/v1/auth/%s/web/sso/callback"
+		SAMLURLTemplate = "http://%s/api/v1/auth/saml/%s/%s"
+		SCIMURLTemplate = "http://%s/scim/v2/%s/%s"
 		CORSAllowedOrigins = []string{"http://localhost:3000", webDomain}
 		CORSAllowCredentials = true
 	}
 
 	if Environment == "development" {
-		ClientExpiry = 60 * time.Minute
+		if !clientExpiryExists {
+			ClientExpiry = 60 * time.Minute
+		}
 		JwtTokenTimeout = 60 * time.Minute
 		SSOCallbackTemplate = "https://%s/api/v1/auth/%s/web/sso/callback"
+		SAMLURLTemplate = "https://%s/api/v1/auth/saml/%s/%s"
+		SCIMURLTemplate = "https://%s/scim/v2/%s/%s"
 		CORSAllowedOrigins = []string{"http://localhost:3000", webDomain}
 		CORSAllowCredentials = true
 	}
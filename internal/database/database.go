@@ -26,16 +26,39 @@ func ConnectDatabase() error {
 }
 
 func AutomigrateDatabase() error {
+	scimMarkerMissing := DB.Migrator().HasTable(&models.User{}) && !DB.Migrator().HasColumn(&models.User{}, "scim_provisioned")
 	err := DB.AutoMigrate(&models.User{},
 		&models.VpnGateway{},
 		&models.Client{},
-		&models.IPPool{},
+		&models.Device{},
+		&models.IPAllocation{},
+		&models.IPReservation{},
 		&models.AdminConfiguration{},
 		&models.SSOConfig{},
+		&models.SSOGroupMapping{},
 		&models.Auth{},
+		&models.AuditTrail{},
+		&models.GatewayOperation{},
+		&models.VpnGatewayDriftReport{},
+		&models.VpnGatewayHealthCheck{},
+		&models.AccessPolicy{},
 	)
 	if err != nil {
 		return err
 	}
+	// users provisioned through scim before the marker are the ones with an external id, which only
+	// scim sets, admins are left to the admin
+	if scimMarkerMissing {
+		err := DB.Model(&models.User{}).Where("external_id <> ? AND role <> ?", "", models.AdminRole).Update("scim_provisioned", true).Error
+		if err != nil {
+			return err
+		}
+	}
+	// private keys of generated client key pairs are no longer stored, the ones of older clients are dropped
+	if DB.Migrator().HasColumn(&models.Client{}, "client_private_key") {
+		if err := DB.Migrator().DropColumn(&models.Client{}, "client_private_key"); err != nil {
+			return err
+		}
+	}
 	return nil
 }
@@ -88,6 +88,45 @@ func AddNewPeerInVpnGateway(vpnGatewayDomain string, authToken string, wgServerP
 	return string(body), res.StatusCode, nil
 }
 
+func UpdatePeersInVpnGateway(vpnGatewayDomain string, authToken string, wgServerPeerConfigs []models.WGServerPeerConfig) (string, int, error) {
+	log := logger.Default()
+
+	spaceClient := http.Client{
+		Timeout: time.Second * 5,
+	}
+	vpnGatewayUpdatePeersUrl := fmt.Sprintf("https://%s/controller/update-peers", vpnGatewayDomain)
+
+	jsonWGServerPeerConfigs, err := json.Marshal(wgServerPeerConfigs)
+	if err != nil {
+		return "", 0, err
+	}
+	req, err := http.NewRequest(http.MethodPut, vpnGatewayUpdatePeersUrl, bytes.NewBuffer(jsonWGServerPeerConfigs))
+	if err != nil {
+		log.Errorf("Error in creating request for %s", vpnGatewayUpdatePeersUrl)
+		return "", 0, err
+	}
+
+	authTokenWithBearer := fmt.Sprintf("Bearer %s", authToken)
+	req.Header.Set("Authorization", authTokenWithBearer)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := spaceClient.Do(req)
+	if err != nil {
+		log.Errorf("Error in executing request for %s", vpnGatewayUpdatePeersUrl)
+		return "", 0, err
+	}
+	if res.Body != nil {
+		defer res.Body.Close()
+	}
+	body, err := io.ReadAll(res.Body)
+	if err != nil {
+		log.Errorf("Error in reading response body from %s", vpnGatewayUpdatePeersUrl)
+		return "", 0, err
+	}
+
+	return string(body), res.StatusCode, nil
+}
+
 func DeletePeerInVpnGateway(vpnGatewayDomain string, authToken string, wgServerPeerConfigs []models.WGServerPeerConfig) (string, int, error) {
 	log := logger.Default()
 
@@ -161,3 +200,42 @@ func RestartVpnGateway(vpnGatewayDomain string, authToken string) (string, int,
 
 	return string(body), res.StatusCode, nil
 }
+
+func ListPeersInVpnGateway(vpnGatewayDomain string, authToken string) ([]models.WGServerPeerConfig, int, error) {
+	log := logger.Default()
+
+	spaceClient := http.Client{
+		Timeout: time.Second * 5,
+	}
+	vpnGatewayListPeersUrl := fmt.Sprintf("https://%s/controller/list-peers", vpnGatewayDomain)
+
+	req, err := http.NewRequest(http.MethodGet, vpnGatewayListPeersUrl, nil)
+	if err != nil {
+		log.Errorf("Error in creating request for %s", vpnGatewayListPeersUrl)
+		return nil, 0, err
+	}
+
+	authTokenWithBearer := fmt.Sprintf("Bearer %s", authToken)
+	req.Header.Set("Authorization", authTokenWithBearer)
+	req.Header.Set("Content-Type", "application/json")
+
+	res, err := spaceClient.Do(req)
+	if err != nil {
+		log.Errorf("Error in executing request for %s", vpnGatewayListPeersUrl)
+		return nil, 0, err
+	}
+	if res.Body != nil {
+		defer res.Body.Close()
+	}
+	if res.StatusCode != http.StatusOK {
+		return nil, res.StatusCode, nil
+	}
+
+	var wgServerPeerConfigs []models.WGServerPeerConfig
+	if err := json.NewDecoder(res.Body).Decode(&wgServerPeerConfigs); err != nil {
+		log.Errorf("Error in decoding response body from %s", vpnGatewayListPeersUrl)
+		return nil, res.StatusCode, err
+	}
+
+	return wgServerPeerConfigs, res.StatusCode, nil
+}
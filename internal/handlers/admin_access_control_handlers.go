@@ -26,6 +26,7 @@ import (
 //	@Param			VpnGatewayUpdateUserRequest	body		models.VpnGatewayUpdateUserRequest	true	"user ids"
 //	@Router			/api/v1/admin/access/gateway/{id}/{action}/users [put]
 func AddRemoveUsersInVpnGateway(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
 	gatewayUuid := c.Param("id")
 	action := c.Param("action")
 	if action != "add" && action != "remove" {
@@ -40,7 +41,7 @@ func AddRemoveUsersInVpnGateway(c *gin.Context) {
 		return
 	}
 
-	err := services.AddRemoveUsersInVpnGateway(action,
+	err := services.AddRemoveUsersInVpnGateway(adminUuid.(string), action,
 		gatewayUuid,
 		vpnGatewayUpdateUserRequest.UserUuids)
 	if err != nil {
@@ -68,6 +69,7 @@ func AddRemoveUsersInVpnGateway(c *gin.Context) {
 //
 //	@Router			/api/v1/admin/access/gateway/{id}/{action}/groups [put]
 func AddRemoveGroupsInVpnGateway(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
 	log := logger.Default()
 	gatewayUuid := c.Param("id")
 	action := c.Param("action")
@@ -81,7 +83,7 @@ func AddRemoveGroupsInVpnGateway(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	err := services.AddRemoveGroupsInVpnGateway(action,
+	err := services.AddRemoveGroupsInVpnGateway(adminUuid.(string), action,
 		gatewayUuid,
 		gatewayUpdateGroupRequest.GroupUuids)
 	if err != nil {
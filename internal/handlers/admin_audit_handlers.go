@@ -0,0 +1,47 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leetsecure/qryptic-controller/internal/models"
+	"github.com/leetsecure/qryptic-controller/internal/services"
+)
+
+// ListAuditTrails godoc
+//
+//	@Summary		ListAuditTrails
+//	@ID				ListAuditTrails
+//	@Description	list the audit trail of admin, user and gateway actions, newest first
+//	@Tags			admin-audit
+//	@Accept			json
+//	@Produce		json
+//	@Success		200				{object}	models.AuditTrailListResponse
+//	@Failure		400				{object}	any
+//	@Failure		401				{object}	any
+//	@Failure		500				{object}	any
+//	@Param			Authorization	header		string	true	"Insert your token"	default(Bearer <token>)
+//	@Param			page			query		int		false	"page number, starts at 1"
+//	@Param			pageSize		query		int		false	"entries per page, at most 500"
+//	@Param			action			query		string	false	"action e.g. user.create"
+//	@Param			actorUuid		query		string	false	"uuid of the user or gateway performing the action"
+//	@Param			userUuid		query		string	false	"affected user id"
+//	@Param			groupUuid		query		string	false	"affected group id"
+//	@Param			gatewayUuid		query		string	false	"affected gateway id"
+//	@Param			clientUuid		query		string	false	"affected client id"
+//	@Param			from			query		string	false	"RFC3339 start time"
+//	@Param			to				query		string	false	"RFC3339 end time"
+//	@Router			/api/v1/admin/audit [get]
+func ListAuditTrails(c *gin.Context) {
+	var auditTrailListRequest models.AuditTrailListRequest
+	if err := c.ShouldBindQuery(&auditTrailListRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	auditTrails, err := services.ListAuditTrails(auditTrailListRequest)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, auditTrails)
+}
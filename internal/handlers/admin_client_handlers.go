@@ -24,8 +24,9 @@ import (
 //
 //	@Router			/api/v1/admin/client/{id} [delete]
 func DeleteVpnClientByAdmin(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
 	clientUuid := c.Param("id")
-	err := services.DeleteClientFromUserAndVpnGateway(clientUuid)
+	err := services.DeleteClientFromUserAndVpnGateway(adminUuid.(string), clientUuid)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -47,7 +48,8 @@ func DeleteVpnClientByAdmin(c *gin.Context) {
 //	@Param			Authorization	header		string	true	"Insert your token"	default(Bearer <token>)
 //	@Router			/api/v1/admin/client/expired [delete]
 func DeleteExpiredClients(c *gin.Context) {
-	err := services.DeleteExpiredClientsFromUserAndVpnGateway()
+	adminUuid, _ := c.Get("userUuid")
+	err := services.DeleteExpiredClientsFromUserAndVpnGateway(adminUuid.(string))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -12,7 +12,7 @@ import (
 //
 //	@Summary		Add SSO Config
 //	@ID				AddSsoConfig
-//	@Description	Add SSO Configuration
+//	@Description	Add the SSO Configuration of an OIDC provider, its endpoints are discovered from the issuer url
 //	@Tags			admin-config
 //	@Accept			json
 //	@Produce		json
@@ -23,15 +23,21 @@ import (
 //	@Param			AddSsoConfigRequest	body		models.AddSsoConfigRequest	true	"SSO Config Details"
 //	@Router			/api/v1/admin/config/sso [post]
 func AddSsoConfig(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
 	var addSsoConfigRequest models.AddSsoConfigRequest
 	if err := c.ShouldBindJSON(&addSsoConfigRequest); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	err := services.AddSsoConfig(addSsoConfigRequest.Domain,
+	err := services.AddSsoConfig(adminUuid.(string), addSsoConfigRequest.Domain,
 		addSsoConfigRequest.Provider,
 		addSsoConfigRequest.ClientID,
-		addSsoConfigRequest.ClientSecret, addSsoConfigRequest.Platform)
+		addSsoConfigRequest.ClientSecret, addSsoConfigRequest.Platform,
+		addSsoConfigRequest.IssuerURL,
+		addSsoConfigRequest.Scopes,
+		addSsoConfigRequest.EmailClaim,
+		addSsoConfigRequest.GroupClaim,
+		addSsoConfigRequest.TrustEmailClaim)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -40,6 +46,183 @@ func AddSsoConfig(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
 
+// AddSamlSsoConfig godoc
+//
+//	@Summary		AddSamlSsoConfig
+//	@ID				AddSamlSsoConfig
+//	@Description	Add a SAML identity provider from its metadata, the service provider metadata is then served at /api/v1/auth/saml/{provider}/metadata
+//	@Tags			admin-config
+//	@Accept			json
+//	@Produce		json
+//	@Success		200						{object}	any
+//	@Failure		400						{object}	any
+//	@Failure		401						{object}	any
+//	@Failure		500						{object}	any
+//	@Param			Authorization			header		string							true	"Insert your token"	default(Bearer <token>)
+//	@Param			AddSamlSsoConfigRequest	body		models.AddSamlSsoConfigRequest	true	"SAML SSO Config Details"
+//	@Router			/api/v1/admin/config/sso/saml [post]
+func AddSamlSsoConfig(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
+	var addSamlSsoConfigRequest models.AddSamlSsoConfigRequest
+	if err := c.ShouldBindJSON(&addSamlSsoConfigRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := services.AddSamlSsoConfig(adminUuid.(string), addSamlSsoConfigRequest.Domain,
+		addSamlSsoConfigRequest.Provider,
+		addSamlSsoConfigRequest.IdpMetadata,
+		addSamlSsoConfigRequest.EmailAttribute,
+		addSamlSsoConfigRequest.NameAttribute,
+		addSamlSsoConfigRequest.GroupAttribute)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// UpdateSamlIdpMetadata godoc
+//
+//	@Summary		UpdateSamlIdpMetadata
+//	@ID				UpdateSamlIdpMetadata
+//	@Description	Replace the identity provider metadata of a SAML sso configuration
+//	@Tags			admin-config
+//	@Accept			json
+//	@Produce		json
+//	@Success		200								{object}	any
+//	@Failure		400								{object}	any
+//	@Failure		401								{object}	any
+//	@Failure		500								{object}	any
+//	@Param			Authorization					header		string								true	"Insert your token"	default(Bearer <token>)
+//	@Param			id								path		string								true	"sso id"
+//	@Param			UpdateSamlIdpMetadataRequest	body		models.UpdateSamlIdpMetadataRequest	true	"IdP metadata xml"
+//	@Router			/api/v1/admin/config/sso/{id}/saml-metadata [put]
+func UpdateSamlIdpMetadata(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
+	var updateSamlIdpMetadataRequest models.UpdateSamlIdpMetadataRequest
+	if err := c.ShouldBindJSON(&updateSamlIdpMetadataRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := services.UpdateSamlIdpMetadata(adminUuid.(string), c.Param("id"), updateSamlIdpMetadataRequest.IdpMetadata)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// UpdateSSOJITProvisioning godoc
+//
+//	@Summary		UpdateSSOJITProvisioning
+//	@ID				UpdateSSOJITProvisioning
+//	@Description	Set whether the sso config creates the users of its domain on their first login, with the default role and groups
+//	@Tags			admin-config
+//	@Accept			json
+//	@Produce		json
+//	@Success		200								{object}	any
+//	@Failure		400								{object}	any
+//	@Failure		401								{object}	any
+//	@Failure		500								{object}	any
+//	@Param			Authorization					header		string									true	"Insert your token"	default(Bearer <token>)
+//	@Param			id								path		string									true	"sso id"
+//	@Param			UpdateSSOJITProvisioningRequest	body		models.UpdateSSOJITProvisioningRequest	true	"JIT provisioning settings"
+//	@Router			/api/v1/admin/config/sso/{id}/jit [put]
+func UpdateSSOJITProvisioning(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
+	var updateSSOJITProvisioningRequest models.UpdateSSOJITProvisioningRequest
+	if err := c.ShouldBindJSON(&updateSSOJITProvisioningRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := services.UpdateSSOJITProvisioning(adminUuid.(string), c.Param("id"), updateSSOJITProvisioningRequest)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// CreateSSOGroupMapping godoc
+//
+//	@Summary		CreateSSOGroupMapping
+//	@ID				CreateSSOGroupMapping
+//	@Description	Map a value of the group claim of the sso config to a group, the members of the mapped groups are synced on every login through the config
+//	@Tags			admin-config
+//	@Accept			json
+//	@Produce		json
+//	@Success		201								{object}	models.SSOGroupMapping
+//	@Failure		400								{object}	any
+//	@Failure		401								{object}	any
+//	@Failure		500								{object}	any
+//	@Param			Authorization					header		string								true	"Insert your token"	default(Bearer <token>)
+//	@Param			id								path		string								true	"sso id"
+//	@Param			SSOGroupMappingCreateRequest	body		models.SSOGroupMappingCreateRequest	true	"Claim value and group"
+//	@Router			/api/v1/admin/config/sso/{id}/group-mappings [post]
+func CreateSSOGroupMapping(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
+	var ssoGroupMappingCreateRequest models.SSOGroupMappingCreateRequest
+	if err := c.ShouldBindJSON(&ssoGroupMappingCreateRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	ssoGroupMapping, err := services.CreateSSOGroupMapping(adminUuid.(string), c.Param("id"), ssoGroupMappingCreateRequest)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, ssoGroupMapping)
+}
+
+// ListSSOGroupMappings godoc
+//
+//	@Summary		ListSSOGroupMappings
+//	@ID				ListSSOGroupMappings
+//	@Description	List the group mappings of the sso config
+//	@Tags			admin-config
+//	@Accept			json
+//	@Produce		json
+//	@Success		200				{object}	[]models.SSOGroupMapping
+//	@Failure		401				{object}	any
+//	@Failure		500				{object}	any
+//	@Param			Authorization	header		string	true	"Insert your token"	default(Bearer <token>)
+//	@Param			id				path		string	true	"sso id"
+//	@Router			/api/v1/admin/config/sso/{id}/group-mappings [get]
+func ListSSOGroupMappings(c *gin.Context) {
+	ssoGroupMappings, err := services.ListSSOGroupMappings(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ssoGroupMappings)
+}
+
+// DeleteSSOGroupMapping godoc
+//
+//	@Summary		DeleteSSOGroupMapping
+//	@ID				DeleteSSOGroupMapping
+//	@Description	Delete a group mapping of the sso config, the members of the group are kept
+//	@Tags			admin-config
+//	@Accept			json
+//	@Produce		json
+//	@Success		200				{object}	any
+//	@Failure		401				{object}	any
+//	@Failure		500				{object}	any
+//	@Param			Authorization	header		string	true	"Insert your token"	default(Bearer <token>)
+//	@Param			id				path		string	true	"sso id"
+//	@Param			mappingId		path		string	true	"group mapping id"
+//	@Router			/api/v1/admin/config/sso/{id}/group-mappings/{mappingId} [delete]
+func DeleteSSOGroupMapping(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
+	err := services.DeleteSSOGroupMapping(adminUuid.(string), c.Param("id"), c.Param("mappingId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
 // DeleteSsoConfig godoc
 //
 //	@Summary		DeleteSsoConfig
@@ -55,13 +238,14 @@ func AddSsoConfig(c *gin.Context) {
 //	@Param			id				path		string	true	"sso id"
 //	@Router			/api/v1/admin/config/sso/{id} [delete]
 func DeleteSsoConfig(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
 	// var deleteSsoConfigRequest models.DeleteSsoConfigRequest
 	// if err := c.ShouldBindJSON(&deleteSsoConfigRequest); err != nil {
 	// 	c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 	// 	return
 	// }
 	ssoConfigUuid := c.Param("id")
-	err := services.DeleteSsoConfig(ssoConfigUuid)
+	err := services.DeleteSsoConfig(adminUuid.(string), ssoConfigUuid)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -85,13 +269,14 @@ func DeleteSsoConfig(c *gin.Context) {
 //	@Param			UpdateAllowPasswordLoginRequest	body		models.UpdateAllowPasswordLoginRequest	true	"true or false"
 //	@Router			/api/v1/admin/config/password-login [put]
 func UpdateAllowPasswordLogin(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
 	var updateAllowPasswordLoginRequest models.UpdateAllowPasswordLoginRequest
 	if err := c.ShouldBindJSON(&updateAllowPasswordLoginRequest); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
-	err := services.UpdateAllowPasswordLogin(*updateAllowPasswordLoginRequest.AllowPasswordLogin)
+	err := services.UpdateAllowPasswordLogin(adminUuid.(string), *updateAllowPasswordLoginRequest.AllowPasswordLogin)
 
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
@@ -115,13 +300,60 @@ func UpdateAllowPasswordLogin(c *gin.Context) {
 //	@Param			UpdateAllowSSOLoginRequest	body		models.UpdateAllowSSOLoginRequest	true	"true or false"
 //	@Router			/api/v1/admin/config/sso-login [put]
 func UpdateAllowSSOLogin(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
 	var updateAllowSSOLoginRequest models.UpdateAllowSSOLoginRequest
 	if err := c.ShouldBindJSON(&updateAllowSSOLoginRequest); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	err := services.UpdateAllowSSOLogin(*updateAllowSSOLoginRequest.AllowSsoLogin)
+	err := services.UpdateAllowSSOLogin(adminUuid.(string), *updateAllowSSOLoginRequest.AllowSsoLogin)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
 
+// RotateSCIMToken godoc
+//
+//	@Summary		RotateSCIMToken
+//	@ID				RotateSCIMToken
+//	@Description	Issue a new bearer token for the scim client at /scim/v2, the previous token stops working. The token is only shown once
+//	@Tags			admin-config
+//	@Accept			json
+//	@Produce		json
+//	@Success		200				{object}	any
+//	@Failure		401				{object}	any
+//	@Failure		500				{object}	any
+//	@Param			Authorization	header		string	true	"Insert your token"	default(Bearer <token>)
+//	@Router			/api/v1/admin/config/scim-token [put]
+func RotateSCIMToken(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
+	scimToken, err := services.RotateSCIMToken(adminUuid.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"scimToken": scimToken})
+}
+
+// RevokeSCIMToken godoc
+//
+//	@Summary		RevokeSCIMToken
+//	@ID				RevokeSCIMToken
+//	@Description	Revoke the bearer token of the scim client, which disables scim provisioning
+//	@Tags			admin-config
+//	@Accept			json
+//	@Produce		json
+//	@Success		200				{object}	any
+//	@Failure		401				{object}	any
+//	@Failure		500				{object}	any
+//	@Param			Authorization	header		string	true	"Insert your token"	default(Bearer <token>)
+//	@Router			/api/v1/admin/config/scim-token [delete]
+func RevokeSCIMToken(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
+	err := services.RevokeSCIMToken(adminUuid.(string))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -6,6 +6,8 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/leetsecure/qryptic-controller/internal/models"
 	"github.com/leetsecure/qryptic-controller/internal/services"
+	"github.com/leetsecure/qryptic-controller/internal/utils/helper"
+	"github.com/leetsecure/qryptic-controller/internal/utils/wireguard"
 )
 
 // ListGateways godoc
@@ -29,6 +31,7 @@ func ListVpnGateways(c *gin.Context) {
 	includeUsersWithClients := false
 	includeGroups := false
 	includeIpPool := false
+	includeHealthHistory := false
 	for _, include := range includeQueryParams {
 		if include == "users-clients" {
 			includeUsersWithClients = true
@@ -38,12 +41,14 @@ func ListVpnGateways(c *gin.Context) {
 			includeGroups = true
 		} else if include == "clients" {
 			includeClients = true
-		} else if include == "ipPool" {
+		} else if include == "ipPool" || include == "ipAllocations" {
 			includeIpPool = true
+		} else if include == "healthHistory" {
+			includeHealthHistory = true
 		}
 	}
 
-	vpnGateways, err := services.ListVpnGateways(includeUsers, includeClients, includeUsersWithClients, includeGroups, includeIpPool)
+	vpnGateways, err := services.ListVpnGateways(includeUsers, includeClients, includeUsersWithClients, includeGroups, includeIpPool, includeHealthHistory)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -66,17 +71,23 @@ func ListVpnGateways(c *gin.Context) {
 //	@Param			VpnGatewayCreateRequest	body		models.VpnGatewayCreateRequest	true	"Gateway details"
 //	@Router			/api/v1/admin/gateway [post]
 func CreateVpnGateway(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
 	var vpnGatewayCreateRequest models.VpnGatewayCreateRequest
 	if err := c.ShouldBindJSON(&vpnGatewayCreateRequest); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	err := services.CreateVpnGateway(vpnGatewayCreateRequest.Name,
-		vpnGatewayCreateRequest.Domain,
-		vpnGatewayCreateRequest.IpAddress,
-		vpnGatewayCreateRequest.VpnCIDR,
-		vpnGatewayCreateRequest.Port,
-		vpnGatewayCreateRequest.DnsServer)
+	if vpnGatewayCreateRequest.ServerPrivateKey != "" {
+		if err := wireguard.ValidatePrivateKey(vpnGatewayCreateRequest.ServerPrivateKey); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if _, err := helper.NormalizeCIDRs(vpnGatewayCreateRequest.Routes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := services.CreateVpnGateway(adminUuid.(string), vpnGatewayCreateRequest)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -100,9 +111,9 @@ func CreateVpnGateway(c *gin.Context) {
 //	@Param			id				path		string	true	"gateway id"
 //	@Router			/api/v1/admin/gateway/{id} [delete]
 func DeleteVpnGateway(c *gin.Context) {
-
+	adminUuid, _ := c.Get("userUuid")
 	gatewayUuid := c.Param("id")
-	err := services.DeleteVpnGateway(gatewayUuid)
+	err := services.DeleteVpnGateway(adminUuid.(string), gatewayUuid)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -127,18 +138,24 @@ func DeleteVpnGateway(c *gin.Context) {
 //	@Param			id						path		string							true	"gateway id"
 //	@Router			/api/v1/admin/gateway/{id} [put]
 func UpdateVpnGateway(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
 	var vpnGatewayUpdateRequest models.VpnGatewayUpdateRequest
 	if err := c.ShouldBindJSON(&vpnGatewayUpdateRequest); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if vpnGatewayUpdateRequest.ServerPrivateKey != "" {
+		if err := wireguard.ValidatePrivateKey(vpnGatewayUpdateRequest.ServerPrivateKey); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	if _, err := helper.NormalizeCIDRs(vpnGatewayUpdateRequest.Routes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	gatewayUuid := c.Param("id")
-	err := services.UpdateVpnGateway(gatewayUuid,
-		vpnGatewayUpdateRequest.Name,
-		vpnGatewayUpdateRequest.Domain,
-		vpnGatewayUpdateRequest.IpAddress,
-		vpnGatewayUpdateRequest.Port,
-		vpnGatewayUpdateRequest.DnsServer)
+	err := services.UpdateVpnGateway(adminUuid.(string), gatewayUuid, vpnGatewayUpdateRequest)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -195,6 +212,7 @@ func GetGatewayByUUID(c *gin.Context) {
 	includeUsersWithClients := false
 	includeGroups := false
 	includeIpPool := false
+	includeHealthHistory := false
 	for _, include := range includeQueryParams {
 		if include == "users-clients" {
 			includeUsersWithClients = true
@@ -204,12 +222,14 @@ func GetGatewayByUUID(c *gin.Context) {
 			includeGroups = true
 		} else if include == "clients" {
 			includeClients = true
-		} else if include == "ipPool" {
+		} else if include == "ipPool" || include == "ipAllocations" {
 			includeIpPool = true
+		} else if include == "healthHistory" {
+			includeHealthHistory = true
 		}
 	}
 
-	vpnGatewayDetail, err := services.GetVpnGatewayByUUID(gatewayUuid, includeUsers, includeClients, includeUsersWithClients, includeGroups, includeIpPool)
+	vpnGatewayDetail, err := services.GetVpnGatewayByUUID(gatewayUuid, includeUsers, includeClients, includeUsersWithClients, includeGroups, includeIpPool, includeHealthHistory)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -233,8 +253,303 @@ func GetGatewayByUUID(c *gin.Context) {
 //
 //	@Router			/api/v1/admin/gateway/{id}/reset [delete]
 func ClearVpnGatewayClientsAndIPPool(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
+	gatewayUuid := c.Param("id")
+	err := services.ClearVpnGatewayClientsAndIPPool(adminUuid.(string), gatewayUuid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// ListGatewayOperations godoc
+//
+//	@Summary		ListGatewayOperations
+//	@Description	List the operations queued for delivery to the gateway, newest first
+//	@Tags			admin-gateway
+//	@Accept			json
+//	@Produce		json
+//	@Success		200				{array}		models.GatewayOperation
+//	@Failure		400				{object}	any
+//	@Failure		401				{object}	any
+//	@Failure		500				{object}	any
+//	@Param			Authorization	header		string	true	"Insert your token"	default(Bearer <token>)
+//	@Param			id				path		string	true	"gateway id"
+//	@Param			status			query		string	false	"Pending, Succeeded, Failed or Dismissed"
+//
+//	@Router			/api/v1/admin/gateway/{id}/operations [get]
+func ListGatewayOperations(c *gin.Context) {
+	gatewayUuid := c.Param("id")
+	status := c.Query("status")
+	gatewayOperations, err := services.ListGatewayOperations(gatewayUuid, status)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gatewayOperations)
+}
+
+// RetryGatewayOperation godoc
+//
+//	@Summary		RetryGatewayOperation
+//	@Description	Queue a failed gateway operation for delivery again
+//	@Tags			admin-gateway
+//	@Accept			json
+//	@Produce		json
+//	@Success		200				{object}	any
+//	@Failure		400				{object}	any
+//	@Failure		401				{object}	any
+//	@Failure		500				{object}	any
+//	@Param			Authorization	header		string	true	"Insert your token"	default(Bearer <token>)
+//	@Param			id				path		string	true	"gateway id"
+//	@Param			operationId		path		string	true	"operation id"
+//
+//	@Router			/api/v1/admin/gateway/{id}/operations/{operationId}/retry [put]
+func RetryGatewayOperation(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
+	gatewayUuid := c.Param("id")
+	operationUuid := c.Param("operationId")
+	err := services.RetryGatewayOperation(adminUuid.(string), gatewayUuid, operationUuid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// DismissGatewayOperation godoc
+//
+//	@Summary		DismissGatewayOperation
+//	@Description	Give up on a failed gateway operation so that the later operations of the gateway are delivered
+//	@Tags			admin-gateway
+//	@Accept			json
+//	@Produce		json
+//	@Success		200				{object}	any
+//	@Failure		400				{object}	any
+//	@Failure		401				{object}	any
+//	@Failure		500				{object}	any
+//	@Param			Authorization	header		string	true	"Insert your token"	default(Bearer <token>)
+//	@Param			id				path		string	true	"gateway id"
+//	@Param			operationId		path		string	true	"operation id"
+//
+//	@Router			/api/v1/admin/gateway/{id}/operations/{operationId}/dismiss [put]
+func DismissGatewayOperation(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
+	gatewayUuid := c.Param("id")
+	operationUuid := c.Param("operationId")
+	err := services.DismissGatewayOperation(adminUuid.(string), gatewayUuid, operationUuid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// GetGatewayDriftReport godoc
+//
+//	@Summary		GetGatewayDriftReport
+//	@Description	Get the latest comparison of the peers held by the gateway with its active clients
+//	@Tags			admin-gateway
+//	@Accept			json
+//	@Produce		json
+//	@Success		200				{object}	models.VpnGatewayDriftReport
+//	@Failure		400				{object}	any
+//	@Failure		401				{object}	any
+//	@Failure		500				{object}	any
+//	@Param			Authorization	header		string	true	"Insert your token"	default(Bearer <token>)
+//	@Param			id				path		string	true	"gateway id"
+//
+//	@Router			/api/v1/admin/gateway/{id}/drift [get]
+func GetGatewayDriftReport(c *gin.Context) {
+	gatewayUuid := c.Param("id")
+	driftReport, err := services.GetVpnGatewayDriftReport(gatewayUuid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, driftReport)
+}
+
+// GetGatewayIPUtilization godoc
+//
+//	@Summary		GetGatewayIPUtilization
+//	@Description	Count the allocated, reserved and available client addresses of the gateway's vpn cidr
+//	@Tags			admin-gateway
+//	@Accept			json
+//	@Produce		json
+//	@Success		200				{object}	models.IPUtilizationReport
+//	@Failure		400				{object}	any
+//	@Failure		401				{object}	any
+//	@Failure		500				{object}	any
+//	@Param			Authorization	header		string	true	"Insert your token"	default(Bearer <token>)
+//	@Param			id				path		string	true	"gateway id"
+//
+//	@Router			/api/v1/admin/gateway/{id}/utilization [get]
+func GetGatewayIPUtilization(c *gin.Context) {
+	gatewayUuid := c.Param("id")
+	utilizationReport, err := services.GetVpnGatewayIPUtilization(gatewayUuid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, utilizationReport)
+}
+
+// RepairGatewayIPAllocations godoc
+//
+//	@Summary		RepairGatewayIPAllocations
+//	@Description	Link the ip allocations of the gateway to their clients and reclaim the addresses leaked by inactive clients
+//	@Tags			admin-gateway
+//	@Accept			json
+//	@Produce		json
+//	@Success		200				{object}	models.IPAllocationRepairReport
+//	@Failure		400				{object}	any
+//	@Failure		401				{object}	any
+//	@Failure		500				{object}	any
+//	@Param			Authorization	header		string	true	"Insert your token"	default(Bearer <token>)
+//	@Param			id				path		string	true	"gateway id"
+//
+//	@Router			/api/v1/admin/gateway/{id}/ip-allocations/repair [put]
+func RepairGatewayIPAllocations(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
+	gatewayUuid := c.Param("id")
+	repairReport, err := services.RepairVpnGatewayIPAllocations(adminUuid.(string), gatewayUuid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, repairReport)
+}
+
+// ReconcileGateway godoc
+//
+//	@Summary		ReconcileGateway
+//	@Description	Compare the peers held by the gateway with its active clients now and queue the corrections
+//	@Tags			admin-gateway
+//	@Accept			json
+//	@Produce		json
+//	@Success		200				{object}	models.VpnGatewayDriftReport
+//	@Failure		400				{object}	any
+//	@Failure		401				{object}	any
+//	@Failure		500				{object}	any
+//	@Param			Authorization	header		string	true	"Insert your token"	default(Bearer <token>)
+//	@Param			id				path		string	true	"gateway id"
+//
+//	@Router			/api/v1/admin/gateway/{id}/reconcile [put]
+func ReconcileGateway(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
+	gatewayUuid := c.Param("id")
+	driftReport, err := services.ReconcileVpnGateway(adminUuid.(string), gatewayUuid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, driftReport)
+}
+
+// ListGatewayActiveSessions godoc
+//
+//	@Summary		ListGatewayActiveSessions
+//	@Description	List the clients of the gateway with a recent handshake
+//	@Tags			admin-gateway
+//	@Accept			json
+//	@Produce		json
+//	@Success		200				{array}		models.ActiveSession
+//	@Failure		400				{object}	any
+//	@Failure		401				{object}	any
+//	@Failure		500				{object}	any
+//	@Param			Authorization	header		string	true	"Insert your token"	default(Bearer <token>)
+//	@Param			id				path		string	true	"gateway id"
+//
+//	@Router			/api/v1/admin/gateway/{id}/sessions [get]
+func ListGatewayActiveSessions(c *gin.Context) {
+	gatewayUuid := c.Param("id")
+	activeSessions, err := services.ListActiveSessionsOfVpnGateway(gatewayUuid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, activeSessions)
+}
+
+// CreateGatewayIPReservation godoc
+//
+//	@Summary		CreateGatewayIPReservation
+//	@Description	Reserve an address of the gateway's vpn cidr for the clients of a user
+//	@Tags			admin-gateway
+//	@Accept			json
+//	@Produce		json
+//	@Success		201							{object}	models.IPReservation
+//	@Failure		400							{object}	any
+//	@Failure		401							{object}	any
+//	@Failure		500							{object}	any
+//	@Param			Authorization				header		string								true	"Insert your token"	default(Bearer <token>)
+//	@Param			IPReservationCreateRequest	body		models.IPReservationCreateRequest	true	"Reservation details"
+//	@Param			id							path		string								true	"gateway id"
+//	@Router			/api/v1/admin/gateway/{id}/reservations [post]
+func CreateGatewayIPReservation(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
+	var ipReservationCreateRequest models.IPReservationCreateRequest
+	if err := c.ShouldBindJSON(&ipReservationCreateRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	gatewayUuid := c.Param("id")
+	ipReservation, err := services.CreateIPReservation(adminUuid.(string), gatewayUuid, ipReservationCreateRequest)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, ipReservation)
+}
+
+// ListGatewayIPReservations godoc
+//
+//	@Summary		ListGatewayIPReservations
+//	@Description	ListGatewayIPReservations
+//	@Tags			admin-gateway
+//	@Accept			json
+//	@Produce		json
+//	@Success		200				{array}		models.IPReservation
+//	@Failure		400				{object}	any
+//	@Failure		401				{object}	any
+//	@Failure		500				{object}	any
+//	@Param			Authorization	header		string	true	"Insert your token"	default(Bearer <token>)
+//	@Param			id				path		string	true	"gateway id"
+//
+//	@Router			/api/v1/admin/gateway/{id}/reservations [get]
+func ListGatewayIPReservations(c *gin.Context) {
+	gatewayUuid := c.Param("id")
+	ipReservations, err := services.ListIPReservations(gatewayUuid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, ipReservations)
+}
+
+// DeleteGatewayIPReservation godoc
+//
+//	@Summary		DeleteGatewayIPReservation
+//	@Description	Return a reserved address to the pool, a client holding it keeps it until deleted
+//	@Tags			admin-gateway
+//	@Accept			json
+//	@Produce		json
+//	@Success		200				{object}	any
+//	@Failure		400				{object}	any
+//	@Failure		401				{object}	any
+//	@Failure		500				{object}	any
+//	@Param			Authorization	header		string	true	"Insert your token"	default(Bearer <token>)
+//	@Param			id				path		string	true	"gateway id"
+//	@Param			reservationId	path		string	true	"reservation id"
+//
+//	@Router			/api/v1/admin/gateway/{id}/reservations/{reservationId} [delete]
+func DeleteGatewayIPReservation(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
 	gatewayUuid := c.Param("id")
-	err := services.ClearVpnGatewayClientsAndIPPool(gatewayUuid)
+	reservationUuid := c.Param("reservationId")
+	err := services.DeleteIPReservation(adminUuid.(string), gatewayUuid, reservationUuid)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -6,6 +6,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/leetsecure/qryptic-controller/internal/models"
 	"github.com/leetsecure/qryptic-controller/internal/services"
+	"github.com/leetsecure/qryptic-controller/internal/utils/helper"
 	"github.com/leetsecure/qryptic-controller/internal/utils/logger"
 )
 
@@ -21,12 +22,17 @@ import (
 //	@Param			GroupCreateRequest	body		models.GroupCreateRequest	true	"Group details"
 //	@Router			/api/v1/admin/group [post]
 func CreateGroup(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
 	var groupCreateRequest models.GroupCreateRequest
 	if err := c.ShouldBindJSON(&groupCreateRequest); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
-	err := services.CreateGroup(groupCreateRequest.Name)
+	if _, err := helper.NormalizeCIDRs(groupCreateRequest.Routes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := services.CreateGroup(adminUuid.(string), groupCreateRequest.Name, groupCreateRequest.Routes, groupCreateRequest.MaxClientLifetime)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -49,8 +55,9 @@ func CreateGroup(c *gin.Context) {
 //	@Param			id				path		string	true	"group id"
 //	@Router			/api/v1/admin/group/{id} [delete]
 func DeleteGroup(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
 	groupUuid := c.Param("id")
-	err := services.DeleteVpnGateway(groupUuid)
+	err := services.DeleteGroup(adminUuid.(string), groupUuid)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -75,14 +82,21 @@ func DeleteGroup(c *gin.Context) {
 //
 //	@Router			/api/v1/admin/group/{id} [put]
 func UpdateGroup(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
 	var groupUpdateRequest models.GroupUpdateRequest
 	if err := c.ShouldBindJSON(&groupUpdateRequest); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if _, err := helper.NormalizeCIDRs(groupUpdateRequest.Routes); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 	groupUuid := c.Param("id")
-	err := services.UpdateGroup(groupUuid,
-		groupUpdateRequest.Name)
+	err := services.UpdateGroup(adminUuid.(string), groupUuid,
+		groupUpdateRequest.Name,
+		groupUpdateRequest.Routes,
+		groupUpdateRequest.MaxClientLifetime)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -108,6 +122,7 @@ func UpdateGroup(c *gin.Context) {
 //
 //	@Router			/api/v1/admin/group/{id}/{action}/users [put]
 func AddRemoveUsersInGroup(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
 	log := logger.Default()
 	var groupUpdateUserRequest models.GroupUpdateUserRequest
 	if err := c.ShouldBindJSON(&groupUpdateUserRequest); err != nil {
@@ -121,7 +136,7 @@ func AddRemoveUsersInGroup(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid action"})
 		return
 	}
-	err := services.AddRemoveUsersInGroup(action,
+	err := services.AddRemoveUsersInGroup(adminUuid.(string), action,
 		groupUuid,
 		groupUpdateUserRequest.UserUuids)
 	if err != nil {
@@ -0,0 +1,144 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leetsecure/qryptic-controller/internal/models"
+	"github.com/leetsecure/qryptic-controller/internal/services"
+)
+
+// CreateAccessPolicy godoc
+//
+//	@Summary		CreateAccessPolicy
+//	@Description	Allow or deny a user or group traffic to a destination through a gateway
+//	@Tags			admin-policy
+//	@Accept			json
+//	@Produce		json
+//	@Success		201							{object}	models.AccessPolicy
+//	@Failure		400							{object}	any
+//	@Failure		401							{object}	any
+//	@Failure		500							{object}	any
+//	@Param			Authorization				header		string								true	"Insert your token"	default(Bearer <token>)
+//	@Param			AccessPolicyCreateRequest	body		models.AccessPolicyCreateRequest	true	"Policy details"
+//	@Router			/api/v1/admin/policy [post]
+func CreateAccessPolicy(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
+	var accessPolicyCreateRequest models.AccessPolicyCreateRequest
+	if err := c.ShouldBindJSON(&accessPolicyCreateRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	accessPolicy, err := services.CreateAccessPolicy(adminUuid.(string), accessPolicyCreateRequest)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, accessPolicy)
+}
+
+// UpdateAccessPolicy godoc
+//
+//	@Summary		UpdateAccessPolicy
+//	@Description	UpdateAccessPolicy
+//	@Tags			admin-policy
+//	@Accept			json
+//	@Produce		json
+//	@Success		200							{object}	any
+//	@Failure		400							{object}	any
+//	@Failure		401							{object}	any
+//	@Failure		500							{object}	any
+//	@Param			Authorization				header		string								true	"Insert your token"	default(Bearer <token>)
+//	@Param			AccessPolicyUpdateRequest	body		models.AccessPolicyUpdateRequest	true	"Policy details"
+//	@Param			id							path		string								true	"policy id"
+//	@Router			/api/v1/admin/policy/{id} [put]
+func UpdateAccessPolicy(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
+	var accessPolicyUpdateRequest models.AccessPolicyUpdateRequest
+	if err := c.ShouldBindJSON(&accessPolicyUpdateRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	accessPolicyUuid := c.Param("id")
+	err := services.UpdateAccessPolicy(adminUuid.(string), accessPolicyUuid, accessPolicyUpdateRequest)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// DeleteAccessPolicy godoc
+//
+//	@Summary		DeleteAccessPolicy
+//	@Description	DeleteAccessPolicy
+//	@Tags			admin-policy
+//	@Accept			json
+//	@Produce		json
+//	@Success		200				{object}	any
+//	@Failure		400				{object}	any
+//	@Failure		401				{object}	any
+//	@Failure		500				{object}	any
+//	@Param			Authorization	header		string	true	"Insert your token"	default(Bearer <token>)
+//
+//	@Param			id				path		string	true	"policy id"
+//	@Router			/api/v1/admin/policy/{id} [delete]
+func DeleteAccessPolicy(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
+	accessPolicyUuid := c.Param("id")
+	err := services.DeleteAccessPolicy(adminUuid.(string), accessPolicyUuid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// ListAccessPolicies godoc
+//
+//	@Summary		ListAccessPolicies
+//	@Description	List the access policies, ordered by gateway and evaluation priority
+//	@Tags			admin-policy
+//	@Accept			json
+//	@Produce		json
+//	@Success		200				{array}		models.AccessPolicy
+//	@Failure		401				{object}	any
+//	@Failure		500				{object}	any
+//	@Param			Authorization	header		string	true	"Insert your token"	default(Bearer <token>)
+//	@Param			gatewayUuid		query		string	false	"gateway id"
+//	@Param			userUuid		query		string	false	"user id"
+//	@Param			groupUuid		query		string	false	"group id"
+//	@Router			/api/v1/admin/policy/list [get]
+func ListAccessPolicies(c *gin.Context) {
+	accessPolicies, err := services.ListAccessPolicies(c.Query("gatewayUuid"), c.Query("userUuid"), c.Query("groupUuid"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, accessPolicies)
+}
+
+// GetAccessPolicyByUUID godoc
+//
+//	@Summary		GetAccessPolicyByUUID
+//	@Description	GetAccessPolicyByUUID
+//	@Tags			admin-policy
+//	@Accept			json
+//	@Produce		json
+//	@Success		200				{object}	models.AccessPolicy
+//	@Failure		400				{object}	any
+//	@Failure		401				{object}	any
+//	@Failure		500				{object}	any
+//	@Param			Authorization	header		string	true	"Insert your token"	default(Bearer <token>)
+//
+//	@Param			id				path		string	true	"policy id"
+//	@Router			/api/v1/admin/policy/{id} [get]
+func GetAccessPolicyByUUID(c *gin.Context) {
+	accessPolicyUuid := c.Param("id")
+	accessPolicy, err := services.GetAccessPolicyByUUID(accessPolicyUuid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, accessPolicy)
+}
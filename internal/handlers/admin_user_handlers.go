@@ -25,6 +25,7 @@ import (
 //	@Param			RegisterUserRequest	body		models.RegisterUserRequest	true	"User details"
 //	@Router			/api/v1/admin/user [post]
 func RegisterUser(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
 	var registerUserRequest models.RegisterUserRequest
 
 	if err := c.ShouldBindJSON(&registerUserRequest); err != nil {
@@ -44,7 +45,7 @@ func RegisterUser(c *gin.Context) {
 		return
 	}
 
-	err := services.RegisterUser(registerUserRequest.EmailId, registerUserRequest.Password, string(registerUserRequest.Role), isPasswordSet)
+	err := services.RegisterUser(adminUuid.(string), registerUserRequest.EmailId, registerUserRequest.Password, string(registerUserRequest.Role), isPasswordSet, registerUserRequest.MaxClientLifetime)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -70,9 +71,10 @@ func RegisterUser(c *gin.Context) {
 //
 //	@Router			/api/v1/admin/user/{id} [delete]
 func DeleteUser(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
 	userUuid := c.Param("id")
 
-	err := services.DeleteUser(userUuid)
+	err := services.DeleteUser(adminUuid.(string), userUuid)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -99,6 +101,7 @@ func DeleteUser(c *gin.Context) {
 //
 //	@Router			/api/v1/admin/user/{id} [put]
 func UpdateUser(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
 	var updateUserRequest models.UpdateUserRequest
 	if err := c.ShouldBindJSON(&updateUserRequest); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
@@ -119,7 +122,7 @@ func UpdateUser(c *gin.Context) {
 		return
 	}
 
-	err := services.UpdateUser(userUuid, updateUserRequest.EmailId, updateUserRequest.NewPassword, string(updateUserRequest.Role), isPasswordSet)
+	err := services.UpdateUser(adminUuid.(string), userUuid, updateUserRequest.EmailId, updateUserRequest.NewPassword, string(updateUserRequest.Role), isPasswordSet, updateUserRequest.MaxClientLifetime)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -211,3 +214,78 @@ func GetUserByUUID(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, userDetail)
 }
+
+// ListUserActiveSessions godoc
+//
+//	@Summary		ListUserActiveSessions
+//	@Description	List the clients of the user with a recent handshake
+//	@Tags			admin-user
+//	@Accept			json
+//	@Produce		json
+//	@Success		200				{array}		models.ActiveSession
+//	@Failure		400				{object}	any
+//	@Failure		401				{object}	any
+//	@Failure		500				{object}	any
+//	@Param			Authorization	header		string	true	"Insert your token"	default(Bearer <token>)
+//
+//	@Param			id				path		string	true	"user id"
+//
+//	@Router			/api/v1/admin/user/{id}/sessions [get]
+func ListUserActiveSessions(c *gin.Context) {
+	userUuid := c.Param("id")
+	activeSessions, err := services.ListActiveSessionsOfUser(userUuid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, activeSessions)
+}
+
+// ListDevicesByAdmin godoc
+//
+//	@Summary		ListDevicesByAdmin
+//	@Description	List the registered devices, revoked ones only when includeRevoked is true
+//	@Tags			admin-user
+//	@Accept			json
+//	@Produce		json
+//	@Success		200				{array}		models.Device
+//	@Failure		401				{object}	any
+//	@Failure		500				{object}	any
+//	@Param			Authorization	header		string	true	"Insert your token"	default(Bearer <token>)
+//	@Param			userUuid		query		string	false	"user id"
+//	@Param			includeRevoked	query		bool	false	"include revoked devices"
+//	@Router			/api/v1/admin/device/list [get]
+func ListDevicesByAdmin(c *gin.Context) {
+	devices, err := services.ListDevices(c.Query("userUuid"), c.Query("includeRevoked") == "true")
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, devices)
+}
+
+// RevokeDeviceByAdmin godoc
+//
+//	@Summary		RevokeDeviceByAdmin
+//	@Description	Revoke a device along with its active clients
+//	@Tags			admin-user
+//	@Accept			json
+//	@Produce		json
+//	@Success		200				{object}	any
+//	@Failure		400				{object}	any
+//	@Failure		401				{object}	any
+//	@Failure		500				{object}	any
+//	@Param			Authorization	header		string	true	"Insert your token"	default(Bearer <token>)
+//
+//	@Param			id				path		string	true	"device id"
+//	@Router			/api/v1/admin/device/{id} [delete]
+func RevokeDeviceByAdmin(c *gin.Context) {
+	adminUuid, _ := c.Get("userUuid")
+	deviceUuid := c.Param("id")
+	err := services.RevokeDevice(adminUuid.(string), "", deviceUuid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
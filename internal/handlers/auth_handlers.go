@@ -74,14 +74,19 @@ func GetSsoConfiguration(c *gin.Context) {
 //
 //	@Summary		InitiateSSOAuth
 //	@ID				InitiateSSOAuth
-//	@Description	InitiateSSOAuth
+//	@Description	Redirect to the authorization endpoint of the OIDC provider for an app doing PKCE
 //	@Tags			public
 //	@Accept			json
 //	@Produce		json
-//	@Success		200			{object}	any
-//	@Failure		401			{object}	any
-//	@Failure		500			{object}	any
-//	@Param			provider	path		string	true	"provider"
+//	@Success		200						{object}	any
+//	@Failure		401						{object}	any
+//	@Failure		500						{object}	any
+//	@Param			provider				path		string	true	"provider"
+//	@Param			platform				query		string	true	"platform"
+//	@Param			code_challenge			query		string	true	"code challenge"
+//	@Param			redirect_uri			query		string	true	"redirect uri"
+//	@Param			client_id				query		string	false	"client id of the sso config of the app"
+//	@Param			code_challenge_method	query		string	false	"S256 by default"
 //
 //	@Router			/api/v1/auth/{provider}/sso/initiate [get]
 func InitiateSSOAuth(c *gin.Context) {
@@ -90,17 +95,9 @@ func InitiateSSOAuth(c *gin.Context) {
 	err := services.AuthProviderValidate(provider)
 	if err != nil {
 		log.Error(err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err})
-		return
-	}
-	if provider == "google" {
-		InitiateGoogleSSOAuth(c)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
-}
-
-func InitiateGoogleSSOAuth(c *gin.Context) {
-	log := logger.Default()
 	clientId := c.DefaultQuery("client_id", "")
 	platform := c.DefaultQuery("platform", "")
 	if platform == "" {
@@ -118,14 +115,13 @@ func InitiateGoogleSSOAuth(c *gin.Context) {
 		return
 	}
 	codeChallengeMethod := c.DefaultQuery("code_challenge_method", "S256")
-	authURL, err := services.InitiateGoogleSsoAuth(clientId, platform, codeChallenge, redirectUri, codeChallengeMethod)
+	authURL, err := services.InitiateSsoAuth(provider, clientId, platform, codeChallenge, redirectUri, codeChallengeMethod)
 	if err != nil {
 		log.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Internal Server Error"})
 		return
 	}
 	c.Redirect(http.StatusFound, authURL)
-
 }
 
 // This is for sso signin with PKCE support
@@ -137,10 +133,13 @@ func InitiateGoogleSSOAuth(c *gin.Context) {
 //	@Tags			public
 //	@Accept			json
 //	@Produce		json
-//	@Success		200			{object}	any
-//	@Failure		401			{object}	any
-//	@Failure		500			{object}	any
-//	@Param			provider	path		string	true	"provider"
+//	@Success		200				{object}	any
+//	@Failure		401				{object}	any
+//	@Failure		500				{object}	any
+//	@Param			provider		path		string	true	"provider"
+//	@Param			code			query		string	true	"authorization code"
+//	@Param			state			query		string	true	"state"
+//	@Param			code_verifier	query		string	true	"code verifier"
 //
 //	@Router			/api/v1/auth/{provider}/sso/callback [get]
 func UserAuthSSOCallback(c *gin.Context) {
@@ -152,14 +151,6 @@ func UserAuthSSOCallback(c *gin.Context) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "provider not allowed"})
 		return
 	}
-	if provider == "google" {
-		GoogleSSOCallback(c)
-		return
-	}
-}
-
-func GoogleSSOCallback(c *gin.Context) {
-	log := logger.Default()
 	code := c.DefaultQuery("code", "")
 	if code == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing code"})
@@ -175,40 +166,32 @@ func GoogleSSOCallback(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing code verifier"})
 		return
 	}
-	codeChallenge, redirectUrl, err := services.ValidateStateJWT(stateJWT)
+	codeChallenge, redirectUrl, clientId, err := services.ValidateStateJWT(stateJWT)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid state JWT"})
 		return
 	}
-	// Step 3: Verify the code verifier matches the code challenge
+	// Verify the code verifier matches the code challenge
 	if !services.VerifyCodeVerifier(codeVerifier, codeChallenge) {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code verifier"})
 		return
 	}
 
-	// Step 4: Exchange the authorization code for tokens from Google
-	tokenResponse, err := services.ExchangeCodeForTokens(code, codeVerifier, redirectUrl)
+	// Exchange the authorization code for tokens from the provider
+	ssoIdentity, err := services.ExchangeCodeForIdentity(provider, clientId, code, codeVerifier, redirectUrl)
 	if err != nil {
+		log.Error(err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to exchange code for tokens"})
 		return
 	}
-	log.Info(tokenResponse)
-	userInfo, err := services.GetUserInfoFromGoogle(tokenResponse.AccessToken)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate JWT"})
-		return
-	}
-	log.Info(userInfo)
 
-	// Step 5: Generate a custom JWT for the frontend
-	userEmail := userInfo.Email
-	authToken, err := services.UserSSOLogin(userEmail)
+	// Generate a custom JWT for the frontend
+	authToken, err := services.UserSSOLogin(ssoIdentity)
 	if err != nil {
 		c.AbortWithError(http.StatusUnauthorized, err)
 		return
 	}
 	c.JSON(http.StatusOK, gin.H{"authToken": authToken})
-
 }
 
 // This is for mobile devices where we only need to validate the idtoken given by the user
@@ -224,6 +207,7 @@ func GoogleSSOCallback(c *gin.Context) {
 //	@Failure		401			{object}	any
 //	@Failure		500			{object}	any
 //	@Param			provider	path		string	true	"provider"
+//	@Param			token		query		string	true	"id token issued by the provider"
 //
 //	@Router			/api/v1/auth/{provider}/sso/token [get]
 func UserAuthVerifySSOToken(c *gin.Context) {
@@ -232,32 +216,22 @@ func UserAuthVerifySSOToken(c *gin.Context) {
 	err := services.AuthProviderValidate(provider)
 	if err != nil {
 		log.Error(err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err})
-		return
-	}
-	if provider == "google" {
-		UserAuthVerifyGoogleSSOToken(c)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
-}
-
-// This is for mobile devices using google_sign_in package where we only need to validate the google idtoken given by the user
-func UserAuthVerifyGoogleSSOToken(c *gin.Context) {
-	log := logger.Default()
 	token := c.Query("token")
 	if token == "" {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing token"})
 		return
 	}
-	emailId, err := services.VerifyGoogleSSOToken(token)
+	ssoIdentity, err := services.VerifySSOToken(provider, token)
 	if err != nil {
 		log.Info(err)
 		c.JSON(http.StatusUnauthorized, nil)
 		return
 	}
 
-	userEmail := emailId
-	authToken, err := services.UserSSOLogin(userEmail)
+	authToken, err := services.UserSSOLogin(ssoIdentity)
 	if err != nil {
 		c.AbortWithError(http.StatusUnauthorized, err)
 		return
@@ -265,11 +239,11 @@ func UserAuthVerifyGoogleSSOToken(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"authToken": authToken})
 }
 
-// WebGoogleLoginInitiate godoc
+// WebSSOLoginInitiate godoc
 //
-//	@Summary		WebGoogleLoginInitiate
-//	@ID				WebGoogleLoginInitiate
-//	@Description	WebGoogleLoginInitiate
+//	@Summary		WebSSOLoginInitiate
+//	@ID				WebSSOLoginInitiate
+//	@Description	Redirect to the authorization endpoint of the OIDC provider with the Website sso config
 //	@Tags			public
 //	@Accept			json
 //	@Produce		json
@@ -279,13 +253,13 @@ func UserAuthVerifyGoogleSSOToken(c *gin.Context) {
 //	@Param			provider		path		string	true	"provider"
 //	@Param			code_challenge	query		string	true	"string"	code_challenge(string)
 //	@Router			/api/v1/auth/{provider}/web/sso/initiate [get]
-func WebGoogleLoginInitiate(c *gin.Context) {
+func WebSSOLoginInitiate(c *gin.Context) {
 	log := logger.Default()
 	provider := c.Param("provider")
 	err := services.AuthProviderValidate(provider)
 	if err != nil {
 		log.Error(err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 	codeChallenge := c.DefaultQuery("code_challenge", "")
@@ -293,7 +267,7 @@ func WebGoogleLoginInitiate(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing code challenge"})
 		return
 	}
-	authURL, err := services.WebGoogleLoginInitiate(codeChallenge)
+	authURL, err := services.WebSSOLoginInitiate(provider, codeChallenge)
 	if err != nil {
 		log.Info(err)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "internal error"})
@@ -302,13 +276,13 @@ func WebGoogleLoginInitiate(c *gin.Context) {
 	c.Redirect(http.StatusFound, authURL)
 }
 
-func WebGoogleLoginCallback(c *gin.Context) {
+func WebSSOLoginCallback(c *gin.Context) {
 	log := logger.Default()
 	provider := c.Param("provider")
 	err := services.AuthProviderValidate(provider)
 	if err != nil {
 		log.Error(err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 	state := c.DefaultQuery("state", "")
@@ -321,7 +295,7 @@ func WebGoogleLoginCallback(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing code"})
 		return
 	}
-	err = services.WebGoogleLoginCallback(state, code)
+	err = services.WebSSOLoginCallback(provider, state, code)
 	if err != nil {
 		log.Error(err)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "internal error"})
@@ -330,11 +304,11 @@ func WebGoogleLoginCallback(c *gin.Context) {
 	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Authentication Completed. You can close this tab"})
 }
 
-// WebGoogleLoginToken godoc
+// WebSSOLoginToken godoc
 //
-//	@Summary		WebGoogleLoginToken
-//	@ID				WebGoogleLoginToken
-//	@Description	WebGoogleLoginToken
+//	@Summary		WebSSOLoginToken
+//	@ID				WebSSOLoginToken
+//	@Description	WebSSOLoginToken
 //	@Tags			public
 //	@Accept			json
 //	@Produce		json
@@ -345,13 +319,13 @@ func WebGoogleLoginCallback(c *gin.Context) {
 //	@Param			code_verifier	query		string	true	"string"	code_verifier(string)
 //	@Param			code_challenge	query		string	true	"string"	code_challenge(string)
 //	@Router			/api/v1/auth/{provider}/web/sso/token [get]
-func WebGoogleLoginToken(c *gin.Context) {
+func WebSSOLoginToken(c *gin.Context) {
 	log := logger.Default()
 	provider := c.Param("provider")
 	err := services.AuthProviderValidate(provider)
 	if err != nil {
 		log.Error(err)
-		c.JSON(http.StatusUnauthorized, gin.H{"error": err})
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 	code_verifier := c.DefaultQuery("code_verifier", "")
@@ -365,7 +339,7 @@ func WebGoogleLoginToken(c *gin.Context) {
 		return
 	}
 
-	sessionClosed, authToken, err := services.WebGoogleLoginToken(code_verifier, code_challenge)
+	sessionClosed, authToken, err := services.WebSSOLoginToken(code_verifier, code_challenge)
 	if err != nil {
 		if sessionClosed {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorised"})
@@ -376,3 +350,95 @@ func WebGoogleLoginToken(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{"authToken": authToken})
 }
+
+// SAMLMetadata godoc
+//
+//	@Summary		SAMLMetadata
+//	@ID				SAMLMetadata
+//	@Description	Service provider metadata to register the controller with the SAML identity provider
+//	@Tags			public
+//	@Produce		xml
+//	@Success		200			{object}	any
+//	@Failure		404			{object}	any
+//	@Failure		500			{object}	any
+//	@Param			provider	path		string	true	"provider"
+//	@Router			/api/v1/auth/saml/{provider}/metadata [get]
+func SAMLMetadata(c *gin.Context) {
+	log := logger.Default()
+	metadata, err := services.GetSAMLServiceProviderMetadata(c.Param("provider"))
+	if err != nil {
+		log.Error(err)
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	c.Data(http.StatusOK, "application/samlmetadata+xml", metadata)
+}
+
+// SAMLLoginInitiate godoc
+//
+//	@Summary		SAMLLoginInitiate
+//	@ID				SAMLLoginInitiate
+//	@Description	Redirect to the SAML identity provider, the login completes through /api/v1/auth/{provider}/web/sso/token
+//	@Tags			public
+//	@Accept			json
+//	@Produce		json
+//	@Success		302				{object}	any
+//	@Failure		401				{object}	any
+//	@Failure		500				{object}	any
+//	@Param			provider		path		string	true	"provider"
+//	@Param			code_challenge	query		string	true	"string"	code_challenge(string)
+//	@Router			/api/v1/auth/saml/{provider}/login [get]
+func SAMLLoginInitiate(c *gin.Context) {
+	log := logger.Default()
+	provider := c.Param("provider")
+	err := services.AuthProviderValidate(provider)
+	if err != nil {
+		log.Error(err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	codeChallenge := c.DefaultQuery("code_challenge", "")
+	if codeChallenge == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Missing code challenge"})
+		return
+	}
+	redirectURL, err := services.SAMLLoginInitiate(provider, codeChallenge)
+	if err != nil {
+		log.Error(err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "internal error"})
+		return
+	}
+	c.Redirect(http.StatusFound, redirectURL)
+}
+
+// SAMLAssertionConsumer godoc
+//
+//	@Summary		SAMLAssertionConsumer
+//	@ID				SAMLAssertionConsumer
+//	@Description	Assertion consumer service receiving the SAML response of the identity provider
+//	@Tags			public
+//	@Accept			x-www-form-urlencoded
+//	@Produce		json
+//	@Success		200				{object}	any
+//	@Failure		401				{object}	any
+//	@Param			provider		path		string	true	"provider"
+//	@Param			SAMLResponse	formData	string	true	"SAML response"
+//	@Param			RelayState		formData	string	true	"relay state"
+//	@Router			/api/v1/auth/saml/{provider}/acs [post]
+func SAMLAssertionConsumer(c *gin.Context) {
+	log := logger.Default()
+	provider := c.Param("provider")
+	err := services.AuthProviderValidate(provider)
+	if err != nil {
+		log.Error(err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
+	err = services.SAMLAssertionConsumer(provider, c.Request)
+	if err != nil {
+		log.Error(err)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid saml response"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true, "message": "Authentication Completed. You can close this tab"})
+}
@@ -0,0 +1,397 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/leetsecure/qryptic-controller/internal/models"
+	"github.com/leetsecure/qryptic-controller/internal/services"
+	"github.com/leetsecure/qryptic-controller/internal/utils/logger"
+)
+
+const scimContentType = "application/scim+json"
+
+func scimJSON(c *gin.Context, status int, response any) {
+	c.Header("Content-Type", scimContentType)
+	c.JSON(status, response)
+}
+
+// scimError answers with the scim error response matching the error of the service.
+func scimError(c *gin.Context, err error) {
+	status := http.StatusInternalServerError
+	scimType := ""
+	switch {
+	case errors.Is(err, services.ErrSCIMNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, services.ErrSCIMUniqueness):
+		status, scimType = http.StatusConflict, "uniqueness"
+	case errors.Is(err, services.ErrSCIMInvalidFilter):
+		status, scimType = http.StatusBadRequest, "invalidFilter"
+	case errors.Is(err, services.ErrSCIMInvalidPath):
+		status, scimType = http.StatusBadRequest, "invalidPath"
+	case errors.Is(err, services.ErrSCIMInvalidValue):
+		status, scimType = http.StatusBadRequest, "invalidValue"
+	default:
+		logger.Default().Error(err)
+	}
+	scimJSON(c, status, models.SCIMErrorResponse{
+		Schemas:  []string{models.SCIMSchemaError},
+		Status:   strconv.Itoa(status),
+		SCIMType: scimType,
+		Detail:   err.Error(),
+	})
+}
+
+func scimBadRequest(c *gin.Context, err error) {
+	scimJSON(c, http.StatusBadRequest, models.SCIMErrorResponse{
+		Schemas:  []string{models.SCIMSchemaError},
+		Status:   strconv.Itoa(http.StatusBadRequest),
+		SCIMType: "invalidSyntax",
+		Detail:   err.Error(),
+	})
+}
+
+// SCIMServiceProviderConfig godoc
+//
+//	@Summary		SCIMServiceProviderConfig
+//	@ID				SCIMServiceProviderConfig
+//	@Description	scim features supported by the controller
+//	@Tags			scim
+//	@Produce		json
+//	@Success		200				{object}	any
+//	@Failure		401				{object}	models.SCIMErrorResponse
+//	@Param			Authorization	header		string	true	"Insert your scim token"	default(Bearer <token>)
+//	@Router			/scim/v2/ServiceProviderConfig [get]
+func SCIMServiceProviderConfig(c *gin.Context) {
+	scimJSON(c, http.StatusOK, services.SCIMServiceProviderConfig())
+}
+
+// ListSCIMUsers godoc
+//
+//	@Summary		ListSCIMUsers
+//	@ID				ListSCIMUsers
+//	@Description	list the users, filtered with attribute expressions joined by and, e.g. userName eq "jane@example.com"
+//	@Tags			scim
+//	@Produce		json
+//	@Success		200					{object}	models.SCIMListResponse
+//	@Failure		400					{object}	models.SCIMErrorResponse
+//	@Failure		401					{object}	models.SCIMErrorResponse
+//	@Failure		500					{object}	models.SCIMErrorResponse
+//	@Param			Authorization		header		string	true	"Insert your scim token"	default(Bearer <token>)
+//	@Param			filter				query		string	false	"scim filter"
+//	@Param			startIndex			query		int		false	"1-based index of the first result"
+//	@Param			count				query		int		false	"results per page, at most 1000"
+//	@Param			excludedAttributes	query		string	false	"attributes left out of the results"
+//	@Router			/scim/v2/Users [get]
+func ListSCIMUsers(c *gin.Context) {
+	var scimListRequest models.SCIMListRequest
+	if err := c.ShouldBindQuery(&scimListRequest); err != nil {
+		scimBadRequest(c, err)
+		return
+	}
+	response, err := services.ListSCIMUsers(scimListRequest)
+	if err != nil {
+		scimError(c, err)
+		return
+	}
+	scimJSON(c, http.StatusOK, response)
+}
+
+// GetSCIMUser godoc
+//
+//	@Summary		GetSCIMUser
+//	@ID				GetSCIMUser
+//	@Description	GetSCIMUser
+//	@Tags			scim
+//	@Produce		json
+//	@Success		200				{object}	models.SCIMUser
+//	@Failure		401				{object}	models.SCIMErrorResponse
+//	@Failure		404				{object}	models.SCIMErrorResponse
+//	@Param			Authorization	header		string	true	"Insert your scim token"	default(Bearer <token>)
+//	@Param			id				path		string	true	"user id"
+//	@Router			/scim/v2/Users/{id} [get]
+func GetSCIMUser(c *gin.Context) {
+	scimUser, err := services.GetSCIMUser(c.Param("id"))
+	if err != nil {
+		scimError(c, err)
+		return
+	}
+	scimJSON(c, http.StatusOK, scimUser)
+}
+
+// CreateSCIMUser godoc
+//
+//	@Summary		CreateSCIMUser
+//	@ID				CreateSCIMUser
+//	@Description	provision a user signing in with sso, the userName is the email of the user
+//	@Tags			scim
+//	@Accept			json
+//	@Produce		json
+//	@Success		201				{object}	models.SCIMUser
+//	@Failure		400				{object}	models.SCIMErrorResponse
+//	@Failure		401				{object}	models.SCIMErrorResponse
+//	@Failure		409				{object}	models.SCIMErrorResponse
+//	@Param			Authorization	header		string				true	"Insert your scim token"	default(Bearer <token>)
+//	@Param			SCIMUser		body		models.SCIMUser	true	"user"
+//	@Router			/scim/v2/Users [post]
+func CreateSCIMUser(c *gin.Context) {
+	var scimUser models.SCIMUser
+	if err := c.ShouldBindJSON(&scimUser); err != nil {
+		scimBadRequest(c, err)
+		return
+	}
+	scimUser, err := services.CreateSCIMUser(scimUser)
+	if err != nil {
+		scimError(c, err)
+		return
+	}
+	c.Header("Location", scimUser.Meta.Location)
+	scimJSON(c, http.StatusCreated, scimUser)
+}
+
+// ReplaceSCIMUser godoc
+//
+//	@Summary		ReplaceSCIMUser
+//	@ID				ReplaceSCIMUser
+//	@Description	ReplaceSCIMUser
+//	@Tags			scim
+//	@Accept			json
+//	@Produce		json
+//	@Success		200				{object}	models.SCIMUser
+//	@Failure		400				{object}	models.SCIMErrorResponse
+//	@Failure		401				{object}	models.SCIMErrorResponse
+//	@Failure		404				{object}	models.SCIMErrorResponse
+//	@Param			Authorization	header		string				true	"Insert your scim token"	default(Bearer <token>)
+//	@Param			id				path		string				true	"user id"
+//	@Param			SCIMUser		body		models.SCIMUser	true	"user"
+//	@Router			/scim/v2/Users/{id} [put]
+func ReplaceSCIMUser(c *gin.Context) {
+	var scimUser models.SCIMUser
+	if err := c.ShouldBindJSON(&scimUser); err != nil {
+		scimBadRequest(c, err)
+		return
+	}
+	scimUser, err := services.ReplaceSCIMUser(c.Param("id"), scimUser)
+	if err != nil {
+		scimError(c, err)
+		return
+	}
+	scimJSON(c, http.StatusOK, scimUser)
+}
+
+// PatchSCIMUser godoc
+//
+//	@Summary		PatchSCIMUser
+//	@ID				PatchSCIMUser
+//	@Description	patch the user, setting active to false deactivates the user and revokes its active clients on every gateway
+//	@Tags			scim
+//	@Accept			json
+//	@Produce		json
+//	@Success		200					{object}	models.SCIMUser
+//	@Failure		400					{object}	models.SCIMErrorResponse
+//	@Failure		401					{object}	models.SCIMErrorResponse
+//	@Failure		404					{object}	models.SCIMErrorResponse
+//	@Param			Authorization		header		string					true	"Insert your scim token"	default(Bearer <token>)
+//	@Param			id					path		string					true	"user id"
+//	@Param			SCIMPatchRequest	body		models.SCIMPatchRequest	true	"patch operations"
+//	@Router			/scim/v2/Users/{id} [patch]
+func PatchSCIMUser(c *gin.Context) {
+	var scimPatchRequest models.SCIMPatchRequest
+	if err := c.ShouldBindJSON(&scimPatchRequest); err != nil {
+		scimBadRequest(c, err)
+		return
+	}
+	scimUser, err := services.PatchSCIMUser(c.Param("id"), scimPatchRequest)
+	if err != nil {
+		scimError(c, err)
+		return
+	}
+	scimJSON(c, http.StatusOK, scimUser)
+}
+
+// DeleteSCIMUser godoc
+//
+//	@Summary		DeleteSCIMUser
+//	@ID				DeleteSCIMUser
+//	@Description	deprovision the user, its active clients are revoked on every gateway
+//	@Tags			scim
+//	@Success		204				{object}	any
+//	@Failure		401				{object}	models.SCIMErrorResponse
+//	@Failure		404				{object}	models.SCIMErrorResponse
+//	@Param			Authorization	header		string	true	"Insert your scim token"	default(Bearer <token>)
+//	@Param			id				path		string	true	"user id"
+//	@Router			/scim/v2/Users/{id} [delete]
+func DeleteSCIMUser(c *gin.Context) {
+	err := services.DeleteSCIMUser(c.Param("id"))
+	if err != nil {
+		scimError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// ListSCIMGroups godoc
+//
+//	@Summary		ListSCIMGroups
+//	@ID				ListSCIMGroups
+//	@Description	list the groups, filtered with attribute expressions joined by and, e.g. displayName eq "Oncall"
+//	@Tags			scim
+//	@Produce		json
+//	@Success		200					{object}	models.SCIMListResponse
+//	@Failure		400					{object}	models.SCIMErrorResponse
+//	@Failure		401					{object}	models.SCIMErrorResponse
+//	@Failure		500					{object}	models.SCIMErrorResponse
+//	@Param			Authorization		header		string	true	"Insert your scim token"	default(Bearer <token>)
+//	@Param			filter				query		string	false	"scim filter"
+//	@Param			startIndex			query		int		false	"1-based index of the first result"
+//	@Param			count				query		int		false	"results per page, at most 1000"
+//	@Param			excludedAttributes	query		string	false	"attributes left out of the results"
+//	@Router			/scim/v2/Groups [get]
+func ListSCIMGroups(c *gin.Context) {
+	var scimListRequest models.SCIMListRequest
+	if err := c.ShouldBindQuery(&scimListRequest); err != nil {
+		scimBadRequest(c, err)
+		return
+	}
+	response, err := services.ListSCIMGroups(scimListRequest)
+	if err != nil {
+		scimError(c, err)
+		return
+	}
+	scimJSON(c, http.StatusOK, response)
+}
+
+// GetSCIMGroup godoc
+//
+//	@Summary		GetSCIMGroup
+//	@ID				GetSCIMGroup
+//	@Description	GetSCIMGroup
+//	@Tags			scim
+//	@Produce		json
+//	@Success		200				{object}	models.SCIMGroup
+//	@Failure		401				{object}	models.SCIMErrorResponse
+//	@Failure		404				{object}	models.SCIMErrorResponse
+//	@Param			Authorization	header		string	true	"Insert your scim token"	default(Bearer <token>)
+//	@Param			id				path		string	true	"group id"
+//	@Router			/scim/v2/Groups/{id} [get]
+func GetSCIMGroup(c *gin.Context) {
+	scimGroup, err := services.GetSCIMGroup(c.Param("id"))
+	if err != nil {
+		scimError(c, err)
+		return
+	}
+	scimJSON(c, http.StatusOK, scimGroup)
+}
+
+// CreateSCIMGroup godoc
+//
+//	@Summary		CreateSCIMGroup
+//	@ID				CreateSCIMGroup
+//	@Description	provision a group with its members, the members are user ids
+//	@Tags			scim
+//	@Accept			json
+//	@Produce		json
+//	@Success		201				{object}	models.SCIMGroup
+//	@Failure		400				{object}	models.SCIMErrorResponse
+//	@Failure		401				{object}	models.SCIMErrorResponse
+//	@Failure		409				{object}	models.SCIMErrorResponse
+//	@Param			Authorization	header		string				true	"Insert your scim token"	default(Bearer <token>)
+//	@Param			SCIMGroup		body		models.SCIMGroup	true	"group"
+//	@Router			/scim/v2/Groups [post]
+func CreateSCIMGroup(c *gin.Context) {
+	var scimGroup models.SCIMGroup
+	if err := c.ShouldBindJSON(&scimGroup); err != nil {
+		scimBadRequest(c, err)
+		return
+	}
+	scimGroup, err := services.CreateSCIMGroup(scimGroup)
+	if err != nil {
+		scimError(c, err)
+		return
+	}
+	c.Header("Location", scimGroup.Meta.Location)
+	scimJSON(c, http.StatusCreated, scimGroup)
+}
+
+// ReplaceSCIMGroup godoc
+//
+//	@Summary		ReplaceSCIMGroup
+//	@ID				ReplaceSCIMGroup
+//	@Description	ReplaceSCIMGroup
+//	@Tags			scim
+//	@Accept			json
+//	@Produce		json
+//	@Success		200				{object}	models.SCIMGroup
+//	@Failure		400				{object}	models.SCIMErrorResponse
+//	@Failure		401				{object}	models.SCIMErrorResponse
+//	@Failure		404				{object}	models.SCIMErrorResponse
+//	@Param			Authorization	header		string				true	"Insert your scim token"	default(Bearer <token>)
+//	@Param			id				path		string				true	"group id"
+//	@Param			SCIMGroup		body		models.SCIMGroup	true	"group"
+//	@Router			/scim/v2/Groups/{id} [put]
+func ReplaceSCIMGroup(c *gin.Context) {
+	var scimGroup models.SCIMGroup
+	if err := c.ShouldBindJSON(&scimGroup); err != nil {
+		scimBadRequest(c, err)
+		return
+	}
+	scimGroup, err := services.ReplaceSCIMGroup(c.Param("id"), scimGroup)
+	if err != nil {
+		scimError(c, err)
+		return
+	}
+	scimJSON(c, http.StatusOK, scimGroup)
+}
+
+// PatchSCIMGroup godoc
+//
+//	@Summary		PatchSCIMGroup
+//	@ID				PatchSCIMGroup
+//	@Description	PatchSCIMGroup
+//	@Tags			scim
+//	@Accept			json
+//	@Produce		json
+//	@Success		200					{object}	models.SCIMGroup
+//	@Failure		400					{object}	models.SCIMErrorResponse
+//	@Failure		401					{object}	models.SCIMErrorResponse
+//	@Failure		404					{object}	models.SCIMErrorResponse
+//	@Param			Authorization		header		string					true	"Insert your scim token"	default(Bearer <token>)
+//	@Param			id					path		string					true	"group id"
+//	@Param			SCIMPatchRequest	body		models.SCIMPatchRequest	true	"patch operations"
+//	@Router			/scim/v2/Groups/{id} [patch]
+func PatchSCIMGroup(c *gin.Context) {
+	var scimPatchRequest models.SCIMPatchRequest
+	if err := c.ShouldBindJSON(&scimPatchRequest); err != nil {
+		scimBadRequest(c, err)
+		return
+	}
+	scimGroup, err := services.PatchSCIMGroup(c.Param("id"), scimPatchRequest)
+	if err != nil {
+		scimError(c, err)
+		return
+	}
+	scimJSON(c, http.StatusOK, scimGroup)
+}
+
+// DeleteSCIMGroup godoc
+//
+//	@Summary		DeleteSCIMGroup
+//	@ID				DeleteSCIMGroup
+//	@Description	DeleteSCIMGroup
+//	@Tags			scim
+//	@Success		204				{object}	any
+//	@Failure		401				{object}	models.SCIMErrorResponse
+//	@Failure		404				{object}	models.SCIMErrorResponse
+//	@Param			Authorization	header		string	true	"Insert your scim token"	default(Bearer <token>)
+//	@Param			id				path		string	true	"group id"
+//	@Router			/scim/v2/Groups/{id} [delete]
+func DeleteSCIMGroup(c *gin.Context) {
+	err := services.DeleteSCIMGroup(c.Param("id"))
+	if err != nil {
+		scimError(c, err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
@@ -1,10 +1,16 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
-	_ "github.com/leetsecure/qryptic-controller/internal/models"
+	"github.com/leetsecure/qryptic-controller/internal/models"
+	"github.com/leetsecure/qryptic-controller/internal/utils/wireguard"
 
 	"github.com/leetsecure/qryptic-controller/internal/services"
 )
@@ -34,21 +40,62 @@ func GetVpnGatewaysAccessibleByUser(c *gin.Context) {
 // GetVpnClientConfig godoc
 //
 //	@Summary		GetVpnClientConfig
-//	@Description	GetVpnClientConfig
+//	@Description	Create a client on the gateway and return its config as json, a wg-quick config file or a png qr code.
+//	@Description	The format is taken from the format query parameter, or else negotiated from the Accept header.
+//	@Description	A client over the client limits of the gateway is rejected with 409, unless the gateway revokes the oldest clients of the user.
 //	@Tags			user
 //	@Accept			json
 //	@Produce		json
+//	@Produce		plain
+//	@Produce		png
 //	@Success		200				{object}	models.WGClientConfig
 //	@Failure		400				{object}	any
 //	@Failure		401				{object}	any
+//	@Failure		409				{object}	any
 //	@Failure		500				{object}	any
+//	@Failure		503				{object}	any
 //	@Param			Authorization	header		string	true	"Insert your token"	default(Bearer <token>)
 //	@Param			id				path		string	true	"gateway id"
+//	@Param			format			query		string	false	"json, conf or qr"
+//	@Param			device			query		string	false	"registered device id, the config then carries no private key"
+//	@Param			lifetime		query		int		false	"requested lifetime in minutes, capped by the gateway, group and user limits"
+//	@Param			presharedKey	query		bool	false	"issue a preshared key on a gateway where it is optional, always issued where it is required"
 //	@Router			/api/v1/gateway/{id}/client [get]
 func GetVpnClientConfig(c *gin.Context) {
 	userUuid, _ := c.Get("userUuid")
 	gatewayUuid := c.Param("id")
-	vpnClientConfig, status, err := services.CreateVpnGatewayUserClient(userUuid.(string), gatewayUuid)
+	format := clientConfigFormat(c)
+	if format == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unsupported format, expected json, conf or qr"})
+		return
+	}
+	var lifetime time.Duration
+	if lifetimeString := c.Query("lifetime"); lifetimeString != "" {
+		lifetimeMinutes, err := strconv.Atoi(lifetimeString)
+		if err != nil || lifetimeMinutes <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "lifetime should be a positive number of minutes"})
+			return
+		}
+		lifetime = time.Duration(lifetimeMinutes) * time.Minute
+	}
+	var withPresharedKey bool
+	if presharedKeyString := c.Query("presharedKey"); presharedKeyString != "" {
+		var err error
+		withPresharedKey, err = strconv.ParseBool(presharedKeyString)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "presharedKey should be true or false"})
+			return
+		}
+	}
+	vpnClientConfig, status, err := services.CreateVpnGatewayUserClient(userUuid.(string), gatewayUuid, c.Query("device"), lifetime, withPresharedKey)
+	if errors.Is(err, services.ErrVpnGatewayDown) {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrClientLimitReached) {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -57,7 +104,53 @@ func GetVpnClientConfig(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"success": false})
 		return
 	}
-	c.JSON(http.StatusOK, vpnClientConfig)
+
+	switch format {
+	case clientConfigFormatConf:
+		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", clientConfigFileName(vpnClientConfig)+".conf"))
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(services.RenderWGClientConfig(vpnClientConfig)))
+	case clientConfigFormatQR:
+		qrCode, err := services.RenderWGClientConfigQRCode(vpnClientConfig)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.Header("Content-Disposition", fmt.Sprintf("inline; filename=%q", clientConfigFileName(vpnClientConfig)+".png"))
+		c.Data(http.StatusOK, "image/png", qrCode)
+	default:
+		c.JSON(http.StatusOK, vpnClientConfig)
+	}
+}
+
+const (
+	clientConfigFormatJSON = "json"
+	clientConfigFormatConf = "conf"
+	clientConfigFormatQR   = "qr"
+)
+
+// clientConfigFormat returns the requested client config format, or "" when it is not supported.
+func clientConfigFormat(c *gin.Context) string {
+	format := c.Query("format")
+	if format == "" {
+		switch c.NegotiateFormat(gin.MIMEJSON, gin.MIMEPlain, "image/png") {
+		case gin.MIMEPlain:
+			return clientConfigFormatConf
+		case "image/png":
+			return clientConfigFormatQR
+		default:
+			return clientConfigFormatJSON
+		}
+	}
+	switch format {
+	case clientConfigFormatJSON, clientConfigFormatConf, clientConfigFormatQR:
+		return format
+	}
+	return ""
+}
+
+// clientConfigFileName is also the tunnel name in wg-quick, limited to 15 characters
+func clientConfigFileName(wgClientConfig models.WGClientConfig) string {
+	return "qryptic-" + strings.ReplaceAll(wgClientConfig.ClientUuid, "-", "")[:7]
 }
 
 // DeleteVpnClient godoc
@@ -86,3 +179,141 @@ func DeleteVpnClient(c *gin.Context) {
 	}
 	c.JSON(http.StatusOK, gin.H{"success": true})
 }
+
+// RenewVpnClient godoc
+//
+//	@Summary		RenewVpnClient
+//	@Description	Extend the expiry of a client within the lifetime limits, keeping its key and address.
+//	@Description	Requires a recent login.
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Success		200					{object}	models.ClientRenewResponse
+//	@Failure		400					{object}	any
+//	@Failure		401					{object}	any
+//	@Failure		500					{object}	any
+//	@Param			Authorization		header		string						true	"Insert your token"	default(Bearer <token>)
+//	@Param			id					path		string						true	"client id"
+//	@Param			ClientRenewRequest	body		models.ClientRenewRequest	false	"Requested lifetime"
+//	@Router			/api/v1/client/{id}/renew [post]
+func RenewVpnClient(c *gin.Context) {
+	userUuid, _ := c.Get("userUuid")
+	var clientRenewRequest models.ClientRenewRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&clientRenewRequest); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+	clientUuid := c.Param("id")
+	clientRenewResponse, err := services.RenewClient(userUuid.(string), clientUuid, time.Duration(clientRenewRequest.Lifetime)*time.Minute)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, clientRenewResponse)
+}
+
+// GetActiveSessions godoc
+//
+//	@Summary		GetActiveSessions
+//	@Description	List the clients of the logged in user with a recent handshake
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Success		200				{array}		models.ActiveSession
+//	@Failure		401				{object}	any
+//	@Failure		500				{object}	any
+//	@Param			Authorization	header		string	true	"Insert your token"	default(Bearer <token>)
+//	@Router			/api/v1/sessions [get]
+func GetActiveSessions(c *gin.Context) {
+	userUuid, _ := c.Get("userUuid")
+	activeSessions, err := services.ListActiveSessionsOfUser(userUuid.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, activeSessions)
+}
+
+// RegisterDevice godoc
+//
+//	@Summary		RegisterDevice
+//	@Description	Register a device with the public key of a wireguard key pair generated on it
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Success		201						{object}	models.Device
+//	@Failure		400						{object}	any
+//	@Failure		401						{object}	any
+//	@Failure		500						{object}	any
+//	@Param			Authorization			header		string							true	"Insert your token"	default(Bearer <token>)
+//	@Param			DeviceRegisterRequest	body		models.DeviceRegisterRequest	true	"Device details"
+//	@Router			/api/v1/device [post]
+func RegisterDevice(c *gin.Context) {
+	userUuid, _ := c.Get("userUuid")
+	var deviceRegisterRequest models.DeviceRegisterRequest
+	if err := c.ShouldBindJSON(&deviceRegisterRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := wireguard.ValidatePublicKey(deviceRegisterRequest.PublicKey); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	device, err := services.RegisterDevice(userUuid.(string), deviceRegisterRequest)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, device)
+}
+
+// ListDevices godoc
+//
+//	@Summary		ListDevices
+//	@Description	List the devices of the logged in user
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Success		200				{array}		models.Device
+//	@Failure		401				{object}	any
+//	@Failure		500				{object}	any
+//	@Param			Authorization	header		string	true	"Insert your token"	default(Bearer <token>)
+//	@Router			/api/v1/device/list [get]
+func ListDevices(c *gin.Context) {
+	userUuid, _ := c.Get("userUuid")
+	devices, err := services.ListDevicesOfUser(userUuid.(string))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, devices)
+}
+
+// RevokeDevice godoc
+//
+//	@Summary		RevokeDevice
+//	@Description	Revoke a device of the logged in user along with its active clients
+//	@Tags			user
+//	@Accept			json
+//	@Produce		json
+//	@Success		200				{object}	any
+//	@Failure		400				{object}	any
+//	@Failure		401				{object}	any
+//	@Failure		500				{object}	any
+//	@Param			Authorization	header		string	true	"Insert your token"	default(Bearer <token>)
+//
+//	@Param			id				path		string	true	"device id"
+//
+//	@Router			/api/v1/device/{id} [delete]
+func RevokeDevice(c *gin.Context) {
+	userUuid, _ := c.Get("userUuid")
+	deviceUuid := c.Param("id")
+	err := services.RevokeDevice(userUuid.(string), userUuid.(string), deviceUuid)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
@@ -4,7 +4,7 @@ import (
 	"net/http"
 
 	"github.com/gin-gonic/gin"
-	_ "github.com/leetsecure/qryptic-controller/internal/models"
+	"github.com/leetsecure/qryptic-controller/internal/models"
 	"github.com/leetsecure/qryptic-controller/internal/services"
 )
 
@@ -31,3 +31,61 @@ func GetVpnGatewayWGConfigByGW(c *gin.Context) {
 
 	c.JSON(http.StatusOK, vpnGatewayConfig)
 }
+
+// VpnGatewayHeartbeat godoc
+//
+//	@Summary		VpnGatewayHeartbeat
+//	@Description	Report the version, uptime, public ip and wireguard interface state of the gateway
+//	@Tags			gateway
+//	@Accept			json
+//	@Produce		json
+//	@Success		200							{object}	any
+//	@Failure		400							{object}	any
+//	@Failure		401							{object}	any
+//	@Failure		500							{object}	any
+//	@Param			Authorization				header		string								true	"Insert your token"	default(Bearer <token>)
+//	@Param			VpnGatewayHeartbeatRequest	body		models.VpnGatewayHeartbeatRequest	true	"Gateway state"
+//	@Router			/api/v1/gateway/heartbeat [post]
+func VpnGatewayHeartbeat(c *gin.Context) {
+	vpnGatewayUuid, _ := c.Get("vpnGatewayUuid")
+	var heartbeatRequest models.VpnGatewayHeartbeatRequest
+	if err := c.ShouldBindJSON(&heartbeatRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	err := services.RecordVpnGatewayHeartbeat(vpnGatewayUuid.(string), heartbeatRequest)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"success": true})
+}
+
+// VpnGatewayPeerStats godoc
+//
+//	@Summary		VpnGatewayPeerStats
+//	@Description	Report the latest handshake, traffic and endpoint of the peers of the gateway
+//	@Tags			gateway
+//	@Accept			json
+//	@Produce		json
+//	@Success		200							{object}	models.VpnGatewayPeerStatsResponse
+//	@Failure		400							{object}	any
+//	@Failure		401							{object}	any
+//	@Failure		500							{object}	any
+//	@Param			Authorization				header		string								true	"Insert your token"	default(Bearer <token>)
+//	@Param			VpnGatewayPeerStatsRequest	body		models.VpnGatewayPeerStatsRequest	true	"Peer stats"
+//	@Router			/api/v1/gateway/peer-stats [post]
+func VpnGatewayPeerStats(c *gin.Context) {
+	vpnGatewayUuid, _ := c.Get("vpnGatewayUuid")
+	var peerStatsRequest models.VpnGatewayPeerStatsRequest
+	if err := c.ShouldBindJSON(&peerStatsRequest); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	peerStatsResponse, err := services.RecordVpnGatewayPeerStats(vpnGatewayUuid.(string), peerStatsRequest)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, peerStatsResponse)
+}
@@ -2,8 +2,10 @@ package middlewares
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/leetsecure/qryptic-controller/internal/config"
 	"github.com/leetsecure/qryptic-controller/internal/database"
 	"github.com/leetsecure/qryptic-controller/internal/models"
 	"github.com/leetsecure/qryptic-controller/internal/utils/auth"
@@ -23,15 +25,35 @@ func ControllerAuthCheckMiddleware(c *gin.Context) {
 
 	token := authorisation[len(Bearer_Schema):]
 
-	userUuid, userRole, err := auth.VerifyUserAuthToken(token)
+	userUuid, userRole, issuedAt, err := auth.VerifyUserAuthToken(token)
 	log.Info(userUuid, userRole)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		c.Abort()
 		return
 	}
+	// tokens of users deactivated or deleted since the login are no longer accepted
+	var user models.User
+	if err := database.DB.Select("is_active").Where("uuid = ?", userUuid).First(&user).Error; err != nil || !user.IsActive {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorised"})
+		c.Abort()
+		return
+	}
 	c.Set("userRole", userRole)
 	c.Set("userUuid", userUuid)
+	c.Set("userAuthIssuedAt", issuedAt)
+	c.Next()
+}
+
+// FreshAuthCheckMiddleware guards sensitive actions behind a recent login, so that a leaked or
+// long lived token is not enough. It runs after ControllerAuthCheckMiddleware.
+func FreshAuthCheckMiddleware(c *gin.Context) {
+	issuedAt, exists := c.Get("userAuthIssuedAt")
+	if !exists || time.Since(issuedAt.(time.Time)) > config.FreshAuthMaxAge {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "recent login required, sign in again", "reauthenticate": true})
+		c.Abort()
+		return
+	}
 	c.Next()
 }
 
@@ -79,3 +101,40 @@ func VpnGatewayAuthCheckMiddleware(c *gin.Context) {
 	c.Set("vpnGatewayUuid", vpnGatewayUuid)
 	c.Next()
 }
+
+// SCIMAuthCheckMiddleware checks the bearer token of the scim client against the hash stored with the
+// admin configuration, the errors are scim error responses.
+func SCIMAuthCheckMiddleware(c *gin.Context) {
+	Bearer_Schema := "Bearer "
+	authorisation := c.GetHeader("Authorization")
+
+	if len(authorisation) <= len(Bearer_Schema)+1 {
+		scimUnauthorised(c, "unauthorised")
+		return
+	}
+	token := authorisation[len(Bearer_Schema):]
+
+	var adminConfiguration models.AdminConfiguration
+	err := database.DB.First(&adminConfiguration).Error
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		c.Abort()
+		return
+	}
+	err = auth.VerifySCIMToken(token, adminConfiguration.SCIMTokenHash)
+	if err != nil {
+		scimUnauthorised(c, err.Error())
+		return
+	}
+	c.Next()
+}
+
+func scimUnauthorised(c *gin.Context, detail string) {
+	c.Header("Content-Type", "application/scim+json")
+	c.JSON(http.StatusUnauthorized, models.SCIMErrorResponse{
+		Schemas: []string{models.SCIMSchemaError},
+		Status:  "401",
+		Detail:  detail,
+	})
+	c.Abort()
+}
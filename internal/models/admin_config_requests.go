@@ -6,6 +6,43 @@ type AddSsoConfigRequest struct {
 	Provider     string `json:"provider" binding:"required"`
 	ClientID     string `json:"clientID" binding:"required"`
 	ClientSecret string `json:"clientSecret" binding:"required"`
+	// IssuerURL is required for every provider but google
+	IssuerURL  string   `json:"issuerURL" binding:"omitempty,url"`
+	Scopes     []string `json:"scopes"`
+	EmailClaim string   `json:"emailClaim"`
+	GroupClaim string   `json:"groupClaim"` // claim holding the groups of the user, groups when empty
+	// TrustEmailClaim accepts emails without an email_verified claim, only for providers that don't let
+	// users set their own email
+	TrustEmailClaim bool `json:"trustEmailClaim"`
+}
+
+type AddSamlSsoConfigRequest struct {
+	Domain   string `json:"domain" binding:"required"`
+	Provider string `json:"provider" binding:"required"`
+	// IdpMetadata is the metadata xml downloaded from the identity provider
+	IdpMetadata string `json:"idpMetadata" binding:"required"`
+	// EmailAttribute and NameAttribute name the assertion attributes mapped to the user, the email falls
+	// back to the common email attributes and then to the name id
+	EmailAttribute string `json:"emailAttribute"`
+	NameAttribute  string `json:"nameAttribute"`
+	GroupAttribute string `json:"groupAttribute"` // the common group attributes are tried when empty
+}
+
+type SSOGroupMappingCreateRequest struct {
+	ClaimValue string `json:"claimValue" binding:"required"` // value of the group claim, e.g. eng-oncall
+	GroupUuid  string `json:"groupUuid" binding:"required"`
+}
+
+type UpdateSSOJITProvisioningRequest struct {
+	JITProvisioning *bool `json:"jitProvisioning" binding:"required"`
+	// DefaultRole is User when empty, provisioned users are promoted to admins one by one
+	DefaultRole UserRoleEnum `json:"defaultRole" binding:"omitempty,oneof=User Default"`
+	// DefaultGroupUuids replace the groups the provisioned users are added to
+	DefaultGroupUuids []string `json:"defaultGroupUuids"`
+}
+
+type UpdateSamlIdpMetadataRequest struct {
+	IdpMetadata string `json:"idpMetadata" binding:"required"`
 }
 
 type UpdateAllowPasswordLoginRequest struct {
@@ -1,10 +1,14 @@
 package models
 
+import "time"
+
 type RegisterUserRequest struct {
 	EmailId       string       `json:"email" binding:"required"`
 	Password      string       `json:"password"`
 	Role          UserRoleEnum `json:"role" binding:"required"`
 	IsPasswordSet *bool        `json:"isPasswordSet"`
+	// MaxClientLifetime in minutes caps the clients of the user, 0 for no cap
+	MaxClientLifetime int `json:"maxClientLifetime" binding:"omitempty,min=0"`
 }
 
 type UpdateUserRequest struct {
@@ -12,6 +16,8 @@ type UpdateUserRequest struct {
 	NewPassword   string       `json:"newPassword"`
 	Role          UserRoleEnum `json:"role"`
 	IsPasswordSet *bool        `json:"isPasswordSet"`
+	// MaxClientLifetime in minutes replaces the cap of the user when present, 0 removes it
+	MaxClientLifetime *int `json:"maxClientLifetime" binding:"omitempty,min=0"`
 }
 
 type VpnGatewayPeerConfig struct {
@@ -34,6 +40,8 @@ type VpnClientConfig struct {
 	VpnGatewayPublicKey  string
 	VpnGatewayEndpoint   string
 	VpnGatewayAllowedIPs string
+	PresharedKey         string
+	PersistentKeepalive  int
 }
 
 type VpnGatewayUpdateUserRequest struct {
@@ -45,8 +53,24 @@ type VpnGatewayCreateRequest struct {
 	Domain    string `json:"domain"  binding:"required"`
 	IpAddress string `json:"ipAddress"  binding:"required"`
 	VpnCIDR   string `json:"vpnCIDR"  `
+	VpnCIDRv6 string `json:"vpnCIDRv6"`
 	Port      int    `json:"port"  binding:"required"`
 	DnsServer string `json:"dnsServer"  binding:"required"`
+	// ServerPrivateKey is optional, a key pair is generated when it is empty
+	ServerPrivateKey string `json:"serverPrivateKey"`
+	// PresharedKeyPolicy defaults to Required
+	PresharedKeyPolicy PresharedKeyPolicyEnum `json:"presharedKeyPolicy" binding:"omitempty,oneof=Required Optional"`
+	// Routes are the cidrs advertised to clients, a full tunnel when empty
+	Routes []string `json:"routes"`
+	// ReservedRanges are cidrs of the vpn cidr never handed out to clients
+	ReservedRanges []string `json:"reservedRanges"`
+	// MaxClientLifetime in minutes caps the clients of the gateway, 0 for no cap
+	MaxClientLifetime int `json:"maxClientLifetime" binding:"min=0"`
+	// MaxClients and MaxClientsPerUser cap the active clients, 0 for no cap
+	MaxClients        int `json:"maxClients" binding:"min=0"`
+	MaxClientsPerUser int `json:"maxClientsPerUser" binding:"min=0"`
+	// ClientLimitPolicy defaults to Reject
+	ClientLimitPolicy ClientLimitPolicyEnum `json:"clientLimitPolicy" binding:"omitempty,oneof=Reject RevokeOldest"`
 }
 
 type VpnGatewayUpdateRequest struct {
@@ -55,14 +79,36 @@ type VpnGatewayUpdateRequest struct {
 	IpAddress string `json:"ipAddress"`
 	Port      int    `json:"port"`
 	DnsServer string `json:"dnsServer"`
+	// ServerPrivateKey rotates the key of the gateway, the configs of existing clients have to be fetched again
+	ServerPrivateKey   string                 `json:"serverPrivateKey"`
+	PresharedKeyPolicy PresharedKeyPolicyEnum `json:"presharedKeyPolicy" binding:"omitempty,oneof=Required Optional"`
+	// VpnCIDRv6 can only be set on a gateway that is not dual-stack yet, existing clients stay ipv4 only
+	VpnCIDRv6 string `json:"vpnCIDRv6"`
+	// Routes replace the advertised routes when present, an empty list makes the gateway a full tunnel
+	Routes []string `json:"routes"`
+	// ReservedRanges replace the reserved ranges when present, allocated addresses stay with their clients
+	ReservedRanges []string `json:"reservedRanges"`
+	// MaxClientLifetime replaces the cap when present, existing clients keep their expiry
+	MaxClientLifetime *int `json:"maxClientLifetime" binding:"omitempty,min=0"`
+	// MaxClients and MaxClientsPerUser replace the caps when present, existing clients are kept
+	MaxClients        *int                  `json:"maxClients" binding:"omitempty,min=0"`
+	MaxClientsPerUser *int                  `json:"maxClientsPerUser" binding:"omitempty,min=0"`
+	ClientLimitPolicy ClientLimitPolicyEnum `json:"clientLimitPolicy" binding:"omitempty,oneof=Reject RevokeOldest"`
 }
 
 type GroupCreateRequest struct {
-	Name string `json:"name"  binding:"required"`
+	Name   string   `json:"name"  binding:"required"`
+	Routes []string `json:"routes"`
+	// MaxClientLifetime in minutes caps the clients on the gateways granted through the group
+	MaxClientLifetime int `json:"maxClientLifetime" binding:"min=0"`
 }
 
 type GroupUpdateRequest struct {
 	Name string `json:"name" binding:"required" `
+	// Routes replace the routes of the group when present, an empty list removes the narrowing
+	Routes []string `json:"routes"`
+	// MaxClientLifetime replaces the cap when present, 0 removes it
+	MaxClientLifetime *int `json:"maxClientLifetime" binding:"omitempty,min=0"`
 }
 
 type GroupUpdateUserRequest struct {
@@ -72,3 +118,55 @@ type GroupUpdateUserRequest struct {
 type GatewayUpdateGroupRequest struct {
 	GroupUuids []string `json:"groupUuids" binding:"required"`
 }
+
+type AuditTrailListRequest struct {
+	Page        int       `form:"page"`
+	PageSize    int       `form:"pageSize"`
+	Action      string    `form:"action"`
+	ActorUuid   string    `form:"actorUuid"`
+	UserUuid    string    `form:"userUuid"`
+	GroupUuid   string    `form:"groupUuid"`
+	GatewayUuid string    `form:"gatewayUuid"`
+	ClientUuid  string    `form:"clientUuid"`
+	From        time.Time `form:"from" time_format:"2006-01-02T15:04:05Z07:00"`
+	To          time.Time `form:"to" time_format:"2006-01-02T15:04:05Z07:00"`
+}
+
+type AuditTrailListResponse struct {
+	AuditTrails []AuditTrail `json:"auditTrails"`
+	Total       int64        `json:"total"`
+	Page        int          `json:"page"`
+	PageSize    int          `json:"pageSize"`
+}
+
+type AccessPolicyCreateRequest struct {
+	Name           string                   `json:"name" binding:"required"`
+	VpnGatewayUuid string                   `json:"vpnGatewayUuid" binding:"required"`
+	UserUuid       string                   `json:"userUuid" binding:"required_without=GroupUuid,excluded_with=GroupUuid"`
+	GroupUuid      string                   `json:"groupUuid" binding:"required_without=UserUuid,excluded_with=UserUuid"`
+	Action         AccessPolicyActionEnum   `json:"action" binding:"required,oneof=Allow Deny"`
+	Destination    string                   `json:"destination" binding:"required,cidr"`
+	Protocol       AccessPolicyProtocolEnum `json:"protocol" binding:"omitempty,oneof=any tcp udp icmp"`
+	PortFrom       int                      `json:"portFrom" binding:"min=0,max=65535"`
+	PortTo         int                      `json:"portTo" binding:"min=0,max=65535"`
+	Priority       int                      `json:"priority"`
+}
+
+type AccessPolicyUpdateRequest struct {
+	Name        string                   `json:"name"`
+	Action      AccessPolicyActionEnum   `json:"action" binding:"omitempty,oneof=Allow Deny"`
+	Destination string                   `json:"destination" binding:"omitempty,cidr"`
+	Protocol    AccessPolicyProtocolEnum `json:"protocol" binding:"omitempty,oneof=any tcp udp icmp"`
+	PortFrom    *int                     `json:"portFrom" binding:"omitempty,min=0,max=65535"`
+	PortTo      *int                     `json:"portTo" binding:"omitempty,min=0,max=65535"`
+	Priority    *int                     `json:"priority"`
+}
+
+// IPReservationCreateRequest reserves the ip for the user, or for a device of the user when
+// DeviceUuid is set
+type IPReservationCreateRequest struct {
+	UserUuid    string `json:"userUuid" binding:"required_without=DeviceUuid"`
+	DeviceUuid  string `json:"deviceUuid"`
+	IP          string `json:"ip" binding:"required,ipv4"`
+	Description string `json:"description"`
+}
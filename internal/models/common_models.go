@@ -1 +1,52 @@
 package models
+
+import "time"
+
+// ActiveSession is a client whose tunnel completed a handshake recently
+type ActiveSession struct {
+	ClientUuid      string    `json:"clientUuid"`
+	UserUuid        string    `json:"userUuid"`
+	UserName        string    `json:"userName"`
+	UserEmail       string    `json:"userEmail"`
+	VpnGatewayUuid  string    `json:"vpnGatewayUuid"`
+	VpnGatewayName  string    `json:"vpnGatewayName"`
+	AllocatedIP     string    `json:"allocatedIP"`
+	Endpoint        string    `json:"endpoint"`
+	LastHandshakeAt time.Time `json:"lastHandshakeAt"`
+	RxBytes         int64     `json:"rxBytes"`
+	TxBytes         int64     `json:"txBytes"`
+}
+
+// IPUtilizationReport counts the client addresses of the vpn cidr of a gateway
+type IPUtilizationReport struct {
+	VpnGatewayUuid     string   `json:"vpnGatewayUuid"`
+	VpnCIDR            string   `json:"vpnCIDR"`
+	ReservedRanges     []string `json:"reservedRanges"`
+	Total              int64    `json:"total"`
+	Reserved           int64    `json:"reserved"`           // addresses in reserved ranges
+	StaticReservations int64    `json:"staticReservations"` // reservations outside reserved ranges, in use or not
+	Allocated          int64    `json:"allocated"`
+	Available          int64    `json:"available"`
+	UtilizationPercent float64  `json:"utilizationPercent"`
+}
+
+// IPAllocationRepairReport counts the allocations fixed by a repair of a gateway
+type IPAllocationRepairReport struct {
+	VpnGatewayUuid string `json:"vpnGatewayUuid"`
+	Linked         int64  `json:"linked"`    // allocations matched to the active client holding the address
+	Reclaimed      int64  `json:"reclaimed"` // allocations of inactive or deleted clients released
+	Restored       int64  `json:"restored"`  // allocations recorded for active clients missing one
+}
+
+// SSOIdentity is the user authenticated by an sso provider
+type SSOIdentity struct {
+	Email       string
+	Name        string
+	Groups      []string // values of the group claim
+	SSOConfigID uint     // sso config the user authenticated through
+	// GroupsClaimed is set when the provider sent the group claim, the groups are only synced then
+	GroupsClaimed bool
+	HostedDomain  string // google workspace domain managing the account, from the hd claim
+	// EmailVerified is set when the provider vouches for the email, only verified users are provisioned
+	EmailVerified bool
+}
@@ -17,21 +17,63 @@ type AdminConfiguration struct {
 	GatewayJWTAlgorithm     string       `json:"-"`
 	TempUserCreated         bool         `json:"-"`
 	TempUserActive          bool         `json:"tempUserActive"`
+	SCIMTokenHash           string       `json:"-"` // sha256 of the bearer token of the scim client, scim is off when empty
 	SSOConfigs              []*SSOConfig `json:"ssoConfigs" gorm:"foreignKey:AdminConfigurationID"`
 }
 
 type SSOConfig struct {
 	gorm.Model
-	UUID                 string `json:"uuid" gorm:"uniqueIndex"`
-	Enabled              string `json:"enabled" gorm:"default:true"`
-	Domain               string `json:"domain"`   // Email domain for SSO
-	Provider             string `json:"provider"` // SSO provider (e.g., "google", "microsoft")
-	Platform             string `json:"platform"`
-	ClientID             string `json:"clientID"`
-	ClientSecret         string `json:"clientSecret"`
-	AdminConfigurationID uint   `json:"adminConfigurationID"` // Foreign key to VpnGateway
+	UUID                 string          `json:"uuid" gorm:"uniqueIndex"`
+	Enabled              string          `json:"enabled" gorm:"default:true"`
+	Domain               string          `json:"domain"`   // Email domain for SSO
+	Provider             string          `json:"provider"` // SSO provider (e.g., "google", "microsoft")
+	Platform             string          `json:"platform"`
+	ClientID             string          `json:"clientID"`
+	ClientSecret         string          `json:"clientSecret"`
+	AdminConfigurationID uint            `json:"adminConfigurationID"` // Foreign key to VpnGateway
+	Protocol             SSOProtocolEnum `json:"protocol" gorm:"default:OIDC"`
+	// IssuerURL is the OIDC issuer whose discovery document gives the endpoints and keys of the provider
+	IssuerURL  string   `json:"issuerURL"`
+	Scopes     []string `json:"scopes" gorm:"serializer:json"` // openid, profile and email when empty
+	EmailClaim string   `json:"emailClaim"`                    // claim or saml attribute holding the email of the user
+	NameClaim  string   `json:"nameClaim"`                     // saml attribute holding the name of the user
+	GroupClaim string   `json:"groupClaim"`                    // claim or saml attribute holding the groups of the user
+	// TrustEmailClaim treats an email without an email_verified claim as verified, for providers that
+	// only issue addresses they own and leave the claim out. Users can't sign in with unverified emails.
+	TrustEmailClaim bool `json:"trustEmailClaim"`
+	// SAMLIdPMetadata is the metadata xml of the identity provider, the service provider key pair is
+	// generated with the config
+	SAMLIdPMetadata   string `json:"samlIdpMetadata"`
+	SAMLSPCertificate string `json:"samlSpCertificate"`
+	SAMLSPPrivateKey  string `json:"-"`
+	// GroupMappings sync the members of the mapped groups on every login through the config
+	GroupMappings []*SSOGroupMapping `json:"groupMappings,omitempty" gorm:"foreignKey:SSOConfigID"`
+	// JITProvisioning creates the users of the domain signing in through the config for the first time
+	// with the default role and groups, the email has to be verified by the provider
+	JITProvisioning  bool         `json:"jitProvisioning"`
+	JITDefaultRole   UserRoleEnum `json:"jitDefaultRole"` // User when empty, never Admin
+	JITDefaultGroups []*Group     `json:"jitDefaultGroups,omitempty" gorm:"many2many:sso_config_default_groups;"`
 }
 
+// SSOGroupMapping makes the users whose group claim holds the claim value members of the group, and
+// removes the others from it when they sign in through the sso config.
+type SSOGroupMapping struct {
+	ID          uint      `json:"id" gorm:"primarykey"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UUID        string    `json:"uuid" gorm:"uniqueIndex"`
+	SSOConfigID uint      `json:"ssoConfigId" gorm:"uniqueIndex:idx_sso_group_mappings_config_value_group"`
+	ClaimValue  string    `json:"claimValue" gorm:"uniqueIndex:idx_sso_group_mappings_config_value_group"`
+	GroupID     uint      `json:"groupId" gorm:"uniqueIndex:idx_sso_group_mappings_config_value_group"`
+	Group       *Group    `json:"group" gorm:"foreignKey:GroupID"`
+}
+
+type SSOProtocolEnum string
+
+const (
+	SSOProtocolOIDC SSOProtocolEnum = "OIDC"
+	SSOProtocolSAML SSOProtocolEnum = "SAML"
+)
+
 // User DB model
 type User struct {
 	gorm.Model
@@ -44,27 +86,91 @@ type User struct {
 	Clients       []*Client     `json:"clients"`
 	VpnGateways   []*VpnGateway `json:"vpnGateways" gorm:"many2many:user_vpngateways;"`
 	Groups        []*Group      `json:"groups" gorm:"many2many:group_users;"`
+	// MaxClientLifetime in minutes caps the clients of the user on every gateway, 0 for no cap
+	MaxClientLifetime int `json:"maxClientLifetime"`
+	// IsActive is cleared when the user is deprovisioned, inactive users cannot sign in
+	IsActive   bool   `json:"isActive" gorm:"default:true"`
+	ExternalID string `json:"externalId" gorm:"index"` // id of the user in the provisioning client
+	// SCIMProvisioned marks the users created through scim, the only ones scim can see and change
+	SCIMProvisioned bool `json:"scimProvisioned"`
 }
 
 // VPN Gateway DB model
 type VpnGateway struct {
 	gorm.Model
-	UUID             string    `json:"uuid" gorm:"uniqueIndex"`
-	Name             string    `json:"name" `
-	JwtSecretKey     string    `json:"jwtSecretKey"`
-	JwtAlgorithm     string    `json:"jwtAlgorithm"`
-	ServerPublicKey  string    `json:"serverPublicKey"`
-	ServerPrivateKey string    `json:"serverPrivateKey"`
-	Domain           string    `json:"domain"`
-	IpAddress        string    `json:"ipAddressCIDR"`
-	VpnCIDR          string    `json:"vpnCIDR"`
-	Port             int       `json:"port"`
-	DnsServer        string    `json:"dnsServer"`
-	Clients          []*Client `json:"clients"`
-	Users            []*User   `json:"users" gorm:"many2many:user_vpngateways;"`
-	Groups           []*Group  `json:"groups" gorm:"many2many:group_vpngateways;"`
-	// IPAllocations    []*IPAllocation `json:"ipAllocations"`
-	IPPool []IPPool `json:"ipPool"`
+	UUID                      string                  `json:"uuid" gorm:"uniqueIndex"`
+	Name                      string                  `json:"name" `
+	JwtSecretKey              string                  `json:"jwtSecretKey"`
+	JwtAlgorithm              string                  `json:"jwtAlgorithm"`
+	ServerPublicKey           string                  `json:"serverPublicKey"`
+	ServerPrivateKey          string                  `json:"serverPrivateKey"`
+	Domain                    string                  `json:"domain"`
+	IpAddress                 string                  `json:"ipAddressCIDR"`
+	VpnCIDR                   string                  `json:"vpnCIDR"`
+	VpnCIDRv6                 string                  `json:"vpnCIDRv6"` // optional, makes the gateway dual-stack
+	Port                      int                     `json:"port"`
+	DnsServer                 string                  `json:"dnsServer"`
+	Clients                   []*Client               `json:"clients"`
+	Users                     []*User                 `json:"users" gorm:"many2many:user_vpngateways;"`
+	Groups                    []*Group                `json:"groups" gorm:"many2many:group_vpngateways;"`
+	IPAllocations             []IPAllocation          `json:"ipAllocations"`
+	ReservedRanges            []string                `json:"reservedRanges" gorm:"serializer:json"` // cidrs of the vpn cidr never handed out to clients
+	MaxClientLifetime         int                     `json:"maxClientLifetime"`                     // minutes, 0 for no cap
+	MaxClients                int                     `json:"maxClients"`                            // active clients on the gateway, 0 for no cap
+	MaxClientsPerUser         int                     `json:"maxClientsPerUser"`                     // active clients of a user on the gateway, 0 for no cap
+	ClientLimitPolicy         ClientLimitPolicyEnum   `json:"clientLimitPolicy" gorm:"default:Reject"`
+	PresharedKeyPolicy        PresharedKeyPolicyEnum  `json:"presharedKeyPolicy" gorm:"default:Required"`
+	Routes                    []string                `json:"routes" gorm:"serializer:json"` // cidrs advertised to clients, a full tunnel when empty
+	HealthStatus              GatewayHealthStatusEnum `json:"healthStatus" gorm:"default:Unknown"`
+	HealthLatencyMs           int64                   `json:"healthLatencyMs"`
+	ConsecutiveHealthFailures int                     `json:"consecutiveHealthFailures"`
+	LastHealthCheckAt         *time.Time              `json:"lastHealthCheckAt"`
+	LastSeenAt                *time.Time              `json:"lastSeenAt"`
+	HealthChecks              []VpnGatewayHealthCheck `json:"healthChecks"`
+	// reported by the gateway in its heartbeat
+	GatewayVersion       string     `json:"gatewayVersion"`
+	UptimeSeconds        int64      `json:"uptimeSeconds"`
+	ReportedPublicIP     string     `json:"reportedPublicIP"`
+	WGInterfaceName      string     `json:"wgInterfaceName"`
+	WGInterfaceUp        bool       `json:"wgInterfaceUp"`
+	WGInterfacePeerCount int        `json:"wgInterfacePeerCount"`
+	LastHeartbeatAt      *time.Time `json:"lastHeartbeatAt"`
+}
+
+// PresharedKeyPolicyEnum decides whether peers without a preshared key are accepted on the gateway.
+// Clients always get a preshared key when it is required, and only when they ask for one when optional.
+type PresharedKeyPolicyEnum string
+
+const (
+	PresharedKeyRequired PresharedKeyPolicyEnum = "Required"
+	PresharedKeyOptional PresharedKeyPolicyEnum = "Optional"
+)
+
+// ClientLimitPolicyEnum decides what happens to a client request over the client caps of the gateway
+type ClientLimitPolicyEnum string
+
+const (
+	ClientLimitReject       ClientLimitPolicyEnum = "Reject"
+	ClientLimitRevokeOldest ClientLimitPolicyEnum = "RevokeOldest" // revokes the oldest clients of the same user only
+)
+
+type GatewayHealthStatusEnum string
+
+const (
+	GatewayHealthUnknown GatewayHealthStatusEnum = "Unknown"
+	GatewayHealthUp      GatewayHealthStatusEnum = "Up"
+	GatewayHealthDown    GatewayHealthStatusEnum = "Down"
+)
+
+// VpnGatewayHealthCheck is the result of a single probe of the gateway health url
+type VpnGatewayHealthCheck struct {
+	ID           uint                    `json:"-" gorm:"primarykey"`
+	VpnGatewayID uint                    `json:"-" gorm:"index"`
+	Healthy      bool                    `json:"healthy"`
+	Status       GatewayHealthStatusEnum `json:"status"` // gateway status after the probe
+	LatencyMs    int64                   `json:"latencyMs"`
+	Error        string                  `json:"error"`
+	CheckedAt    time.Time               `json:"checkedAt" gorm:"index"`
 }
 
 type UserRoleEnum string
@@ -87,41 +193,211 @@ type Client struct {
 	UUID             string      `json:"uuid" gorm:"uniqueIndex"`
 	UserID           uint        `json:"userId" gorm:"index"`
 	User             *User       `json:"user" gorm:"foreignKey:UserID"`
-	VpnGatewayID     uint        `json:"vpnGatewayId" gorm:"index"`
+	VpnGatewayID     uint        `json:"vpnGatewayId" gorm:"index;index:idx_clients_gateway_ipv6,unique,priority:1"`
 	VpnGateway       *VpnGateway `json:"vpnGateway" gorm:"foreignKey:VpnGatewayID"`
+	DeviceID         *uint       `json:"deviceId" gorm:"index"` // set when issued against a registered device
+	Device           *Device     `json:"device,omitempty" gorm:"foreignKey:DeviceID"`
 	ClientPublicKey  string      `json:"clientPublicKey"`
-	ClientPrivateKey string      `json:"clientPrivateKey"`
+	ClientPrivateKey string      `json:"-" gorm:"-"` // only returned to the user at creation, never stored, and empty for clients of devices
 	PresharedKey     string      `json:"preshared_key"`
 	ExpiryTime       time.Time   `json:"expiryTime"`
 	IsActive         bool        `json:"is_active"`
 	AllocatedIP      string      `json:"allocatedIP"`
+	AllocatedIPv6    string      `json:"allocatedIPv6" gorm:"index:idx_clients_gateway_ipv6,unique,where:is_active AND allocated_ipv6 <> ''"`
 	AllowedIPs       string      `json:"allowedIPs"`
 	DnsServer        string      `json:"dnsServer"`
+	// reported by the gateway in its peer stats
+	LastHandshakeAt *time.Time `json:"lastHandshakeAt"`
+	LastUsedAt      *time.Time `json:"lastUsedAt"` // last time the handshake or the traffic counters moved
+	RxBytes         int64      `json:"rxBytes"`
+	TxBytes         int64      `json:"txBytes"`
+	Endpoint        string     `json:"endpoint"`
 
-	// IPAllocated      *IPAllocation `json:"ipAllocated" gorm:"foreignKey:ClientID"`
+	IPAllocated *IPAllocation `json:"ipAllocated,omitempty" gorm:"foreignKey:ClientID"`
 }
 
+type AuditActorTypeEnum string
+
+const (
+	AuditActorUser    AuditActorTypeEnum = "User"
+	AuditActorGateway AuditActorTypeEnum = "Gateway"
+	AuditActorSystem  AuditActorTypeEnum = "System"
+	AuditActorSCIM    AuditActorTypeEnum = "SCIM"
+)
+
+type AuditActionEnum string
+
+const (
+	AuditActionLogin                     AuditActionEnum = "auth.login"
+	AuditActionSSOLogin                  AuditActionEnum = "auth.sso-login"
+	AuditActionUserCreate                AuditActionEnum = "user.create"
+	AuditActionUserJITCreate             AuditActionEnum = "user.jit-create"
+	AuditActionUserUpdate                AuditActionEnum = "user.update"
+	AuditActionUserDelete                AuditActionEnum = "user.delete"
+	AuditActionGroupCreate               AuditActionEnum = "group.create"
+	AuditActionGroupUpdate               AuditActionEnum = "group.update"
+	AuditActionGroupDelete               AuditActionEnum = "group.delete"
+	AuditActionGroupAddUser              AuditActionEnum = "group.add-user"
+	AuditActionGroupRemoveUser           AuditActionEnum = "group.remove-user"
+	AuditActionGatewayCreate             AuditActionEnum = "gateway.create"
+	AuditActionGatewayUpdate             AuditActionEnum = "gateway.update"
+	AuditActionGatewayDelete             AuditActionEnum = "gateway.delete"
+	AuditActionGatewayReset              AuditActionEnum = "gateway.reset"
+	AuditActionAccessGrantUser           AuditActionEnum = "access.grant-user"
+	AuditActionAccessRevokeUser          AuditActionEnum = "access.revoke-user"
+	AuditActionAccessGrantGroup          AuditActionEnum = "access.grant-group"
+	AuditActionAccessRevokeGroup         AuditActionEnum = "access.revoke-group"
+	AuditActionClientCreate              AuditActionEnum = "client.create"
+	AuditActionClientDelete              AuditActionEnum = "client.delete"
+	AuditActionClientExpire              AuditActionEnum = "client.expire"
+	AuditActionClientRenew               AuditActionEnum = "client.renew"
+	AuditActionClientLimitReject         AuditActionEnum = "client.limit-reject"
+	AuditActionClientLimitRevoke         AuditActionEnum = "client.limit-revoke"
+	AuditActionSSOConfigAdd              AuditActionEnum = "config.sso-add"
+	AuditActionSSOConfigDelete           AuditActionEnum = "config.sso-delete"
+	AuditActionSAMLMetadataUpdate        AuditActionEnum = "config.saml-metadata-update"
+	AuditActionSSOGroupMappingAdd        AuditActionEnum = "config.sso-group-mapping-add"
+	AuditActionSSOGroupMappingDelete     AuditActionEnum = "config.sso-group-mapping-delete"
+	AuditActionSSOJITConfigUpdate        AuditActionEnum = "config.sso-jit-update"
+	AuditActionSCIMTokenRotate           AuditActionEnum = "config.scim-token-rotate"
+	AuditActionSCIMTokenRevoke           AuditActionEnum = "config.scim-token-revoke"
+	AuditActionUserDeactivate            AuditActionEnum = "user.deactivate"
+	AuditActionUserActivate              AuditActionEnum = "user.activate"
+	AuditActionPasswordLoginConfigUpdate AuditActionEnum = "config.password-login-update"
+	AuditActionSSOLoginConfigUpdate      AuditActionEnum = "config.sso-login-update"
+	AuditActionGatewayOperationRetry     AuditActionEnum = "gateway.operation-retry"
+	AuditActionGatewayOperationDismiss   AuditActionEnum = "gateway.operation-dismiss"
+	AuditActionGatewayReconcile          AuditActionEnum = "gateway.reconcile"
+	AuditActionGatewayRegister           AuditActionEnum = "gateway.register"
+	AuditActionGatewayVersionChange      AuditActionEnum = "gateway.version-change"
+	AuditActionGatewayKeyRotate          AuditActionEnum = "gateway.key-rotate"
+	AuditActionPolicyCreate              AuditActionEnum = "policy.create"
+	AuditActionPolicyUpdate              AuditActionEnum = "policy.update"
+	AuditActionPolicyDelete              AuditActionEnum = "policy.delete"
+	AuditActionReservationCreate         AuditActionEnum = "reservation.create"
+	AuditActionReservationDelete         AuditActionEnum = "reservation.delete"
+	AuditActionIPAllocationRepair        AuditActionEnum = "gateway.ip-allocation-repair"
+	AuditActionDeviceRegister            AuditActionEnum = "device.register"
+	AuditActionDeviceRevoke              AuditActionEnum = "device.revoke"
+)
+
+// AuditTrail records who performed an action and which user, group, gateway or client it affected
 type AuditTrail struct {
 	gorm.Model
-	UUID         string      `json:"uuid" gorm:"uniqueIndex"`
-	UserID       *uint       `json:"userId" gorm:"index"`
-	User         *User       `json:"user" gorm:"foreignKey:UserID"`
-	Action       string      `json:"action"`
-	Description  string      `json:"description"`
-	Timestamp    time.Time   `json:"timestamp"`
-	VpnGatewayID *uint       `json:"vpnGatewayId"`
-	VpnGateway   *VpnGateway `json:"vpnGateway" gorm:"foreignKey:VpnGatewayID"`
-	ClientID     *uint       `json:"clientId" gorm:"index"`
-	Client       *Client     `json:"client" gorm:"foreignKey:ClientID"`
-}
-
-type IPPool struct {
+	UUID         string             `json:"uuid" gorm:"uniqueIndex"`
+	ActorType    AuditActorTypeEnum `json:"actorType" gorm:"index"`
+	ActorUUID    string             `json:"actorUuid" gorm:"index"` // uuid of the user or gateway performing the action, empty for system
+	UserID       *uint              `json:"userId" gorm:"index"`    // user affected by the action
+	User         *User              `json:"user" gorm:"foreignKey:UserID"`
+	Action       AuditActionEnum    `json:"action" gorm:"index"`
+	Description  string             `json:"description"`
+	Timestamp    time.Time          `json:"timestamp" gorm:"index"`
+	VpnGatewayID *uint              `json:"vpnGatewayId" gorm:"index"`
+	VpnGateway   *VpnGateway        `json:"vpnGateway" gorm:"foreignKey:VpnGatewayID"`
+	ClientID     *uint              `json:"clientId" gorm:"index"`
+	Client       *Client            `json:"client" gorm:"foreignKey:ClientID"`
+	GroupID      *uint              `json:"groupId" gorm:"index"`
+	Group        *Group             `json:"group" gorm:"foreignKey:GroupID"`
+}
+
+type GatewayOperationTypeEnum string
+
+const (
+	GatewayOperationAddPeers    GatewayOperationTypeEnum = "add-peers"
+	GatewayOperationDeletePeers GatewayOperationTypeEnum = "delete-peers"
+	GatewayOperationRestart     GatewayOperationTypeEnum = "restart"
+	GatewayOperationUpdatePeers GatewayOperationTypeEnum = "update-peers"
+)
+
+type GatewayOperationStatusEnum string
+
+const (
+	GatewayOperationPending   GatewayOperationStatusEnum = "Pending"
+	GatewayOperationSucceeded GatewayOperationStatusEnum = "Succeeded"
+	GatewayOperationFailed    GatewayOperationStatusEnum = "Failed"
+	GatewayOperationDismissed GatewayOperationStatusEnum = "Dismissed"
+)
+
+// GatewayOperation is an outbox entry for a request which has to be delivered to a vpn gateway.
+// Operations of a gateway are delivered in the order they were created, a failed operation holds back
+// the later ones until it is retried or dismissed.
+type GatewayOperation struct {
+	gorm.Model
+	UUID          string                     `json:"uuid" gorm:"uniqueIndex"`
+	VpnGatewayID  uint                       `json:"vpnGatewayId" gorm:"index"`
+	VpnGateway    *VpnGateway                `json:"-" gorm:"foreignKey:VpnGatewayID"`
+	Operation     GatewayOperationTypeEnum   `json:"operation"`
+	Peers         []WGServerPeerConfig       `json:"peers" gorm:"serializer:json"`
+	Status        GatewayOperationStatusEnum `json:"status" gorm:"index"`
+	Attempts      int                        `json:"attempts"`
+	NextAttemptAt time.Time                  `json:"nextAttemptAt"`
+	LastError     string                     `json:"lastError"`
+	CompletedAt   *time.Time                 `json:"completedAt"`
+}
+
+type DriftStatusEnum string
+
+const (
+	DriftStatusInSync      DriftStatusEnum = "InSync"
+	DriftStatusDrifted     DriftStatusEnum = "Drifted"
+	DriftStatusUnreachable DriftStatusEnum = "Unreachable"
+)
+
+// VpnGatewayDriftReport is the result of the latest comparison of the peers held by a gateway with the active clients
+type VpnGatewayDriftReport struct {
 	gorm.Model
-	UUID         string `json:"uuid" gorm:"uniqueIndex"`
-	IP           string `json:"ip" `
-	Assigned     bool   `json:"assigned" gorm:"index"`
-	VpnGatewayID uint   `json:"vpngatewayID" gorm:"index"` // Foreign key to VpnGateway
+	UUID              string               `json:"uuid" gorm:"uniqueIndex"`
+	VpnGatewayID      uint                 `json:"vpnGatewayId" gorm:"index"`
+	Status            DriftStatusEnum      `json:"status"`
+	CheckedAt         time.Time            `json:"checkedAt"`
+	MissingPeers      []WGServerPeerConfig `json:"missingPeers" gorm:"serializer:json"`  // active clients not present on the gateway
+	OutdatedPeers     []WGServerPeerConfig `json:"outdatedPeers" gorm:"serializer:json"` // active clients held by the gateway with another configuration
+	StalePeers        []WGServerPeerConfig `json:"stalePeers" gorm:"serializer:json"`    // peers on the gateway without an active client
+	PendingOperations int64                `json:"pendingOperations"`                    // drift is not corrected while operations are pending or failed
+	Corrected         bool                 `json:"corrected"`
+	Error             string               `json:"error"`
+}
 
+// Device is a machine of a user holding its own wireguard key pair, only the public key is known
+// to the controller. Clients issued against a device share its key.
+type Device struct {
+	gorm.Model
+	UUID       string     `json:"uuid" gorm:"uniqueIndex"`
+	UserID     uint       `json:"userId" gorm:"index"`
+	User       *User      `json:"user,omitempty" gorm:"foreignKey:UserID"`
+	Name       string     `json:"name"`
+	OS         string     `json:"os"`
+	PublicKey  string     `json:"publicKey" gorm:"uniqueIndex"`
+	LastSeenAt *time.Time `json:"lastSeenAt"` // last client issued or handshake of one of its clients
+	RevokedAt  *time.Time `json:"revokedAt"`
+	Clients    []*Client  `json:"clients,omitempty" gorm:"foreignKey:DeviceID"`
+}
+
+// IPAllocation holds an address of the vpn cidr handed out to a client. Rows only exist for
+// allocated addresses, the unique index makes concurrent allocations of the same address fail.
+type IPAllocation struct {
+	ID           uint      `json:"id" gorm:"primarykey"`
+	CreatedAt    time.Time `json:"createdAt"`
+	VpnGatewayID uint      `json:"vpngatewayID" gorm:"uniqueIndex:idx_ip_allocations_gateway_ip"`
+	IP           string    `json:"ip" gorm:"uniqueIndex:idx_ip_allocations_gateway_ip"`
+	ClientID     *uint     `json:"clientId" gorm:"uniqueIndex"` // set in the transaction creating the client
+	Client       *Client   `json:"client,omitempty" gorm:"foreignKey:ClientID"`
+}
+
+// IPReservation pins an address of the vpn cidr to a user, whose clients on the gateway get it, or
+// to a device of the user, whose client on the gateway gets it. Reserved addresses are never handed
+// out from the general pool.
+type IPReservation struct {
+	ID           uint      `json:"id" gorm:"primarykey"`
+	CreatedAt    time.Time `json:"createdAt"`
+	UUID         string    `json:"uuid" gorm:"uniqueIndex"`
+	VpnGatewayID uint      `json:"vpnGatewayId" gorm:"uniqueIndex:idx_ip_reservations_gateway_ip"`
+	IP           string    `json:"ip" gorm:"uniqueIndex:idx_ip_reservations_gateway_ip"`
+	UserID       uint      `json:"userId" gorm:"index"`
+	User         *User     `json:"user" gorm:"foreignKey:UserID"`
+	DeviceID     *uint     `json:"deviceId" gorm:"index"` // set for reservations of a device
+	Device       *Device   `json:"device,omitempty" gorm:"foreignKey:DeviceID"`
+	Description  string    `json:"description"`
 }
 
 type Group struct {
@@ -130,6 +406,47 @@ type Group struct {
 	Name        string        `json:"name"`
 	Users       []*User       `json:"users" gorm:"many2many:group_users;"`
 	VpnGateways []*VpnGateway `json:"vpnGateways" gorm:"many2many:group_vpngateways;"`
+	Routes      []string      `json:"routes" gorm:"serializer:json"` // narrows the routes of the gateways granted through the group
+	// MaxClientLifetime in minutes caps the clients on the gateways granted through the group, 0 for no cap
+	MaxClientLifetime int    `json:"maxClientLifetime"`
+	ExternalID        string `json:"externalId" gorm:"index"` // id of the group in the provisioning client
+}
+
+type AccessPolicyActionEnum string
+
+const (
+	AccessPolicyAllow AccessPolicyActionEnum = "Allow"
+	AccessPolicyDeny  AccessPolicyActionEnum = "Deny"
+)
+
+type AccessPolicyProtocolEnum string
+
+const (
+	AccessPolicyProtocolAny  AccessPolicyProtocolEnum = "any"
+	AccessPolicyProtocolTCP  AccessPolicyProtocolEnum = "tcp"
+	AccessPolicyProtocolUDP  AccessPolicyProtocolEnum = "udp"
+	AccessPolicyProtocolICMP AccessPolicyProtocolEnum = "icmp"
+)
+
+// AccessPolicy allows or denies a user, or the members of a group, traffic to a destination through a gateway.
+// The policies of a peer are evaluated by priority, lowest first, and deny before allow on equal priority.
+// A peer without policies reaches all of its allowed destinations, a peer with policies only what they allow.
+type AccessPolicy struct {
+	gorm.Model
+	UUID         string                   `json:"uuid" gorm:"uniqueIndex"`
+	Name         string                   `json:"name"`
+	VpnGatewayID uint                     `json:"vpnGatewayId" gorm:"index"`
+	VpnGateway   *VpnGateway              `json:"vpnGateway" gorm:"foreignKey:VpnGatewayID"`
+	UserID       *uint                    `json:"userId" gorm:"index"`
+	User         *User                    `json:"user" gorm:"foreignKey:UserID"`
+	GroupID      *uint                    `json:"groupId" gorm:"index"`
+	Group        *Group                   `json:"group" gorm:"foreignKey:GroupID"`
+	Action       AccessPolicyActionEnum   `json:"action"`
+	Destination  string                   `json:"destination"`
+	Protocol     AccessPolicyProtocolEnum `json:"protocol"`
+	PortFrom     int                      `json:"portFrom"` // tcp and udp only, 0 for all ports
+	PortTo       int                      `json:"portTo"`
+	Priority     int                      `json:"priority"`
 }
 
 type Auth struct {
@@ -140,5 +457,11 @@ type Auth struct {
 	CodeChallenge string    `json:"codeChallenge" gorm:"index"`
 	ExpiryTime    time.Time `json:"expiryTime"`
 	Email         string    `json:"email"`
+	Name          string    `json:"name"`
+	Groups        []string  `json:"groups" gorm:"serializer:json"` // values of the group claim of the user
+	SSOConfigID   uint      `json:"ssoConfigId"`                   // sso config the user authenticated through
+	GroupsClaimed bool      `json:"groupsClaimed"`                 // whether the provider sent the group claim
+	HostedDomain  string    `json:"hostedDomain"`                  // google workspace domain of the account
+	EmailVerified bool      `json:"emailVerified"`
 	Authenticated bool      `json:"authenticated"`
 }
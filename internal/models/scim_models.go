@@ -0,0 +1,91 @@
+package models
+
+import "time"
+
+const (
+	SCIMSchemaUser                  = "urn:ietf:params:scim:schemas:core:2.0:User"
+	SCIMSchemaGroup                 = "urn:ietf:params:scim:schemas:core:2.0:Group"
+	SCIMSchemaServiceProviderConfig = "urn:ietf:params:scim:schemas:core:2.0:ServiceProviderConfig"
+	SCIMSchemaListResponse          = "urn:ietf:params:scim:api:messages:2.0:ListResponse"
+	SCIMSchemaPatchOp               = "urn:ietf:params:scim:api:messages:2.0:PatchOp"
+	SCIMSchemaError                 = "urn:ietf:params:scim:api:messages:2.0:Error"
+)
+
+type SCIMMeta struct {
+	ResourceType string    `json:"resourceType"`
+	Created      time.Time `json:"created"`
+	LastModified time.Time `json:"lastModified"`
+	Location     string    `json:"location"`
+}
+
+type SCIMName struct {
+	Formatted  string `json:"formatted,omitempty"`
+	GivenName  string `json:"givenName,omitempty"`
+	FamilyName string `json:"familyName,omitempty"`
+}
+
+type SCIMMultiValuedAttribute struct {
+	Value   string `json:"value"`
+	Display string `json:"display,omitempty"`
+	Type    string `json:"type,omitempty"`
+	Primary bool   `json:"primary,omitempty"`
+	Ref     string `json:"$ref,omitempty"`
+}
+
+// SCIMUser maps onto User, the userName is the email of the user
+type SCIMUser struct {
+	Schemas     []string                   `json:"schemas"`
+	ID          string                     `json:"id,omitempty"`
+	ExternalID  string                     `json:"externalId,omitempty"`
+	UserName    string                     `json:"userName"`
+	Name        *SCIMName                  `json:"name,omitempty"`
+	DisplayName string                     `json:"displayName,omitempty"`
+	Emails      []SCIMMultiValuedAttribute `json:"emails,omitempty"`
+	Active      *bool                      `json:"active,omitempty"` // true when left out
+	Groups      []SCIMMultiValuedAttribute `json:"groups,omitempty"` // read only, set through the groups
+	Meta        *SCIMMeta                  `json:"meta,omitempty"`
+}
+
+// SCIMGroup maps onto Group, the members are the ids of the users
+type SCIMGroup struct {
+	Schemas     []string                   `json:"schemas"`
+	ID          string                     `json:"id,omitempty"`
+	ExternalID  string                     `json:"externalId,omitempty"`
+	DisplayName string                     `json:"displayName"`
+	Members     []SCIMMultiValuedAttribute `json:"members,omitempty"`
+	Meta        *SCIMMeta                  `json:"meta,omitempty"`
+}
+
+type SCIMListResponse struct {
+	Schemas      []string `json:"schemas"`
+	TotalResults int      `json:"totalResults"`
+	StartIndex   int      `json:"startIndex"`
+	ItemsPerPage int      `json:"itemsPerPage"`
+	Resources    []any    `json:"Resources"`
+}
+
+type SCIMPatchOperation struct {
+	Op    string `json:"op" binding:"required"`
+	Path  string `json:"path"`
+	Value any    `json:"value"`
+}
+
+type SCIMPatchRequest struct {
+	Schemas    []string             `json:"schemas"`
+	Operations []SCIMPatchOperation `json:"Operations" binding:"required,dive"`
+}
+
+type SCIMErrorResponse struct {
+	Schemas  []string `json:"schemas"`
+	Status   string   `json:"status"`
+	SCIMType string   `json:"scimType,omitempty"`
+	Detail   string   `json:"detail"`
+}
+
+type SCIMListRequest struct {
+	Filter     string `form:"filter"`
+	StartIndex int    `form:"startIndex"`
+	Count      *int   `form:"count"` // page size, a count of 0 only gives the total
+	// ExcludedAttributes skips the members of the groups when it names them
+	ExcludedAttributes string `form:"excludedAttributes"`
+}
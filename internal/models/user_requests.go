@@ -19,22 +19,44 @@ type UserLoginRequest struct {
 type WGClientInterfaceConfig struct {
 	ClientPrivateKey string `json:"privateKey"`
 	AllowedIpAddress string `json:"ipAddress"`
+	IPv6Address      string `json:"ipv6Address"`
 	DnsServer        string `json:"dnsServer"`
 }
 
 type WGClientPeerConfig struct {
-	AllowedIPs       []string `json:"allowedIPs"`
-	ServerPublicKey  string   `json:"publicKey"`
-	PresharedKey     string   `json:"presharedKey"`
-	PersistantAlive  int      `json:"persistantAlive"`
-	VpnGatewayDomain string   `json:"vpnGatewayDomain"`
-	VpnGatewayIP     string   `json:"vpnGatewayIP"`
-	VpnGatewayPort   int      `json:"vpnGatewayPort"`
+	AllowedIPs      []string `json:"allowedIPs"`
+	ServerPublicKey string   `json:"publicKey"`
+	PresharedKey    string   `json:"presharedKey"`
+	// Deprecated: misspelled, use PersistentKeepalive
+	PersistantAlive     int    `json:"persistantAlive"`
+	PersistentKeepalive int    `json:"persistentKeepalive"`
+	VpnGatewayDomain    string `json:"vpnGatewayDomain"`
+	VpnGatewayIP        string `json:"vpnGatewayIP"`
+	VpnGatewayPort      int    `json:"vpnGatewayPort"`
 }
 
 type WGClientConfig struct {
 	ClientUuid              string                  `json:"clientUuid"`
+	DeviceUuid              string                  `json:"deviceUuid,omitempty"` // the private key is the one of the device when set
 	WGClientInterfaceConfig WGClientInterfaceConfig `json:"clientInterfaceConfig"`
 	WGClientPeerConfig      WGClientPeerConfig      `json:"clientPeerConfig"`
 	ExpiryTime              time.Time               `json:"expiryTime"`
 }
+
+type DeviceRegisterRequest struct {
+	Name string `json:"name" binding:"required"`
+	OS   string `json:"os"`
+	// PublicKey is the wireguard public key generated on the device, the private key never leaves it
+	PublicKey string `json:"publicKey" binding:"required"`
+}
+
+type ClientRenewRequest struct {
+	// Lifetime in minutes from now, the longest lifetime allowed when 0
+	Lifetime int `json:"lifetime" binding:"min=0"`
+}
+
+type ClientRenewResponse struct {
+	ClientUuid         string    `json:"clientUuid"`
+	PreviousExpiryTime time.Time `json:"previousExpiryTime"`
+	ExpiryTime         time.Time `json:"expiryTime"`
+}
@@ -5,6 +5,7 @@ type WGServerInterfaceConfig struct {
 	PublicKey      string `json:"publicKey"`
 	PrivateKey     string `json:"privateKey"`
 	IPAddress      string `json:"ipAddress"`
+	IPv6Address    string `json:"ipv6Address"`
 	ListenPort     int    `json:"listenPort"`
 	PostUp         string `json:"postUp"`
 	PostDown       string `json:"postDown"`
@@ -15,9 +16,48 @@ type WGServerPeerConfig struct {
 	ClientAllowedIPs string `json:"clientAllowedIPs"`
 	ClientPublicKey  string `json:"clientPublicKey"`
 	PresharedKey     string `json:"presharedKey"`
+	// AllowedDestinations are the routes the peer may reach through the gateway
+	AllowedDestinations []string `json:"allowedDestinations"`
+	// FirewallRules are evaluated in order and the first match wins. Without rules the allowed
+	// destinations are reachable, with rules everything they do not allow is dropped.
+	FirewallRules []FirewallRule `json:"firewallRules"`
+}
+
+type FirewallRule struct {
+	Action      AccessPolicyActionEnum   `json:"action"`
+	Destination string                   `json:"destination"`
+	Protocol    AccessPolicyProtocolEnum `json:"protocol"`
+	PortFrom    int                      `json:"portFrom"`
+	PortTo      int                      `json:"portTo"`
 }
 
 type WGServerConfig struct {
 	WGServerInterfaceConfig WGServerInterfaceConfig `json:"wgServerInterfaceConfig"`
 	WGServerPeerConfigs     []WGServerPeerConfig    `json:"wgServerPeerConfigs"`
 }
+
+type VpnGatewayHeartbeatRequest struct {
+	Version              string `json:"version" binding:"required"`
+	UptimeSeconds        int64  `json:"uptimeSeconds"`
+	PublicIP             string `json:"publicIP" binding:"omitempty,ip"`
+	WGInterfaceName      string `json:"wgInterfaceName"`
+	WGInterfaceUp        bool   `json:"wgInterfaceUp"`
+	WGInterfacePeerCount int    `json:"wgInterfacePeerCount" binding:"min=0"`
+}
+
+type WGPeerStats struct {
+	ClientPublicKey string `json:"clientPublicKey" binding:"required"`
+	LatestHandshake int64  `json:"latestHandshake"` // unix seconds, 0 if the peer never completed a handshake
+	RxBytes         int64  `json:"rxBytes"`
+	TxBytes         int64  `json:"txBytes"`
+	Endpoint        string `json:"endpoint"`
+}
+
+type VpnGatewayPeerStatsRequest struct {
+	Peers []WGPeerStats `json:"peers" binding:"dive"`
+}
+
+type VpnGatewayPeerStatsResponse struct {
+	Updated int `json:"updated"`
+	Unknown int `json:"unknown"` // peers without an active client on the gateway
+}
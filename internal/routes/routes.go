@@ -20,14 +20,19 @@ func SetupControllerRoutes(r *gin.Engine) {
 		authGroup.GET("/:provider/sso/initiate", handlers.InitiateSSOAuth)
 		authGroup.GET("/:provider/sso/callback", handlers.UserAuthSSOCallback)
 		authGroup.GET("/:provider/sso/token", handlers.UserAuthVerifySSOToken)
-		authGroup.GET("/:provider/web/sso/initiate", handlers.WebGoogleLoginInitiate)
-		authGroup.GET("/:provider/web/sso/callback", handlers.WebGoogleLoginCallback)
-		authGroup.GET("/:provider/web/sso/token", handlers.WebGoogleLoginToken)
+		authGroup.GET("/:provider/web/sso/initiate", handlers.WebSSOLoginInitiate)
+		authGroup.GET("/:provider/web/sso/callback", handlers.WebSSOLoginCallback)
+		authGroup.GET("/:provider/web/sso/token", handlers.WebSSOLoginToken)
+		authGroup.GET("/saml/:provider/metadata", handlers.SAMLMetadata)
+		authGroup.GET("/saml/:provider/login", handlers.SAMLLoginInitiate)
+		authGroup.POST("/saml/:provider/acs", handlers.SAMLAssertionConsumer)
 	}
 
 	gatewayGroup := r.Group("/api/v1/gateway")
 	{
 		gatewayGroup.GET("/get-gateway-config", middlewares.VpnGatewayAuthCheckMiddleware, handlers.GetVpnGatewayWGConfigByGW)
+		gatewayGroup.POST("/heartbeat", middlewares.VpnGatewayAuthCheckMiddleware, handlers.VpnGatewayHeartbeat)
+		gatewayGroup.POST("/peer-stats", middlewares.VpnGatewayAuthCheckMiddleware, handlers.VpnGatewayPeerStats)
 	}
 
 	adminAccessGroup := r.Group("/api/v1/admin/access")
@@ -39,6 +44,11 @@ func SetupControllerRoutes(r *gin.Engine) {
 
 	}
 
+	adminAuditGroup := r.Group("/api/v1/admin/audit")
+	{
+		adminAuditGroup.GET("", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.ListAuditTrails)
+	}
+
 	adminClientGroup := r.Group("/api/v1/admin/client")
 	{
 		adminClientGroup.DELETE("/:id", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.DeleteVpnClientByAdmin)
@@ -48,13 +58,27 @@ func SetupControllerRoutes(r *gin.Engine) {
 	adminConfigGroup := r.Group("/api/v1/admin/config")
 	{
 		adminConfigGroup.POST("/sso", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.AddSsoConfig)
+		adminConfigGroup.POST("/sso/saml", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.AddSamlSsoConfig)
 		adminConfigGroup.DELETE("/sso/:id", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.DeleteSsoConfig)
+		adminConfigGroup.PUT("/sso/:id/saml-metadata", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.UpdateSamlIdpMetadata)
+		adminConfigGroup.PUT("/sso/:id/jit", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.UpdateSSOJITProvisioning)
+		adminConfigGroup.POST("/sso/:id/group-mappings", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.CreateSSOGroupMapping)
+		adminConfigGroup.GET("/sso/:id/group-mappings", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.ListSSOGroupMappings)
+		adminConfigGroup.DELETE("/sso/:id/group-mappings/:mappingId", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.DeleteSSOGroupMapping)
 		adminConfigGroup.PUT("/password-login", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.UpdateAllowPasswordLogin)
 		adminConfigGroup.PUT("/sso-login", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.UpdateAllowSSOLogin)
+		adminConfigGroup.PUT("/scim-token", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.RotateSCIMToken)
+		adminConfigGroup.DELETE("/scim-token", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.RevokeSCIMToken)
 		adminConfigGroup.GET("/", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.GetAdminConfiguration)
 
 	}
 
+	adminDeviceGroup := r.Group("/api/v1/admin/device")
+	{
+		adminDeviceGroup.GET("/list", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.ListDevicesByAdmin)
+		adminDeviceGroup.DELETE("/:id", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.RevokeDeviceByAdmin)
+	}
+
 	adminGatewayGroup := r.Group("/api/v1/admin/gateway")
 	{
 		adminGatewayGroup.POST("/", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.CreateVpnGateway)
@@ -64,6 +88,17 @@ func SetupControllerRoutes(r *gin.Engine) {
 		adminGatewayGroup.GET("/:id/deployment-config", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.GetVpnGatewayDeploymentConfig)
 		adminGatewayGroup.GET("/:id", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.GetGatewayByUUID)
 		adminGatewayGroup.DELETE("/:id/reset", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.ClearVpnGatewayClientsAndIPPool)
+		adminGatewayGroup.GET("/:id/operations", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.ListGatewayOperations)
+		adminGatewayGroup.PUT("/:id/operations/:operationId/retry", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.RetryGatewayOperation)
+		adminGatewayGroup.PUT("/:id/operations/:operationId/dismiss", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.DismissGatewayOperation)
+		adminGatewayGroup.GET("/:id/drift", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.GetGatewayDriftReport)
+		adminGatewayGroup.PUT("/:id/reconcile", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.ReconcileGateway)
+		adminGatewayGroup.GET("/:id/sessions", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.ListGatewayActiveSessions)
+		adminGatewayGroup.GET("/:id/utilization", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.GetGatewayIPUtilization)
+		adminGatewayGroup.PUT("/:id/ip-allocations/repair", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.RepairGatewayIPAllocations)
+		adminGatewayGroup.POST("/:id/reservations", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.CreateGatewayIPReservation)
+		adminGatewayGroup.GET("/:id/reservations", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.ListGatewayIPReservations)
+		adminGatewayGroup.DELETE("/:id/reservations/:reservationId", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.DeleteGatewayIPReservation)
 
 	}
 
@@ -78,6 +113,15 @@ func SetupControllerRoutes(r *gin.Engine) {
 
 	}
 
+	adminPolicyGroup := r.Group("/api/v1/admin/policy")
+	{
+		adminPolicyGroup.POST("/", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.CreateAccessPolicy)
+		adminPolicyGroup.GET("/list", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.ListAccessPolicies)
+		adminPolicyGroup.GET("/:id", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.GetAccessPolicyByUUID)
+		adminPolicyGroup.PUT("/:id", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.UpdateAccessPolicy)
+		adminPolicyGroup.DELETE("/:id", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.DeleteAccessPolicy)
+	}
+
 	adminUserGroup := r.Group("/api/v1/admin/user")
 	{
 		adminUserGroup.POST("/", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.RegisterUser)
@@ -85,6 +129,24 @@ func SetupControllerRoutes(r *gin.Engine) {
 		adminUserGroup.DELETE("/:id", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.DeleteUser)
 		adminUserGroup.GET("/list", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.ListUsers)
 		adminUserGroup.GET("/:id", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.GetUserByUUID)
+		adminUserGroup.GET("/:id/sessions", middlewares.ControllerAuthCheckMiddleware, middlewares.AdminRoleCheckMiddleware, handlers.ListUserActiveSessions)
+	}
+
+	scimGroup := r.Group("/scim/v2")
+	{
+		scimGroup.GET("/ServiceProviderConfig", middlewares.SCIMAuthCheckMiddleware, handlers.SCIMServiceProviderConfig)
+		scimGroup.GET("/Users", middlewares.SCIMAuthCheckMiddleware, handlers.ListSCIMUsers)
+		scimGroup.POST("/Users", middlewares.SCIMAuthCheckMiddleware, handlers.CreateSCIMUser)
+		scimGroup.GET("/Users/:id", middlewares.SCIMAuthCheckMiddleware, handlers.GetSCIMUser)
+		scimGroup.PUT("/Users/:id", middlewares.SCIMAuthCheckMiddleware, handlers.ReplaceSCIMUser)
+		scimGroup.PATCH("/Users/:id", middlewares.SCIMAuthCheckMiddleware, handlers.PatchSCIMUser)
+		scimGroup.DELETE("/Users/:id", middlewares.SCIMAuthCheckMiddleware, handlers.DeleteSCIMUser)
+		scimGroup.GET("/Groups", middlewares.SCIMAuthCheckMiddleware, handlers.ListSCIMGroups)
+		scimGroup.POST("/Groups", middlewares.SCIMAuthCheckMiddleware, handlers.CreateSCIMGroup)
+		scimGroup.GET("/Groups/:id", middlewares.SCIMAuthCheckMiddleware, handlers.GetSCIMGroup)
+		scimGroup.PUT("/Groups/:id", middlewares.SCIMAuthCheckMiddleware, handlers.ReplaceSCIMGroup)
+		scimGroup.PATCH("/Groups/:id", middlewares.SCIMAuthCheckMiddleware, handlers.PatchSCIMGroup)
+		scimGroup.DELETE("/Groups/:id", middlewares.SCIMAuthCheckMiddleware, handlers.DeleteSCIMGroup)
 	}
 
 	userGroup := r.Group("/api/v1/")
@@ -93,6 +155,11 @@ func SetupControllerRoutes(r *gin.Engine) {
 		userGroup.GET("/gateway/list", middlewares.ControllerAuthCheckMiddleware, handlers.GetVpnGatewaysAccessibleByUser)
 		userGroup.GET("/gateway/:id/client", middlewares.ControllerAuthCheckMiddleware, handlers.GetVpnClientConfig)
 		userGroup.DELETE("/client/:id", middlewares.ControllerAuthCheckMiddleware, handlers.DeleteVpnClient)
+		userGroup.POST("/client/:id/renew", middlewares.ControllerAuthCheckMiddleware, middlewares.FreshAuthCheckMiddleware, handlers.RenewVpnClient)
+		userGroup.GET("/sessions", middlewares.ControllerAuthCheckMiddleware, handlers.GetActiveSessions)
+		userGroup.POST("/device", middlewares.ControllerAuthCheckMiddleware, handlers.RegisterDevice)
+		userGroup.GET("/device/list", middlewares.ControllerAuthCheckMiddleware, handlers.ListDevices)
+		userGroup.DELETE("/device/:id", middlewares.ControllerAuthCheckMiddleware, handlers.RevokeDevice)
 	}
 
 }
@@ -0,0 +1,71 @@
+package scheduler
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/leetsecure/qryptic-controller/internal/utils/logger"
+)
+
+// Job is a background task run by the scheduler every Interval.
+// If Wakeup is set, a signal on it runs the job early.
+type Job struct {
+	Name     string
+	Interval time.Duration
+	Run      func() error
+	Wakeup   <-chan struct{}
+}
+
+type Scheduler struct {
+	jobs []Job
+	wg   sync.WaitGroup
+}
+
+func New() *Scheduler {
+	return &Scheduler{}
+}
+
+func (s *Scheduler) AddJob(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Start launches every registered job in its own goroutine. Jobs stop once ctx is cancelled.
+func (s *Scheduler) Start(ctx context.Context) {
+	log := logger.Default()
+	for _, job := range s.jobs {
+		if job.Interval <= 0 {
+			log.Infof("scheduler job %s disabled", job.Name)
+			continue
+		}
+		s.wg.Add(1)
+		go s.runJob(ctx, job)
+	}
+}
+
+// Wait blocks until all running jobs have returned.
+func (s *Scheduler) Wait() {
+	s.wg.Wait()
+}
+
+func (s *Scheduler) runJob(ctx context.Context, job Job) {
+	log := logger.Default()
+	defer s.wg.Done()
+
+	log.Infof("scheduler job %s started with interval %s", job.Name, job.Interval)
+	ticker := time.NewTicker(job.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Infof("scheduler job %s stopped", job.Name)
+			return
+		case <-ticker.C:
+		case <-job.Wakeup:
+		}
+		if err := job.Run(); err != nil {
+			log.Errorf("scheduler job %s failed : %v", job.Name, err)
+		}
+	}
+}
@@ -0,0 +1,352 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/google/uuid"
+	"github.com/leetsecure/qryptic-controller/internal/database"
+	"github.com/leetsecure/qryptic-controller/internal/models"
+	"github.com/leetsecure/qryptic-controller/internal/utils/helper"
+	"gorm.io/gorm"
+)
+
+func CreateAccessPolicy(actorUuid string, request models.AccessPolicyCreateRequest) (models.AccessPolicy, error) {
+	accessPolicy := models.AccessPolicy{
+		UUID:        uuid.NewString(),
+		Name:        request.Name,
+		Action:      request.Action,
+		Destination: request.Destination,
+		Protocol:    request.Protocol,
+		PortFrom:    request.PortFrom,
+		PortTo:      request.PortTo,
+		Priority:    request.Priority,
+	}
+
+	vpnGateway, exists, err := getVpnGatewayFromUuid(request.VpnGatewayUuid)
+	if err != nil {
+		return accessPolicy, err
+	}
+	if !exists {
+		return accessPolicy, errors.New("vpn gateway with given uuid not present")
+	}
+	accessPolicy.VpnGatewayID = vpnGateway.ID
+
+	var subject string
+	if request.UserUuid != "" {
+		user, exists, err := getUserFromUuid(request.UserUuid)
+		if err != nil {
+			return accessPolicy, err
+		}
+		if !exists {
+			return accessPolicy, errors.New("user with given uuid not present")
+		}
+		accessPolicy.UserID = &user.ID
+		subject = "user " + user.Email
+	} else {
+		group, exists, err := getGroupFromUuid(request.GroupUuid)
+		if err != nil {
+			return accessPolicy, err
+		}
+		if !exists {
+			return accessPolicy, errors.New("group with given uuid not present")
+		}
+		accessPolicy.GroupID = &group.ID
+		subject = "group " + group.Name
+	}
+
+	if err := normalizeAccessPolicy(&accessPolicy, vpnGateway); err != nil {
+		return accessPolicy, err
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&accessPolicy).Error; err != nil {
+			return err
+		}
+		return enqueueAccessPolicyPeerUpdates(tx, accessPolicy)
+	})
+	if err != nil {
+		return accessPolicy, err
+	}
+	notifyGatewayOperationsWorker()
+
+	recordAuditTrail(actorUuid, models.AuditTrail{
+		Action:       models.AuditActionPolicyCreate,
+		Description:  fmt.Sprintf("access policy %s created on vpn gateway %s for %s : %s", accessPolicy.Name, vpnGateway.Name, subject, describeAccessPolicy(accessPolicy)),
+		VpnGatewayID: &accessPolicy.VpnGatewayID,
+		UserID:       accessPolicy.UserID,
+		GroupID:      accessPolicy.GroupID,
+	})
+	return accessPolicy, nil
+}
+
+func UpdateAccessPolicy(actorUuid, accessPolicyUuid string, request models.AccessPolicyUpdateRequest) error {
+	var accessPolicy models.AccessPolicy
+	if err := database.DB.Where("uuid = ?", accessPolicyUuid).First(&accessPolicy).Error; err != nil {
+		return err
+	}
+
+	if request.Name != "" {
+		accessPolicy.Name = request.Name
+	}
+	if request.Action != "" {
+		accessPolicy.Action = request.Action
+	}
+	if request.Destination != "" {
+		accessPolicy.Destination = request.Destination
+	}
+	if request.Protocol != "" {
+		accessPolicy.Protocol = request.Protocol
+	}
+	if request.PortFrom != nil {
+		accessPolicy.PortFrom = *request.PortFrom
+	}
+	if request.PortTo != nil {
+		accessPolicy.PortTo = *request.PortTo
+	}
+	if request.Priority != nil {
+		accessPolicy.Priority = *request.Priority
+	}
+	var vpnGateway models.VpnGateway
+	if err := database.DB.Where("id = ?", accessPolicy.VpnGatewayID).First(&vpnGateway).Error; err != nil {
+		return err
+	}
+	if err := normalizeAccessPolicy(&accessPolicy, vpnGateway); err != nil {
+		return err
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&accessPolicy).Error; err != nil {
+			return err
+		}
+		return enqueueAccessPolicyPeerUpdates(tx, accessPolicy)
+	})
+	if err != nil {
+		return err
+	}
+	notifyGatewayOperationsWorker()
+
+	recordAuditTrail(actorUuid, models.AuditTrail{
+		Action:       models.AuditActionPolicyUpdate,
+		Description:  fmt.Sprintf("access policy %s updated : %s", accessPolicy.Name, describeAccessPolicy(accessPolicy)),
+		VpnGatewayID: &accessPolicy.VpnGatewayID,
+		UserID:       accessPolicy.UserID,
+		GroupID:      accessPolicy.GroupID,
+	})
+	return nil
+}
+
+func DeleteAccessPolicy(actorUuid, accessPolicyUuid string) error {
+	var accessPolicy models.AccessPolicy
+	if err := database.DB.Where("uuid = ?", accessPolicyUuid).First(&accessPolicy).Error; err != nil {
+		return err
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&accessPolicy).Error; err != nil {
+			return err
+		}
+		return enqueueAccessPolicyPeerUpdates(tx, accessPolicy)
+	})
+	if err != nil {
+		return err
+	}
+	notifyGatewayOperationsWorker()
+
+	recordAuditTrail(actorUuid, models.AuditTrail{
+		Action:       models.AuditActionPolicyDelete,
+		Description:  fmt.Sprintf("access policy %s deleted : %s", accessPolicy.Name, describeAccessPolicy(accessPolicy)),
+		VpnGatewayID: &accessPolicy.VpnGatewayID,
+		UserID:       accessPolicy.UserID,
+		GroupID:      accessPolicy.GroupID,
+	})
+	return nil
+}
+
+func ListAccessPolicies(vpnGatewayUuid, userUuid, groupUuid string) ([]models.AccessPolicy, error) {
+	var accessPolicies []models.AccessPolicy
+	dbClient := database.DB.Preload("VpnGateway").Preload("User").Preload("Group")
+	if vpnGatewayUuid != "" {
+		dbClient = dbClient.Where("vpn_gateway_id IN (?)", database.DB.Model(&models.VpnGateway{}).Select("id").Where("uuid = ?", vpnGatewayUuid))
+	}
+	if userUuid != "" {
+		dbClient = dbClient.Where("user_id IN (?)", database.DB.Model(&models.User{}).Select("id").Where("uuid = ?", userUuid))
+	}
+	if groupUuid != "" {
+		dbClient = dbClient.Where("group_id IN (?)", database.DB.Model(&models.Group{}).Select("id").Where("uuid = ?", groupUuid))
+	}
+	if err := dbClient.Order("vpn_gateway_id, priority, id").Find(&accessPolicies).Error; err != nil {
+		return nil, err
+	}
+	return accessPolicies, nil
+}
+
+func GetAccessPolicyByUUID(accessPolicyUuid string) (models.AccessPolicy, error) {
+	var accessPolicy models.AccessPolicy
+	err := database.DB.Preload("VpnGateway").Preload("User").Preload("Group").
+		Where("uuid = ?", accessPolicyUuid).
+		First(&accessPolicy).Error
+	return accessPolicy, err
+}
+
+// normalizeAccessPolicy fills the defaults of the policy and checks that the destination lies within the
+// routes of the gateway and that the port range fits the protocol.
+func normalizeAccessPolicy(accessPolicy *models.AccessPolicy, vpnGateway models.VpnGateway) error {
+	destinations, err := helper.NormalizeCIDRs([]string{accessPolicy.Destination})
+	if err != nil {
+		return err
+	}
+	accessPolicy.Destination = destinations[0]
+	// a gateway without routes is a full tunnel and reaches every destination
+	if len(vpnGateway.Routes) > 0 && !slices.Equal(helper.IntersectCIDRs(destinations, vpnGateway.Routes), destinations) {
+		return fmt.Errorf("destination %s is not within the routes of vpn gateway %s", accessPolicy.Destination, vpnGateway.Name)
+	}
+
+	if accessPolicy.Protocol == "" {
+		accessPolicy.Protocol = models.AccessPolicyProtocolAny
+	}
+	if accessPolicy.Protocol != models.AccessPolicyProtocolTCP && accessPolicy.Protocol != models.AccessPolicyProtocolUDP {
+		if accessPolicy.PortFrom != 0 || accessPolicy.PortTo != 0 {
+			return errors.New("ports are only supported for tcp and udp")
+		}
+		return nil
+	}
+	if accessPolicy.PortTo == 0 {
+		accessPolicy.PortTo = accessPolicy.PortFrom
+	}
+	if accessPolicy.PortTo < accessPolicy.PortFrom {
+		return errors.New("portTo must not be lower than portFrom")
+	}
+	return nil
+}
+
+func describeAccessPolicy(accessPolicy models.AccessPolicy) string {
+	description := fmt.Sprintf("%s %s to %s", accessPolicy.Action, accessPolicy.Protocol, accessPolicy.Destination)
+	if accessPolicy.PortFrom != 0 {
+		description += fmt.Sprintf(" ports %d-%d", accessPolicy.PortFrom, accessPolicy.PortTo)
+	}
+	return description + fmt.Sprintf(" with priority %d", accessPolicy.Priority)
+}
+
+// enqueueAccessPolicyPeerUpdates pushes the firewall rules of the peers the policy applies to.
+func enqueueAccessPolicyPeerUpdates(tx *gorm.DB, accessPolicy models.AccessPolicy) error {
+	userIDs := []uint{}
+	if accessPolicy.UserID != nil {
+		userIDs = append(userIDs, *accessPolicy.UserID)
+	} else if accessPolicy.GroupID != nil {
+		err := tx.Table("group_users").Where("group_id = ?", *accessPolicy.GroupID).Pluck("user_id", &userIDs).Error
+		if err != nil {
+			return err
+		}
+	}
+	return enqueuePeerUpdates(tx, accessPolicy.VpnGatewayID, userIDs)
+}
+
+// enqueuePeerUpdates queues an update-peers operation with the current config of the active clients
+// of the users on the gateway. It has to be followed by notifyGatewayOperationsWorker after commit.
+func enqueuePeerUpdates(tx *gorm.DB, vpnGatewayID uint, userIDs []uint) error {
+	if len(userIDs) == 0 {
+		return nil
+	}
+	var vpnGateway models.VpnGateway
+	if err := tx.Where("id = ?", vpnGatewayID).First(&vpnGateway).Error; err != nil {
+		return err
+	}
+	var clients []*models.Client
+	err := tx.Where("vpn_gateway_id = ? AND user_id IN ? AND is_active = ?", vpnGatewayID, userIDs, true).Find(&clients).Error
+	if err != nil {
+		return err
+	}
+
+	wgServerPeerConfigs, err := wgServerPeerConfigsForClients(tx, vpnGateway, clients)
+	if err != nil {
+		return err
+	}
+	if len(wgServerPeerConfigs) == 0 {
+		return nil
+	}
+	return enqueueGatewayOperation(tx, vpnGatewayID, models.GatewayOperationUpdatePeers, wgServerPeerConfigs)
+}
+
+// wgServerPeerConfigsForClients builds the peer configs of the clients of a gateway with their firewall
+// rules compiled from the access policies of their users and groups.
+func wgServerPeerConfigsForClients(db *gorm.DB, vpnGateway models.VpnGateway, clients []*models.Client) ([]models.WGServerPeerConfig, error) {
+	var wgServerPeerConfigs []models.WGServerPeerConfig
+	if len(clients) == 0 {
+		return wgServerPeerConfigs, nil
+	}
+
+	var accessPolicies []models.AccessPolicy
+	if err := db.Where("vpn_gateway_id = ?", vpnGateway.ID).Order("priority, id").Find(&accessPolicies).Error; err != nil {
+		return nil, err
+	}
+	// deny before allow on equal priority
+	sort.SliceStable(accessPolicies, func(i, j int) bool {
+		if accessPolicies[i].Priority != accessPolicies[j].Priority {
+			return accessPolicies[i].Priority < accessPolicies[j].Priority
+		}
+		return accessPolicies[i].Action == models.AccessPolicyDeny && accessPolicies[j].Action != models.AccessPolicyDeny
+	})
+
+	userGroupIDs := make(map[uint]map[uint]bool)
+	if len(accessPolicies) > 0 {
+		userIDs := make([]uint, 0, len(clients))
+		for _, client := range clients {
+			userIDs = append(userIDs, client.UserID)
+		}
+		var groupUsers []struct {
+			UserID  uint
+			GroupID uint
+		}
+		err := db.Table("group_users").
+			Select("group_users.user_id, group_users.group_id").
+			Joins("JOIN groups ON groups.id = group_users.group_id AND groups.deleted_at IS NULL").
+			Where("group_users.user_id IN ?", userIDs).
+			Scan(&groupUsers).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, groupUser := range groupUsers {
+			if userGroupIDs[groupUser.UserID] == nil {
+				userGroupIDs[groupUser.UserID] = make(map[uint]bool)
+			}
+			userGroupIDs[groupUser.UserID][groupUser.GroupID] = true
+		}
+	}
+
+	for _, client := range clients {
+		wgServerPeerConfig := models.WGServerPeerConfig{
+			ClientAllowedIPs:    clientAddresses(*client),
+			ClientPublicKey:     client.ClientPublicKey,
+			PresharedKey:        client.PresharedKey,
+			AllowedDestinations: clientAllowedIPs(*client),
+			FirewallRules:       []models.FirewallRule{},
+		}
+		for _, accessPolicy := range accessPolicies {
+			applies := (accessPolicy.UserID != nil && *accessPolicy.UserID == client.UserID) ||
+				(accessPolicy.GroupID != nil && userGroupIDs[client.UserID][*accessPolicy.GroupID])
+			if !applies {
+				continue
+			}
+			wgServerPeerConfig.FirewallRules = append(wgServerPeerConfig.FirewallRules, models.FirewallRule{
+				Action:      accessPolicy.Action,
+				Destination: accessPolicy.Destination,
+				Protocol:    accessPolicy.Protocol,
+				PortFrom:    accessPolicy.PortFrom,
+				PortTo:      accessPolicy.PortTo,
+			})
+		}
+		wgServerPeerConfigs = append(wgServerPeerConfigs, wgServerPeerConfig)
+	}
+	return wgServerPeerConfigs, nil
+}
+
+// clientAddresses lists the ipv4 and, on dual-stack gateways, the ipv6 address of the client.
+func clientAddresses(client models.Client) string {
+	if client.AllocatedIPv6 == "" {
+		return client.AllocatedIP
+	}
+	return client.AllocatedIP + "," + client.AllocatedIPv6
+}
@@ -10,6 +10,7 @@ import (
 	"github.com/leetsecure/qryptic-controller/internal/models"
 	"github.com/leetsecure/qryptic-controller/internal/utils/auth"
 	"github.com/leetsecure/qryptic-controller/internal/utils/logger"
+	"gorm.io/gorm"
 )
 
 func InitAdminConfig() error {
@@ -98,7 +99,7 @@ func createInitialTempUser() error {
 	tempEmailId := fmt.Sprintf("%s@qryptic.com", auth.RandomStringGenerator(10))
 	tempPassword := fmt.Sprintf("%s@%s#%s", auth.RandomStringGenerator(5), auth.RandomStringGenerator(5), auth.RandomStringGenerator(5))
 	log.Infof("Temporary Email Id : %s \n Temporary Password : %s", tempEmailId, tempPassword)
-	err := RegisterUser(tempEmailId, tempPassword, string(models.AdminRole), true)
+	err := RegisterUser("", tempEmailId, tempPassword, string(models.AdminRole), true, 0)
 	if err != nil {
 		return err
 	}
@@ -107,27 +108,6 @@ func createInitialTempUser() error {
 	return nil
 }
 
-func initializeSSOProviders() error {
-	log := logger.Default()
-	var ssoConfigs []models.SSOConfig
-	if err := database.DB.Where("platform = ?", "Website").First(&ssoConfigs).Error; err != nil {
-		return err
-	}
-	log.Infof("Number of sso configs : %d", len(ssoConfigs))
-	if len(ssoConfigs) > 0 {
-		config.SsoConfig = append(config.SsoConfig, map[string]string{
-			"Platform":     ssoConfigs[0].Platform,
-			"Provider":     ssoConfigs[0].Provider,
-			"ClientID":     ssoConfigs[0].ClientID,
-			"ClientSecret": ssoConfigs[0].ClientSecret,
-		})
-		config.GoogleClientID = ssoConfigs[0].ClientID
-		config.GoogleClientSecret = ssoConfigs[0].ClientSecret
-		log.Infof("GoogleClientID : %s", config.GoogleClientID)
-	}
-	return nil
-}
-
 func GetAdminConfiguration(includeSsoConfigs bool) (models.AdminConfiguration, error) {
 	var adminConfiguration models.AdminConfiguration
 	dbClient := database.DB
@@ -154,6 +134,10 @@ func GetSsoConfiguration() (bool, map[string]string, error) {
 	}
 	response := map[string]string{}
 	for _, ssoConfig := range adminConfiguration.SSOConfigs {
+		// saml providers have no client id for the apps
+		if ssoConfig.Protocol == models.SSOProtocolSAML {
+			continue
+		}
 		platform := ssoConfig.Platform
 		response[platform] = ssoConfig.ClientID
 	}
@@ -161,7 +145,7 @@ func GetSsoConfiguration() (bool, map[string]string, error) {
 	return true, response, nil
 }
 
-func UpdateAllowPasswordLogin(updatedValue bool) error {
+func UpdateAllowPasswordLogin(actorUuid string, updatedValue bool) error {
 	var adminConfiguration models.AdminConfiguration
 	if err := database.DB.First(&adminConfiguration).Error; err != nil {
 		return err
@@ -172,10 +156,14 @@ func UpdateAllowPasswordLogin(updatedValue bool) error {
 		return err
 	}
 	config.AllowPasswordLogin = updatedValue
+	recordAuditTrail(actorUuid, models.AuditTrail{
+		Action:      models.AuditActionPasswordLoginConfigUpdate,
+		Description: fmt.Sprintf("password login allowed set to %t", updatedValue),
+	})
 	return nil
 }
 
-func UpdateAllowSSOLogin(updatedValue bool) error {
+func UpdateAllowSSOLogin(actorUuid string, updatedValue bool) error {
 	var adminConfiguration models.AdminConfiguration
 	if err := database.DB.First(&adminConfiguration).Error; err != nil {
 		return err
@@ -186,40 +174,81 @@ func UpdateAllowSSOLogin(updatedValue bool) error {
 		return err
 	}
 	config.AllowSSOLogin = updatedValue
+	recordAuditTrail(actorUuid, models.AuditTrail{
+		Action:      models.AuditActionSSOLoginConfigUpdate,
+		Description: fmt.Sprintf("sso login allowed set to %t", updatedValue),
+	})
 	return nil
 }
 
-func AddSsoConfig(domain, provider, clientId, clientSecret, platform string) error {
+// AddSsoConfig adds an OIDC provider, the provider name is the one used in the sso routes. The issuer
+// may only be left out for google.
+func AddSsoConfig(actorUuid, domain, provider, clientId, clientSecret, platform, issuerUrl string, scopes []string, emailClaim, groupClaim string, trustEmailClaim bool) error {
 
+	if err := checkSSOProviderProtocol(provider, models.SSOProtocolOIDC); err != nil {
+		return err
+	}
 	adminConfiguration, err := GetAdminConfiguration(true)
 	if err != nil {
 		return err
 	}
 	var ssoConfig models.SSOConfig
 	ssoConfig.UUID = uuid.NewString()
+	ssoConfig.Protocol = models.SSOProtocolOIDC
 	ssoConfig.Platform = platform
 	ssoConfig.Domain = domain
 	ssoConfig.Provider = provider
 	ssoConfig.ClientID = clientId
 	ssoConfig.ClientSecret = clientSecret
+	ssoConfig.IssuerURL = issuerUrl
+	ssoConfig.Scopes = scopes
+	ssoConfig.EmailClaim = emailClaim
+	ssoConfig.GroupClaim = groupClaim
+	ssoConfig.TrustEmailClaim = trustEmailClaim
+	// the discovery document has to be reachable for the provider to be usable, a provider cached
+	// for the issuer by an earlier config is discovered again
+	forgetOIDCProvider(ssoIssuerURL(ssoConfig))
+	if _, err := discoverOIDCProvider(ssoIssuerURL(ssoConfig)); err != nil {
+		return err
+	}
 
 	adminConfiguration.SSOConfigs = append(adminConfiguration.SSOConfigs, &ssoConfig)
 	err = database.DB.Save(&adminConfiguration).Error
 	if err != nil {
 		return err
 	}
+	recordAuditTrail(actorUuid, models.AuditTrail{
+		Action:      models.AuditActionSSOConfigAdd,
+		Description: fmt.Sprintf("sso config %s added for provider %s, platform %s, domain %s and issuer %s, trusting emails without verification claim %t", ssoConfig.UUID, ssoConfig.Provider, ssoConfig.Platform, ssoConfig.Domain, ssoIssuerURL(ssoConfig), ssoConfig.TrustEmailClaim),
+	})
 	return nil
 }
 
-func DeleteSsoConfig(ssoConfigUuid string) error {
+func DeleteSsoConfig(actorUuid, ssoConfigUuid string) error {
 	var ssoConfig models.SSOConfig
 	if err := database.DB.Where("uuid = ?", ssoConfigUuid).First(&ssoConfig).Error; err != nil {
 		return err
 	}
-	err := database.DB.Delete(&ssoConfig).Error
+	// the groups mapped by the config are no longer synced
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("sso_config_id = ?", ssoConfig.ID).Delete(&models.SSOGroupMapping{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&ssoConfig).Association("JITDefaultGroups").Clear(); err != nil {
+			return err
+		}
+		return tx.Delete(&ssoConfig).Error
+	})
 	if err != nil {
 		return err
 	}
+	if ssoConfig.Protocol != models.SSOProtocolSAML {
+		forgetOIDCProvider(ssoIssuerURL(ssoConfig))
+	}
+	recordAuditTrail(actorUuid, models.AuditTrail{
+		Action:      models.AuditActionSSOConfigDelete,
+		Description: fmt.Sprintf("sso config %s deleted for provider %s, platform %s and domain %s", ssoConfig.UUID, ssoConfig.Provider, ssoConfig.Platform, ssoConfig.Domain),
+	})
 
 	return nil
 }
@@ -0,0 +1,101 @@
+package services
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leetsecure/qryptic-controller/internal/database"
+	"github.com/leetsecure/qryptic-controller/internal/models"
+	"github.com/leetsecure/qryptic-controller/internal/utils/logger"
+	"gorm.io/gorm"
+)
+
+const (
+	defaultAuditTrailPageSize = 50
+	maxAuditTrailPageSize     = 500
+)
+
+// recordAuditTrail stores an audit entry for an action performed by actorUuid, an empty actor is the controller itself.
+// Failures are only logged so that auditing never fails the audited operation.
+func recordAuditTrail(actorUuid string, auditTrail models.AuditTrail) {
+	log := logger.Default()
+	auditTrail.UUID = uuid.NewString()
+	auditTrail.Timestamp = time.Now()
+	auditTrail.ActorUUID = actorUuid
+	if auditTrail.ActorType == "" {
+		auditTrail.ActorType = models.AuditActorUser
+		if actorUuid == "" {
+			auditTrail.ActorType = models.AuditActorSystem
+		}
+	}
+	if err := database.DB.Create(&auditTrail).Error; err != nil {
+		log.Errorf("error in recording audit trail for action %s by %s : %v", auditTrail.Action, actorUuid, err)
+	}
+}
+
+func ListAuditTrails(request models.AuditTrailListRequest) (models.AuditTrailListResponse, error) {
+	var response models.AuditTrailListResponse
+
+	page := request.Page
+	if page < 1 {
+		page = 1
+	}
+	pageSize := request.PageSize
+	if pageSize < 1 {
+		pageSize = defaultAuditTrailPageSize
+	}
+	if pageSize > maxAuditTrailPageSize {
+		pageSize = maxAuditTrailPageSize
+	}
+
+	// deleted users, groups, gateways and clients still have to be found in the audit trail
+	unscoped := func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}
+
+	dbClient := database.DB.Model(&models.AuditTrail{})
+	if request.Action != "" {
+		dbClient = dbClient.Where("action = ?", request.Action)
+	}
+	if request.ActorUuid != "" {
+		dbClient = dbClient.Where("actor_uuid = ?", request.ActorUuid)
+	}
+	if request.UserUuid != "" {
+		dbClient = dbClient.Where("user_id IN (?)", database.DB.Unscoped().Model(&models.User{}).Select("id").Where("uuid = ?", request.UserUuid))
+	}
+	if request.GroupUuid != "" {
+		dbClient = dbClient.Where("group_id IN (?)", database.DB.Unscoped().Model(&models.Group{}).Select("id").Where("uuid = ?", request.GroupUuid))
+	}
+	if request.GatewayUuid != "" {
+		dbClient = dbClient.Where("vpn_gateway_id IN (?)", database.DB.Unscoped().Model(&models.VpnGateway{}).Select("id").Where("uuid = ?", request.GatewayUuid))
+	}
+	if request.ClientUuid != "" {
+		dbClient = dbClient.Where("client_id IN (?)", database.DB.Unscoped().Model(&models.Client{}).Select("id").Where("uuid = ?", request.ClientUuid))
+	}
+	if !request.From.IsZero() {
+		dbClient = dbClient.Where("timestamp >= ?", request.From)
+	}
+	if !request.To.IsZero() {
+		dbClient = dbClient.Where("timestamp <= ?", request.To)
+	}
+
+	if err := dbClient.Count(&response.Total).Error; err != nil {
+		return response, err
+	}
+
+	err := dbClient.Preload("User", unscoped).
+		Preload("Group", unscoped).
+		Preload("VpnGateway", unscoped).
+		Preload("Client", unscoped).
+		Order("timestamp DESC").
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Find(&response.AuditTrails).Error
+	if err != nil {
+		return response, err
+	}
+
+	response.Page = page
+	response.PageSize = pageSize
+	return response, nil
+}
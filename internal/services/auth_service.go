@@ -1,14 +1,9 @@
 package services
 
 import (
-	"context"
 	"crypto/sha256"
-	"encoding/json"
 	"errors"
 	"fmt"
-	"io"
-	"net/http"
-	"net/url"
 	"time"
 
 	"github.com/golang-jwt/jwt"
@@ -19,8 +14,6 @@ import (
 	"github.com/leetsecure/qryptic-controller/internal/utils/auth"
 	"github.com/leetsecure/qryptic-controller/internal/utils/logger"
 	"golang.org/x/oauth2"
-	"golang.org/x/oauth2/google"
-	"google.golang.org/api/idtoken"
 )
 
 func UserLogin(emailID string, password string) (string, error) {
@@ -38,6 +31,9 @@ func UserLogin(emailID string, password string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	if !user.IsActive {
+		return "", errors.New("user is deactivated")
+	}
 	userRole := user.Role
 	userUuid := user.UUID
 	userPasswordHash := user.PasswordHash
@@ -53,22 +49,49 @@ func UserLogin(emailID string, password string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	recordAuditTrail(userUuid, models.AuditTrail{
+		Action:      models.AuditActionLogin,
+		Description: fmt.Sprintf("user %s logged in with password", user.Email),
+		UserID:      &user.ID,
+	})
 	return userToken, nil
 }
 
-func UserSSOLogin(emailID string) (string, error) {
+// UserSSOLogin issues the token of the user authenticated by a provider, the name given by the
+// provider replaces the one of the user when set and the groups mapped by the sso config are synced.
+// Unknown users are provisioned when the sso config allows it.
+func UserSSOLogin(ssoIdentity models.SSOIdentity) (string, error) {
 	log := logger.Default()
 	if !config.AllowSSOLogin {
 		return "", errors.New("login using sso not allowed")
 	}
-	exists := ifUserEmailAlreadyPresent(emailID)
-	if !exists {
-		log.Infof("User with email id %s is not present", emailID)
-		return "", errors.New("email id not present")
+	// an unverified email could be set by anyone at the provider and take over the account using it
+	if !ssoIdentity.EmailVerified {
+		log.Infof("User with email id %s could not be signed in through sso | error : email not verified", ssoIdentity.Email)
+		return "", errors.New("email not verified by the sso provider")
 	}
 	var user models.User
-	err := database.DB.Where("email = ?", emailID).First(&user).Error
+	var err error
+	exists := ifUserEmailAlreadyPresent(ssoIdentity.Email)
+	if exists {
+		err = database.DB.Where("email = ?", ssoIdentity.Email).First(&user).Error
+	} else {
+		user, err = provisionSSOUser(ssoIdentity)
+	}
 	if err != nil {
+		log.Infof("User with email id %s could not be signed in through sso | error : %s", ssoIdentity.Email, err)
+		return "", err
+	}
+	if !user.IsActive {
+		return "", errors.New("user is deactivated")
+	}
+	if ssoIdentity.Name != "" && ssoIdentity.Name != user.Name {
+		if err := database.DB.Model(&user).Update("name", ssoIdentity.Name).Error; err != nil {
+			return "", err
+		}
+	}
+	if err := syncSSOGroupMembers(user, ssoIdentity); err != nil {
+		log.Errorf("error in syncing the groups of user %s from sso | error : %s", user.Email, err)
 		return "", err
 	}
 	userRole := user.Role
@@ -78,6 +101,11 @@ func UserSSOLogin(emailID string) (string, error) {
 	if err != nil {
 		return "", err
 	}
+	recordAuditTrail(userUuid, models.AuditTrail{
+		Action:      models.AuditActionSSOLogin,
+		Description: fmt.Sprintf("user %s logged in with sso", user.Email),
+		UserID:      &user.ID,
+	})
 	return userToken, nil
 }
 
@@ -85,47 +113,45 @@ func AuthProviderValidate(provider string) error {
 	if !config.AllowSSOLogin {
 		return errors.New("sso login not allowed")
 	}
-	if provider != "google" {
+	ssoConfigs, err := enabledSSOConfigs(provider)
+	if err != nil {
+		return err
+	}
+	if len(ssoConfigs) == 0 {
 		return errors.New("sso provider not allowed")
 	}
 	return nil
 }
 
-func InitiateGoogleSsoAuth(clientId, platform, codeChallenge, redirectUri, codeChallengeMethod string) (string, error) {
+// InitiateSsoAuth returns the authorization url of the provider for an app doing PKCE itself. The app
+// may pick the sso config of its platform through its client id.
+func InitiateSsoAuth(provider, clientId, platform, codeChallenge, redirectUri, codeChallengeMethod string) (string, error) {
 	log := logger.Default()
-	googleOauth2Config := &oauth2.Config{
-		ClientID:     config.GoogleClientID,
-		ClientSecret: config.GoogleClientSecret,
-		Scopes:       []string{"openid", "profile", "email"},
-		Endpoint:     google.Endpoint,
-	}
-
-	if clientId != "" {
-		googleOauth2Config = &oauth2.Config{
-			ClientID: clientId,
-			Scopes:   []string{"openid", "profile", "email"},
-			Endpoint: google.Endpoint,
-		}
+	ssoProvider, err := getSSOProvider(provider, platform, clientId)
+	if err != nil {
+		return "", err
 	}
 
-	stateJWT, err := generateStateJWT(codeChallenge, redirectUri)
+	stateJWT, err := generateStateJWT(codeChallenge, redirectUri, ssoProvider.ssoConfig.ClientID)
 	if err != nil {
 		log.Error(err)
 		return "", errors.New("failed to generate state jwt")
 	}
 
-	googleOauth2Config.RedirectURL = redirectUri
-	authURL := googleOauth2Config.AuthCodeURL(stateJWT, oauth2.AccessTypeOffline) + "&code_challenge=" + codeChallenge + "&code_challenge_method=" + codeChallengeMethod
+	authURL := ssoProvider.authCodeURL(stateJWT, redirectUri,
+		oauth2.SetAuthURLParam("code_challenge", codeChallenge),
+		oauth2.SetAuthURLParam("code_challenge_method", codeChallengeMethod))
 
 	return authURL, nil
 }
 
-func generateStateJWT(codeChallenge string, redirectUrl string) (string, error) {
+func generateStateJWT(codeChallenge, redirectUrl, clientId string) (string, error) {
 	state := uuid.New().String() // Generate a random state string for CSRF protection
 	timeNow := time.Now()
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
 		"codeChallenge": codeChallenge,
 		"redirectUrl":   redirectUrl,
+		"clientId":      clientId,
 		"state":         state,
 		"exp":           timeNow.Add(config.SSOStateJwtTokenTimeout).Unix(), // Expiration time
 		"iat":           timeNow.Unix(),                                     // Issued at
@@ -133,7 +159,8 @@ func generateStateJWT(codeChallenge string, redirectUrl string) (string, error)
 	return token.SignedString([]byte(config.UserAuthSSOJwtSecretKey))
 }
 
-func ValidateStateJWT(stateJWT string) (string, string, error) {
+// ValidateStateJWT returns the code challenge, redirect url and client id carried by the state.
+func ValidateStateJWT(stateJWT string) (string, string, string, error) {
 	log := logger.Default()
 	// Decode and validate the JWT
 	token, err := jwt.Parse(stateJWT, func(token *jwt.Token) (interface{}, error) {
@@ -144,27 +171,29 @@ func ValidateStateJWT(stateJWT string) (string, string, error) {
 	})
 
 	if err != nil {
-		return "", "", err
+		return "", "", "", err
 	}
 
 	claims, ok := token.Claims.(jwt.MapClaims)
 	if !ok || !token.Valid {
-		return "", "", errors.New("invalid state JWT")
+		return "", "", "", errors.New("invalid state JWT")
 	}
 	codeChallenge, ok := claims["codeChallenge"].(string)
 	if !ok {
 		log.Info("missing code_challenge in state")
-		return "", "", errors.New("missing code_challenge in state")
+		return "", "", "", errors.New("missing code_challenge in state")
 	}
 	log.Info(codeChallenge)
 
 	redirectUrl, ok := claims["redirectUrl"].(string)
 	if !ok {
 		log.Info("missing redirectUrl in state")
-		return "", "", errors.New("missing redirectUrl in state")
+		return "", "", "", errors.New("missing redirectUrl in state")
 	}
 	log.Info(redirectUrl)
-	return codeChallenge, redirectUrl, nil
+	// states issued before the client id was carried fall back to the config of the platform
+	clientId, _ := claims["clientId"].(string)
+	return codeChallenge, redirectUrl, clientId, nil
 }
 
 // Verify that the code verifier matches the code challenge
@@ -176,132 +205,47 @@ func VerifyCodeVerifier(codeVerifier, codeChallenge string) bool {
 	return codeChallenge == codeChallengeComputed
 }
 
-// Exchange authorization code for tokens from Google
-func ExchangeCodeForTokens(code, codeVerifier, redirectUrl string) (*models.GoogleTokenResponse, error) {
-	log := logger.Default()
-	data := url.Values{}
-	data.Set("code", code)
-	data.Set("client_id", config.GoogleClientID)
-	data.Set("client_secret", config.GoogleClientSecret)
-	data.Set("redirect_uri", redirectUrl) // Replace with your redirect URI
-	data.Set("grant_type", "authorization_code")
-	data.Set("code_verifier", codeVerifier)
-	data.Set("scope", "https://www.googleapis.com/auth/userinfo.email https://www.googleapis.com/auth/userinfo.profile")
-
-	log.Info(data)
-
-	// Make the POST request to Google's token endpoint
-	resp, err := http.PostForm("https://oauth2.googleapis.com/token", data)
+// ExchangeCodeForIdentity redeems the authorization code of the PKCE flow and returns the identity of the user
+func ExchangeCodeForIdentity(provider, clientId, code, codeVerifier, redirectUrl string) (models.SSOIdentity, error) {
+	ssoProvider, err := getSSOProvider(provider, "", clientId)
 	if err != nil {
-		return nil, fmt.Errorf("failed to exchange code for tokens: %w", err)
+		return models.SSOIdentity{}, err
 	}
-	log.Info(resp.StatusCode)
-	defer resp.Body.Close()
-
-	// Check for a successful response
-	if resp.StatusCode != http.StatusOK {
-		return nil, fmt.Errorf("failed to exchange code for tokens: %s", resp.Status)
-	}
-
-	body, _ := io.ReadAll(resp.Body)
-	var tokenResponse models.GoogleTokenResponse
-	if err := json.Unmarshal(body, &tokenResponse); err != nil {
-		return nil, err
-	}
-	log.Info("tokenResponse: ", tokenResponse.AccessToken, tokenResponse.IDToken, tokenResponse.RefreshToken)
-	return &tokenResponse, nil
+	return ssoProvider.exchangeForIdentity(code, redirectUrl, codeVerifier)
 }
 
-// Function to get user information (email, name, etc.) from Google
-func GetUserInfoFromGoogle(accessToken string) (models.UserInfoResponse, error) {
+// VerifySSOToken validates an id token obtained by an app from the provider and returns the identity of the user
+func VerifySSOToken(provider, token string) (models.SSOIdentity, error) {
 	log := logger.Default()
-	url := "https://www.googleapis.com/oauth2/v2/userinfo"
-	req, err := http.NewRequest("GET", url, nil)
+	ssoProvider, err := getSSOProvider(provider, "", "")
 	if err != nil {
-		return models.UserInfoResponse{}, err
+		return models.SSOIdentity{}, err
 	}
-
-	verifyAccessToken(accessToken)
-
-	// Set Authorization header with the access token
-	req.Header.Set("Authorization", "Bearer "+accessToken)
-
-	// Send the request
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	claims, err := ssoProvider.verifyIDToken(token)
 	if err != nil {
-		return models.UserInfoResponse{}, err
-	}
-	log.Info(resp.StatusCode)
-	defer resp.Body.Close()
-
-	// Decode the response to extract user info (email, name, etc.)
-	var userInfoResponse models.UserInfoResponse
-	err = json.NewDecoder(resp.Body).Decode(&userInfoResponse)
-	if err != nil {
-		return models.UserInfoResponse{}, err
-	}
-	log.Info(userInfoResponse.Email)
-	return userInfoResponse, nil
-}
-
-func verifyAccessToken(accessToken string) (bool, error) {
-	log := logger.Default()
-	url := fmt.Sprintf("https://www.googleapis.com/oauth2/v3/tokeninfo?access_token=%s", accessToken)
-	resp, err := http.Get(url)
-	if err != nil {
-		return false, err
+		log.Error(err)
+		return models.SSOIdentity{}, err
 	}
-	defer resp.Body.Close()
-
-	// If the response status is 200 OK, the token is valid
-	if resp.StatusCode == http.StatusOK {
-		log.Info("token valid")
-		return true, nil
-	}
-	log.Info("token invalid")
-	// If we get a 401 or any other error, the token is invalid or expired
-	return false, fmt.Errorf("invalid or expired token")
+	return ssoProvider.identityFromClaims(claims)
 }
 
-func VerifyGoogleSSOToken(token string) (string, error) {
+func WebSSOLoginInitiate(provider, code_challenge string) (string, error) {
 	log := logger.Default()
-	tokenValidationResp, err := idtoken.Validate(context.Background(), token, config.GoogleClientID)
-
+	ssoProvider, err := getSSOProvider(provider, WebsiteSSOPlatform, "")
 	if err != nil {
-		log.Error(err)
 		return "", err
 	}
-	log.Infof("audience:%s \nclaims:%s \nexpires:%s \n issuer: %s", tokenValidationResp.Audience, tokenValidationResp.Claims, tokenValidationResp.Expires, tokenValidationResp.Issuer)
-
-	if tokenValidationResp.Expires < time.Now().Unix() {
-		log.Info("idtoken invalid")
-		return "", errors.New("id token expired")
-	}
-
-	return tokenValidationResp.Claims["email"].(string), nil
-}
-
-func WebGoogleLoginInitiate(code_challenge string) (string, error) {
-	log := logger.Default()
 	oauthState := uuid.NewString()
-	var googleOauth2Config = &oauth2.Config{
-		ClientID:     config.GoogleClientID,
-		ClientSecret: config.GoogleClientSecret,
-		Scopes:       []string{"openid", "profile", "email"},
-		Endpoint:     google.Endpoint,
-		RedirectURL:  fmt.Sprintf(config.SSOCallbackTemplate, config.ControllerDomain, "google"),
-	}
-	authURL := googleOauth2Config.AuthCodeURL(oauthState, oauth2.AccessTypeOffline, oauth2.ApprovalForce)
+	authURL := ssoProvider.authCodeURL(oauthState, fmt.Sprintf(config.SSOCallbackTemplate, config.ControllerDomain, provider))
 	log.Info(authURL)
 	auth := models.Auth{
 		UUID:          uuid.NewString(),
-		Provider:      "google",
+		Provider:      provider,
 		State:         oauthState,
 		CodeChallenge: code_challenge,
 		ExpiryTime:    time.Now().Add(2 * time.Minute),
 	}
-	err := database.DB.Save(&auth).Error
+	err = database.DB.Save(&auth).Error
 	if err != nil {
 		log.Error(err)
 		return "", err
@@ -309,33 +253,14 @@ func WebGoogleLoginInitiate(code_challenge string) (string, error) {
 	return authURL, nil
 }
 
-func WebGoogleLoginCallback(state, code string) error {
+func WebSSOLoginCallback(provider, state, code string) error {
 	log := logger.Default()
-	var googleOauth2Config = &oauth2.Config{
-		ClientID:     config.GoogleClientID,
-		ClientSecret: config.GoogleClientSecret,
-		Scopes:       []string{"openid", "profile", "email"},
-		Endpoint:     google.Endpoint,
-		RedirectURL:  fmt.Sprintf(config.SSOCallbackTemplate, config.ControllerDomain, "google"),
-	}
-	token, err := googleOauth2Config.Exchange(context.Background(), code)
-	if err != nil {
-		log.Infof("code exchange wrong: %s", err.Error())
-		return err
-	}
-
 	var auth models.Auth
-	err = database.DB.Where("state = ?", state).First(&auth).Error
+	err := database.DB.Where("state = ? AND provider = ?", state, provider).First(&auth).Error
 	if err != nil {
 		log.Errorf("error in fetching record of state : %s from auth | error : %s", state, err)
 		return err
 	}
-
-	userInfoResponse, err := GetUserInfoFromGoogle(token.AccessToken)
-	if err != nil {
-		log.Errorf("error in fetching user info from google | error : %s", err)
-		return err
-	}
 	timeNow := time.Now()
 	timeExpiry := auth.ExpiryTime
 
@@ -344,8 +269,24 @@ func WebGoogleLoginCallback(state, code string) error {
 		return errors.New("expired state")
 	}
 
+	ssoProvider, err := getSSOProvider(provider, WebsiteSSOPlatform, "")
+	if err != nil {
+		return err
+	}
+	ssoIdentity, err := ssoProvider.exchangeForIdentity(code, fmt.Sprintf(config.SSOCallbackTemplate, config.ControllerDomain, provider), "")
+	if err != nil {
+		log.Errorf("error in fetching user info from %s | error : %s", provider, err)
+		return err
+	}
+
 	auth.Authenticated = true
-	auth.Email = userInfoResponse.Email
+	auth.Email = ssoIdentity.Email
+	auth.Name = ssoIdentity.Name
+	auth.Groups = ssoIdentity.Groups
+	auth.GroupsClaimed = ssoIdentity.GroupsClaimed
+	auth.HostedDomain = ssoIdentity.HostedDomain
+	auth.SSOConfigID = ssoIdentity.SSOConfigID
+	auth.EmailVerified = ssoIdentity.EmailVerified
 	err = database.DB.Save(&auth).Error
 	if err != nil {
 		log.Errorf("error in saving auth details for state : %s in auth | error : %s", state, err)
@@ -354,7 +295,7 @@ func WebGoogleLoginCallback(state, code string) error {
 	return nil
 }
 
-func WebGoogleLoginToken(code_verifier, code_challenge string) (bool, string, error) {
+func WebSSOLoginToken(code_verifier, code_challenge string) (bool, string, error) {
 	log := logger.Default()
 	isVerified := VerifyCodeVerifier(code_verifier, code_challenge)
 	if !isVerified {
@@ -379,7 +320,15 @@ func WebGoogleLoginToken(code_verifier, code_challenge string) (bool, string, er
 	if !auth.Authenticated {
 		return false, "", errors.New("unauthenticated")
 	}
-	authToken, err := UserSSOLogin(auth.Email)
+	authToken, err := UserSSOLogin(models.SSOIdentity{
+		Email:         auth.Email,
+		Name:          auth.Name,
+		Groups:        auth.Groups,
+		GroupsClaimed: auth.GroupsClaimed,
+		HostedDomain:  auth.HostedDomain,
+		SSOConfigID:   auth.SSOConfigID,
+		EmailVerified: auth.EmailVerified,
+	})
 	if err != nil {
 		log.Error(err)
 		return true, "", err
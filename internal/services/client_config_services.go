@@ -0,0 +1,52 @@
+package services
+
+import (
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/leetsecure/qryptic-controller/internal/models"
+	"github.com/leetsecure/qryptic-controller/internal/utils/wireguard"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+const clientConfigQRCodeSize = 512
+
+// RenderWGClientConfig renders the client config as a wg-quick config file.
+func RenderWGClientConfig(wgClientConfig models.WGClientConfig) string {
+	return wireguard.CreateClientConfig(models.VpnClientConfig{
+		ClientAddress:        clientConfigAddress(wgClientConfig.WGClientInterfaceConfig),
+		ClientPrivateKey:     wgClientConfig.WGClientInterfaceConfig.ClientPrivateKey,
+		ClientDNS:            wgClientConfig.WGClientInterfaceConfig.DnsServer,
+		VpnGatewayPublicKey:  wgClientConfig.WGClientPeerConfig.ServerPublicKey,
+		VpnGatewayEndpoint:   clientConfigEndpoint(wgClientConfig.WGClientPeerConfig),
+		VpnGatewayAllowedIPs: strings.Join(wgClientConfig.WGClientPeerConfig.AllowedIPs, ", "),
+		PresharedKey:         wgClientConfig.WGClientPeerConfig.PresharedKey,
+		PersistentKeepalive:  wgClientConfig.WGClientPeerConfig.PersistentKeepalive,
+	})
+}
+
+// RenderWGClientConfigQRCode renders the wg-quick config file as a png qr code for the mobile apps.
+func RenderWGClientConfigQRCode(wgClientConfig models.WGClientConfig) ([]byte, error) {
+	return qrcode.Encode(RenderWGClientConfig(wgClientConfig), qrcode.Medium, clientConfigQRCodeSize)
+}
+
+// clientConfigEndpoint prefers the domain of the gateway and falls back to its ip address,
+// which is stored with its prefix length.
+func clientConfigEndpoint(wgClientPeerConfig models.WGClientPeerConfig) string {
+	host := wgClientPeerConfig.VpnGatewayDomain
+	if host == "" {
+		host = wgClientPeerConfig.VpnGatewayIP
+		if ip, _, err := net.ParseCIDR(host); err == nil {
+			host = ip.String()
+		}
+	}
+	return net.JoinHostPort(host, strconv.Itoa(wgClientPeerConfig.VpnGatewayPort))
+}
+
+func clientConfigAddress(wgClientInterfaceConfig models.WGClientInterfaceConfig) string {
+	if wgClientInterfaceConfig.IPv6Address == "" {
+		return wgClientInterfaceConfig.AllowedIpAddress
+	}
+	return wgClientInterfaceConfig.AllowedIpAddress + ", " + wgClientInterfaceConfig.IPv6Address
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/leetsecure/qryptic-controller/internal/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrClientLimitReached is returned when a client is requested over the client caps of a gateway.
+var ErrClientLimitReached = errors.New("client limit reached")
+
+// enforceClientLimits makes room for one more client of the user on the gateway inside the transaction
+// creating it and returns the clients it revoked. The gateway row is locked so that concurrent requests
+// count each other's clients. Only clients of the user are ever revoked, a request over the gateway cap
+// that revoking them cannot satisfy is rejected whatever the policy.
+func enforceClientLimits(tx *gorm.DB, vpnGateway models.VpnGateway, user models.User) ([]models.Client, error) {
+	if vpnGateway.MaxClients == 0 && vpnGateway.MaxClientsPerUser == 0 {
+		return nil, nil
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").Where("id = ?", vpnGateway.ID).First(&models.VpnGateway{}).Error; err != nil {
+		return nil, err
+	}
+
+	currentTime := time.Now()
+	var userClients []models.Client
+	err := tx.Where("vpn_gateway_id = ? AND user_id = ? AND is_active = ? AND expiry_time > ?", vpnGateway.ID, user.ID, true, currentTime).
+		Order("created_at, id").Find(&userClients).Error
+	if err != nil {
+		return nil, err
+	}
+	var gatewayClientCount int64
+	err = tx.Model(&models.Client{}).
+		Where("vpn_gateway_id = ? AND is_active = ? AND expiry_time > ?", vpnGateway.ID, true, currentTime).
+		Count(&gatewayClientCount).Error
+	if err != nil {
+		return nil, err
+	}
+
+	// number of clients to revoke for the new one to fit under both caps
+	excess := 0
+	var reason string
+	if vpnGateway.MaxClientsPerUser > 0 && len(userClients) >= vpnGateway.MaxClientsPerUser {
+		excess = len(userClients) - vpnGateway.MaxClientsPerUser + 1
+		reason = fmt.Sprintf("user has %d active clients on the gateway, the limit is %d", len(userClients), vpnGateway.MaxClientsPerUser)
+	}
+	if vpnGateway.MaxClients > 0 && int(gatewayClientCount) >= vpnGateway.MaxClients {
+		if gatewayExcess := int(gatewayClientCount) - vpnGateway.MaxClients + 1; gatewayExcess > excess {
+			excess = gatewayExcess
+			reason = fmt.Sprintf("gateway has %d active clients, the limit is %d", gatewayClientCount, vpnGateway.MaxClients)
+		}
+	}
+	if excess == 0 {
+		return nil, nil
+	}
+	if vpnGateway.ClientLimitPolicy != models.ClientLimitRevokeOldest || excess > len(userClients) {
+		return nil, fmt.Errorf("%w: %s, delete a client first", ErrClientLimitReached, reason)
+	}
+
+	return revokeClients(tx, userClients[:excess])
+}
+
+// recordClientLimitAuditTrails records the rejection of a client request or the clients revoked to make
+// room for it.
+func recordClientLimitAuditTrails(user models.User, vpnGateway models.VpnGateway, revokedClients []models.Client, limitErr error) {
+	if limitErr != nil {
+		recordAuditTrail(user.UUID, models.AuditTrail{
+			Action:       models.AuditActionClientLimitReject,
+			Description:  fmt.Sprintf("client of user %s rejected on vpn gateway %s: %s", user.Email, vpnGateway.Name, limitErr.Error()),
+			UserID:       &user.ID,
+			VpnGatewayID: &vpnGateway.ID,
+		})
+		return
+	}
+	for _, client := range revokedClients {
+		recordAuditTrail(user.UUID, models.AuditTrail{
+			Action:       models.AuditActionClientLimitRevoke,
+			Description:  fmt.Sprintf("client %s of user %s revoked on vpn gateway %s to stay within the client limits", client.UUID, user.Email, vpnGateway.Name),
+			UserID:       &user.ID,
+			VpnGatewayID: &vpnGateway.ID,
+			ClientID:     &client.ID,
+		})
+	}
+}
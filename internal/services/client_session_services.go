@@ -0,0 +1,140 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/leetsecure/qryptic-controller/internal/config"
+	"github.com/leetsecure/qryptic-controller/internal/database"
+	"github.com/leetsecure/qryptic-controller/internal/models"
+	"gorm.io/gorm"
+)
+
+// RecordVpnGatewayPeerStats maps the peer stats pushed by a gateway to its active clients by public key.
+func RecordVpnGatewayPeerStats(vpnGatewayUuid string, peerStatsRequest models.VpnGatewayPeerStatsRequest) (models.VpnGatewayPeerStatsResponse, error) {
+	var response models.VpnGatewayPeerStatsResponse
+	vpnGateway, exists, err := getVpnGatewayFromUuid(vpnGatewayUuid)
+	if err != nil {
+		return response, err
+	}
+	if !exists {
+		return response, errors.New("vpn gateway with given uuid not present")
+	}
+	if len(peerStatsRequest.Peers) == 0 {
+		return response, nil
+	}
+
+	publicKeys := make([]string, 0, len(peerStatsRequest.Peers))
+	for _, peerStats := range peerStatsRequest.Peers {
+		publicKeys = append(publicKeys, peerStats.ClientPublicKey)
+	}
+	var clients []models.Client
+	err = database.DB.Where("vpn_gateway_id = ? AND is_active = ? AND client_public_key IN ?", vpnGateway.ID, true, publicKeys).Find(&clients).Error
+	if err != nil {
+		return response, err
+	}
+	clientsByPublicKey := make(map[string]*models.Client, len(clients))
+	for i := range clients {
+		clientsByPublicKey[clients[i].ClientPublicKey] = &clients[i]
+	}
+
+	timeNow := time.Now()
+	var seenDeviceIDs []uint
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		for _, peerStats := range peerStatsRequest.Peers {
+			client, exists := clientsByPublicKey[peerStats.ClientPublicKey]
+			if !exists {
+				response.Unknown++
+				continue
+			}
+
+			used := peerStats.RxBytes != client.RxBytes || peerStats.TxBytes != client.TxBytes
+			if peerStats.LatestHandshake > 0 {
+				lastHandshakeAt := time.Unix(peerStats.LatestHandshake, 0)
+				if client.LastHandshakeAt == nil || lastHandshakeAt.After(*client.LastHandshakeAt) {
+					client.LastHandshakeAt = &lastHandshakeAt
+					used = true
+					if client.DeviceID != nil {
+						seenDeviceIDs = append(seenDeviceIDs, *client.DeviceID)
+					}
+				}
+			}
+			if used {
+				client.LastUsedAt = &timeNow
+			}
+			client.RxBytes = peerStats.RxBytes
+			client.TxBytes = peerStats.TxBytes
+			client.Endpoint = peerStats.Endpoint
+
+			err := tx.Model(client).Select("LastHandshakeAt", "LastUsedAt", "RxBytes", "TxBytes", "Endpoint").Updates(client).Error
+			if err != nil {
+				return err
+			}
+			response.Updated++
+		}
+		if len(seenDeviceIDs) > 0 {
+			return tx.Model(&models.Device{}).Where("id IN ?", seenDeviceIDs).Update("last_seen_at", timeNow).Error
+		}
+		return nil
+	})
+	return response, err
+}
+
+func ListActiveSessionsOfVpnGateway(vpnGatewayUuid string) ([]models.ActiveSession, error) {
+	vpnGateway, exists, err := getVpnGatewayFromUuid(vpnGatewayUuid)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New("vpn gateway with given uuid not present")
+	}
+	return listActiveSessions(database.DB.Where("vpn_gateway_id = ?", vpnGateway.ID))
+}
+
+func ListActiveSessionsOfUser(userUuid string) ([]models.ActiveSession, error) {
+	user, exists, err := getUserFromUuid(userUuid)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New("user with given uuid not present")
+	}
+	return listActiveSessions(database.DB.Where("user_id = ?", user.ID))
+}
+
+// listActiveSessions returns the active clients matching dbClient whose last handshake falls in the
+// ActiveSessionHandshakeWindow, most recent first.
+func listActiveSessions(dbClient *gorm.DB) ([]models.ActiveSession, error) {
+	var clients []models.Client
+	err := dbClient.Preload("User").
+		Preload("VpnGateway").
+		Where("is_active = ? AND last_handshake_at >= ?", true, time.Now().Add(-config.ActiveSessionHandshakeWindow)).
+		Order("last_handshake_at DESC").
+		Find(&clients).Error
+	if err != nil {
+		return nil, err
+	}
+
+	activeSessions := make([]models.ActiveSession, 0, len(clients))
+	for _, client := range clients {
+		activeSession := models.ActiveSession{
+			ClientUuid:      client.UUID,
+			AllocatedIP:     client.AllocatedIP,
+			Endpoint:        client.Endpoint,
+			LastHandshakeAt: *client.LastHandshakeAt,
+			RxBytes:         client.RxBytes,
+			TxBytes:         client.TxBytes,
+		}
+		if client.User != nil {
+			activeSession.UserUuid = client.User.UUID
+			activeSession.UserName = client.User.Name
+			activeSession.UserEmail = client.User.Email
+		}
+		if client.VpnGateway != nil {
+			activeSession.VpnGatewayUuid = client.VpnGateway.UUID
+			activeSession.VpnGatewayName = client.VpnGateway.Name
+		}
+		activeSessions = append(activeSessions, activeSession)
+	}
+	return activeSessions, nil
+}
@@ -0,0 +1,157 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leetsecure/qryptic-controller/internal/database"
+	"github.com/leetsecure/qryptic-controller/internal/models"
+	"gorm.io/gorm"
+)
+
+func RegisterDevice(userUuid string, request models.DeviceRegisterRequest) (models.Device, error) {
+	var device models.Device
+	user, exists, err := getUserFromUuid(userUuid)
+	if err != nil {
+		return device, err
+	}
+	if !exists {
+		return device, errors.New("user with given uuid not present")
+	}
+
+	// a key shared by two devices would make their peers collide on the gateways
+	var count int64
+	if err := database.DB.Unscoped().Model(&models.Device{}).Where("public_key = ?", request.PublicKey).Count(&count).Error; err != nil {
+		return device, err
+	}
+	if count > 0 {
+		return device, errors.New("public key already registered, generate a new key pair on the device")
+	}
+
+	device = models.Device{
+		UUID:      uuid.NewString(),
+		UserID:    user.ID,
+		Name:      request.Name,
+		OS:        request.OS,
+		PublicKey: request.PublicKey,
+	}
+	if err := database.DB.Create(&device).Error; err != nil {
+		return device, err
+	}
+
+	recordAuditTrail(userUuid, models.AuditTrail{
+		Action:      models.AuditActionDeviceRegister,
+		Description: fmt.Sprintf("device %s (%s) registered by user %s with public key %s", device.Name, device.OS, user.Email, device.PublicKey),
+		UserID:      &user.ID,
+	})
+	return device, nil
+}
+
+func ListDevicesOfUser(userUuid string) ([]models.Device, error) {
+	user, exists, err := getUserFromUuid(userUuid)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New("user with given uuid not present")
+	}
+	var devices []models.Device
+	if err := database.DB.Where("user_id = ?", user.ID).Order("id").Find(&devices).Error; err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+func ListDevices(userUuid string, includeRevoked bool) ([]models.Device, error) {
+	var devices []models.Device
+	dbClient := database.DB.Preload("User")
+	if userUuid != "" {
+		dbClient = dbClient.Where("user_id IN (?)", database.DB.Model(&models.User{}).Select("id").Where("uuid = ?", userUuid))
+	}
+	if !includeRevoked {
+		dbClient = dbClient.Where("revoked_at IS NULL")
+	}
+	if err := dbClient.Order("id").Find(&devices).Error; err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+// RevokeDevice stops issuing clients to the device and revokes its active clients. A user can only
+// revoke their own devices, ownerUuid is empty when an admin revokes the device.
+func RevokeDevice(actorUuid, ownerUuid, deviceUuid string) error {
+	var device models.Device
+	if err := database.DB.Preload("User").Where("uuid = ?", deviceUuid).First(&device).Error; err != nil {
+		return err
+	}
+	if ownerUuid != "" && (device.User == nil || device.User.UUID != ownerUuid) {
+		return errors.New("user doesn't have access to given device uuid")
+	}
+	if device.RevokedAt != nil {
+		return errors.New("device already revoked")
+	}
+
+	var clients []models.Client
+	if err := database.DB.Where("device_id = ? AND is_active = ?", device.ID, true).Find(&clients).Error; err != nil {
+		return err
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&device).Update("revoked_at", time.Now()).Error; err != nil {
+			return err
+		}
+		var err error
+		clients, err = revokeClients(tx, clients)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	notifyGatewayOperationsWorker()
+
+	recordAuditTrail(actorUuid, models.AuditTrail{
+		Action:      models.AuditActionDeviceRevoke,
+		Description: fmt.Sprintf("device %s of user %s revoked with its %d active clients", device.Name, device.User.Email, len(clients)),
+		UserID:      &device.UserID,
+	})
+	for _, client := range clients {
+		recordAuditTrail(actorUuid, models.AuditTrail{
+			Action:       models.AuditActionClientDelete,
+			Description:  fmt.Sprintf("client %s with ip %s deleted with device %s", client.UUID, client.AllocatedIP, device.Name),
+			UserID:       &client.UserID,
+			VpnGatewayID: &client.VpnGatewayID,
+			ClientID:     &client.ID,
+		})
+	}
+	return nil
+}
+
+// getDeviceOfUserForVpnGateway returns the device a new client of the user on the gateway is issued to.
+// A device holds a single key, so it can only have one active client per gateway.
+func getDeviceOfUserForVpnGateway(db *gorm.DB, user models.User, vpnGateway models.VpnGateway, deviceUuid string) (*models.Device, error) {
+	var device models.Device
+	err := db.Where("uuid = ? AND user_id = ?", deviceUuid, user.ID).First(&device).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("device with given uuid not registered by the user")
+	}
+	if err != nil {
+		return nil, err
+	}
+	if device.RevokedAt != nil {
+		return nil, errors.New("device is revoked")
+	}
+
+	var count int64
+	err = db.Model(&models.Client{}).
+		Where("device_id = ? AND vpn_gateway_id = ? AND is_active = ?", device.ID, vpnGateway.ID, true).
+		Count(&count).Error
+	if err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, errors.New("device already has an active client on this gateway, delete it first")
+	}
+	return &device, nil
+}
@@ -0,0 +1,93 @@
+package services
+
+import (
+	"errors"
+	"time"
+
+	"github.com/leetsecure/qryptic-controller/internal/config"
+	"github.com/leetsecure/qryptic-controller/internal/database"
+	"github.com/leetsecure/qryptic-controller/internal/externalcomms"
+	"github.com/leetsecure/qryptic-controller/internal/models"
+	"github.com/leetsecure/qryptic-controller/internal/utils/logger"
+	"gorm.io/gorm"
+)
+
+// ErrVpnGatewayDown is returned when a client is requested on a gateway marked down by the health prober.
+var ErrVpnGatewayDown = errors.New("vpn gateway is down")
+
+const gatewayHealthHistoryWindow = 24 * time.Hour
+
+// ProbeVpnGateways polls the health url of every gateway and records the result.
+func ProbeVpnGateways() error {
+	var vpnGateways []models.VpnGateway
+	if err := database.DB.Find(&vpnGateways).Error; err != nil {
+		return err
+	}
+
+	var errs error
+	for _, vpnGateway := range vpnGateways {
+		errs = errors.Join(errs, probeVpnGateway(vpnGateway))
+	}
+	return errs
+}
+
+// probeVpnGateway marks a gateway up on the first successful probe and down once
+// GatewayHealthFailureThreshold probes in a row have failed.
+func probeVpnGateway(vpnGateway models.VpnGateway) error {
+	log := logger.Default()
+
+	startTime := time.Now()
+	_, err := externalcomms.VpnGatewayHealthCheck(vpnGateway.Domain)
+	checkedAt := time.Now()
+
+	healthCheck := models.VpnGatewayHealthCheck{
+		VpnGatewayID: vpnGateway.ID,
+		Healthy:      err == nil,
+		LatencyMs:    checkedAt.Sub(startTime).Milliseconds(),
+		CheckedAt:    checkedAt,
+	}
+
+	previousStatus := vpnGateway.HealthStatus
+	vpnGateway.LastHealthCheckAt = &checkedAt
+	if err == nil {
+		vpnGateway.HealthStatus = models.GatewayHealthUp
+		vpnGateway.HealthLatencyMs = healthCheck.LatencyMs
+		vpnGateway.ConsecutiveHealthFailures = 0
+		vpnGateway.LastSeenAt = &checkedAt
+	} else {
+		healthCheck.Error = err.Error()
+		vpnGateway.ConsecutiveHealthFailures++
+		if vpnGateway.ConsecutiveHealthFailures >= config.GatewayHealthFailureThreshold {
+			vpnGateway.HealthStatus = models.GatewayHealthDown
+		}
+	}
+	healthCheck.Status = vpnGateway.HealthStatus
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&healthCheck).Error; err != nil {
+			return err
+		}
+		return tx.Model(&vpnGateway).Select("HealthStatus", "HealthLatencyMs", "ConsecutiveHealthFailures", "LastHealthCheckAt", "LastSeenAt").Updates(&vpnGateway).Error
+	})
+	if err != nil {
+		return err
+	}
+
+	if previousStatus != vpnGateway.HealthStatus {
+		log.Infof("vpn gateway %s health changed from %s to %s", vpnGateway.UUID, previousStatus, vpnGateway.HealthStatus)
+	}
+	return nil
+}
+
+// PruneVpnGatewayHealthChecks removes health checks older than the retention period.
+func PruneVpnGatewayHealthChecks() error {
+	cutoff := time.Now().Add(-config.GatewayHealthHistoryRetention)
+	return database.DB.Where("checked_at < ?", cutoff).Delete(&models.VpnGatewayHealthCheck{}).Error
+}
+
+// preloadVpnGatewayHealthHistory loads the health checks of the last day, newest first.
+func preloadVpnGatewayHealthHistory(dbClient *gorm.DB) *gorm.DB {
+	return dbClient.Preload("HealthChecks", func(db *gorm.DB) *gorm.DB {
+		return db.Where("checked_at >= ?", time.Now().Add(-gatewayHealthHistoryWindow)).Order("checked_at DESC")
+	})
+}
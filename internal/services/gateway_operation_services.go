@@ -0,0 +1,271 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leetsecure/qryptic-controller/internal/config"
+	"github.com/leetsecure/qryptic-controller/internal/database"
+	"github.com/leetsecure/qryptic-controller/internal/externalcomms"
+	"github.com/leetsecure/qryptic-controller/internal/models"
+	"github.com/leetsecure/qryptic-controller/internal/utils/auth"
+	"github.com/leetsecure/qryptic-controller/internal/utils/logger"
+	"gorm.io/gorm"
+)
+
+const (
+	gatewayOperationBaseBackoff = 5 * time.Second
+	gatewayOperationMaxBackoff  = 30 * time.Minute
+)
+
+var gatewayOperationsMutex sync.Mutex
+var gatewayOperationsWakeup = make(chan struct{}, 1)
+
+// GatewayOperationsWakeup signals the worker whenever new gateway operations are enqueued.
+func GatewayOperationsWakeup() <-chan struct{} {
+	return gatewayOperationsWakeup
+}
+
+func notifyGatewayOperationsWorker() {
+	select {
+	case gatewayOperationsWakeup <- struct{}{}:
+	default:
+	}
+}
+
+// enqueueGatewayOperation adds an operation to the outbox of the gateway. It should be called in the
+// same transaction as the change it reflects and followed by notifyGatewayOperationsWorker after commit.
+func enqueueGatewayOperation(db *gorm.DB, vpnGatewayID uint, operation models.GatewayOperationTypeEnum, wgServerPeerConfigs []models.WGServerPeerConfig) error {
+	gatewayOperation := models.GatewayOperation{
+		UUID:          uuid.NewString(),
+		VpnGatewayID:  vpnGatewayID,
+		Operation:     operation,
+		Peers:         wgServerPeerConfigs,
+		Status:        models.GatewayOperationPending,
+		NextAttemptAt: time.Now(),
+	}
+	return db.Create(&gatewayOperation).Error
+}
+
+// ProcessGatewayOperations delivers the pending operations of every gateway.
+func ProcessGatewayOperations() error {
+	gatewayOperationsMutex.Lock()
+	defer gatewayOperationsMutex.Unlock()
+
+	var vpnGatewayIDs []uint
+	err := database.DB.Model(&models.GatewayOperation{}).
+		Where("status = ?", models.GatewayOperationPending).
+		Distinct().
+		Pluck("vpn_gateway_id", &vpnGatewayIDs).Error
+	if err != nil {
+		return err
+	}
+
+	var errs error
+	for _, vpnGatewayID := range vpnGatewayIDs {
+		errs = errors.Join(errs, processVpnGatewayOperations(vpnGatewayID))
+	}
+	return errs
+}
+
+// processVpnGatewayOperations delivers the pending operations of a gateway in order and stops at the
+// first one that has to be retried, so that a later operation never overtakes an earlier one. A failed
+// operation blocks the gateway until an admin retries or dismisses it.
+func processVpnGatewayOperations(vpnGatewayID uint) error {
+	log := logger.Default()
+	var gatewayOperations []models.GatewayOperation
+	err := database.DB.Preload("VpnGateway", func(db *gorm.DB) *gorm.DB {
+		return db.Unscoped()
+	}).
+		Where("vpn_gateway_id = ? AND status IN ?", vpnGatewayID, []models.GatewayOperationStatusEnum{models.GatewayOperationPending, models.GatewayOperationFailed}).
+		Order("id").
+		Find(&gatewayOperations).Error
+	if err != nil {
+		return err
+	}
+
+	for _, gatewayOperation := range gatewayOperations {
+		if gatewayOperation.Status == models.GatewayOperationFailed {
+			log.Warnf("operations of vpn gateway %d held back by failed %s operation %s", vpnGatewayID, gatewayOperation.Operation, gatewayOperation.UUID)
+			return nil
+		}
+		timeNow := time.Now()
+		if gatewayOperation.NextAttemptAt.After(timeNow) {
+			return nil
+		}
+
+		gatewayOperation.Attempts++
+		if gatewayOperation.VpnGateway == nil {
+			err = errors.New("vpn gateway not present")
+		} else {
+			err = deliverGatewayOperation(*gatewayOperation.VpnGateway, gatewayOperation)
+		}
+
+		if err == nil {
+			gatewayOperation.Status = models.GatewayOperationSucceeded
+			gatewayOperation.LastError = ""
+			gatewayOperation.CompletedAt = &timeNow
+		} else {
+			log.Errorf("attempt %d of %s operation %s failed : %v", gatewayOperation.Attempts, gatewayOperation.Operation, gatewayOperation.UUID, err)
+			gatewayOperation.LastError = err.Error()
+			if gatewayOperation.Attempts >= config.GatewayOperationMaxAttempts {
+				gatewayOperation.Status = models.GatewayOperationFailed
+				gatewayOperation.CompletedAt = &timeNow
+			} else {
+				gatewayOperation.NextAttemptAt = timeNow.Add(gatewayOperationBackoff(gatewayOperation.Attempts))
+			}
+		}
+
+		if saveErr := database.DB.Save(&gatewayOperation).Error; saveErr != nil {
+			return saveErr
+		}
+		if gatewayOperation.Status != models.GatewayOperationSucceeded {
+			return nil
+		}
+	}
+	return nil
+}
+
+func gatewayOperationBackoff(attempts int) time.Duration {
+	backoff := gatewayOperationBaseBackoff
+	for i := 1; i < attempts && backoff < gatewayOperationMaxBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, gatewayOperationMaxBackoff)
+}
+
+func deliverGatewayOperation(vpnGateway models.VpnGateway, gatewayOperation models.GatewayOperation) error {
+	log := logger.Default()
+	authToken, err := auth.CreateVpnGatewayToken(vpnGateway.UUID, vpnGateway.JwtSecretKey)
+	if err != nil {
+		return err
+	}
+
+	var responseBody string
+	var responseStatusCode int
+	switch gatewayOperation.Operation {
+	case models.GatewayOperationAddPeers:
+		responseBody, responseStatusCode, err = externalcomms.AddNewPeerInVpnGateway(vpnGateway.Domain, authToken, gatewayOperation.Peers)
+	case models.GatewayOperationDeletePeers:
+		responseBody, responseStatusCode, err = externalcomms.DeletePeerInVpnGateway(vpnGateway.Domain, authToken, gatewayOperation.Peers)
+	case models.GatewayOperationUpdatePeers:
+		responseBody, responseStatusCode, err = externalcomms.UpdatePeersInVpnGateway(vpnGateway.Domain, authToken, gatewayOperation.Peers)
+	case models.GatewayOperationRestart:
+		responseBody, responseStatusCode, err = externalcomms.RestartVpnGateway(vpnGateway.Domain, authToken)
+	default:
+		return fmt.Errorf("unknown gateway operation : %s", gatewayOperation.Operation)
+	}
+	if err != nil {
+		return err
+	}
+	log.Infof("response body of %s request to vpn gateway %s : %s and status code is %d", gatewayOperation.Operation, vpnGateway.UUID, responseBody, responseStatusCode)
+	if responseStatusCode != http.StatusOK {
+		return fmt.Errorf("request not fulfilled, status code %d", responseStatusCode)
+	}
+	return nil
+}
+
+// PruneGatewayOperations removes succeeded and dismissed operations older than the retention period,
+// failed operations are kept until an admin handles them.
+func PruneGatewayOperations() error {
+	cutoff := time.Now().Add(-config.GatewayOperationRetention)
+	return database.DB.Unscoped().
+		Where("status IN ? AND completed_at < ?", []models.GatewayOperationStatusEnum{models.GatewayOperationSucceeded, models.GatewayOperationDismissed}, cutoff).
+		Delete(&models.GatewayOperation{}).Error
+}
+
+func ListGatewayOperations(vpnGatewayUuid, status string) ([]models.GatewayOperation, error) {
+	vpnGateway, exists, err := getVpnGatewayFromUuid(vpnGatewayUuid)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New("vpn gateway with given uuid not present")
+	}
+
+	var gatewayOperations []models.GatewayOperation
+	dbClient := database.DB.Where("vpn_gateway_id = ?", vpnGateway.ID)
+	if status != "" {
+		dbClient = dbClient.Where("status = ?", status)
+	}
+	if err := dbClient.Order("id DESC").Find(&gatewayOperations).Error; err != nil {
+		return nil, err
+	}
+	return gatewayOperations, nil
+}
+
+// RetryGatewayOperation puts a failed operation back in the queue with a fresh set of attempts.
+func RetryGatewayOperation(actorUuid, vpnGatewayUuid, gatewayOperationUuid string) error {
+	vpnGateway, exists, err := getVpnGatewayFromUuid(vpnGatewayUuid)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("vpn gateway with given uuid not present")
+	}
+
+	var gatewayOperation models.GatewayOperation
+	err = database.DB.Where("uuid = ? AND vpn_gateway_id = ?", gatewayOperationUuid, vpnGateway.ID).First(&gatewayOperation).Error
+	if err != nil {
+		return err
+	}
+	if gatewayOperation.Status != models.GatewayOperationFailed {
+		return errors.New("only failed operations can be retried")
+	}
+
+	gatewayOperation.Status = models.GatewayOperationPending
+	gatewayOperation.Attempts = 0
+	gatewayOperation.NextAttemptAt = time.Now()
+	gatewayOperation.CompletedAt = nil
+	if err := database.DB.Save(&gatewayOperation).Error; err != nil {
+		return err
+	}
+	notifyGatewayOperationsWorker()
+
+	recordAuditTrail(actorUuid, models.AuditTrail{
+		Action:       models.AuditActionGatewayOperationRetry,
+		Description:  fmt.Sprintf("%s operation %s on vpn gateway %s queued for retry", gatewayOperation.Operation, gatewayOperation.UUID, vpnGateway.Name),
+		VpnGatewayID: &vpnGateway.ID,
+	})
+	return nil
+}
+
+// DismissGatewayOperation gives up on a failed operation, which lets the later operations of the
+// gateway through. The drift left by the operation is repaired by the next reconciliation.
+func DismissGatewayOperation(actorUuid, vpnGatewayUuid, gatewayOperationUuid string) error {
+	vpnGateway, exists, err := getVpnGatewayFromUuid(vpnGatewayUuid)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("vpn gateway with given uuid not present")
+	}
+
+	var gatewayOperation models.GatewayOperation
+	err = database.DB.Where("uuid = ? AND vpn_gateway_id = ?", gatewayOperationUuid, vpnGateway.ID).First(&gatewayOperation).Error
+	if err != nil {
+		return err
+	}
+	if gatewayOperation.Status != models.GatewayOperationFailed {
+		return errors.New("only failed operations can be dismissed")
+	}
+
+	timeNow := time.Now()
+	gatewayOperation.Status = models.GatewayOperationDismissed
+	gatewayOperation.CompletedAt = &timeNow
+	if err := database.DB.Save(&gatewayOperation).Error; err != nil {
+		return err
+	}
+	notifyGatewayOperationsWorker()
+
+	recordAuditTrail(actorUuid, models.AuditTrail{
+		Action:       models.AuditActionGatewayOperationDismiss,
+		Description:  fmt.Sprintf("failed %s operation %s on vpn gateway %s dismissed", gatewayOperation.Operation, gatewayOperation.UUID, vpnGateway.Name),
+		VpnGatewayID: &vpnGateway.ID,
+	})
+	return nil
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leetsecure/qryptic-controller/internal/database"
+	"github.com/leetsecure/qryptic-controller/internal/externalcomms"
+	"github.com/leetsecure/qryptic-controller/internal/models"
+	"github.com/leetsecure/qryptic-controller/internal/utils/auth"
+	"github.com/leetsecure/qryptic-controller/internal/utils/logger"
+	"gorm.io/gorm"
+)
+
+// ReconcileVpnGateways compares the peers of every gateway with its active clients and corrects the drift.
+func ReconcileVpnGateways() error {
+	var vpnGateways []models.VpnGateway
+	if err := database.DB.Find(&vpnGateways).Error; err != nil {
+		return err
+	}
+
+	var errs error
+	for _, vpnGateway := range vpnGateways {
+		if _, err := reconcileVpnGateway("", vpnGateway); err != nil {
+			errs = errors.Join(errs, fmt.Errorf("vpn gateway %s : %w", vpnGateway.UUID, err))
+		}
+	}
+	return errs
+}
+
+// ReconcileVpnGateway runs the reconciliation of a single gateway immediately and returns its drift report.
+func ReconcileVpnGateway(actorUuid, vpnGatewayUuid string) (models.VpnGatewayDriftReport, error) {
+	vpnGateway, exists, err := getVpnGatewayFromUuid(vpnGatewayUuid)
+	if err != nil {
+		return models.VpnGatewayDriftReport{}, err
+	}
+	if !exists {
+		return models.VpnGatewayDriftReport{}, errors.New("vpn gateway with given uuid not present")
+	}
+	return reconcileVpnGateway(actorUuid, vpnGateway)
+}
+
+func GetVpnGatewayDriftReport(vpnGatewayUuid string) (models.VpnGatewayDriftReport, error) {
+	var driftReport models.VpnGatewayDriftReport
+	vpnGateway, exists, err := getVpnGatewayFromUuid(vpnGatewayUuid)
+	if err != nil {
+		return driftReport, err
+	}
+	if !exists {
+		return driftReport, errors.New("vpn gateway with given uuid not present")
+	}
+
+	err = database.DB.Where("vpn_gateway_id = ?", vpnGateway.ID).First(&driftReport).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return driftReport, errors.New("vpn gateway not reconciled yet")
+	}
+	return driftReport, err
+}
+
+// reconcileVpnGateway stores the drift report of the gateway and, unless operations are still pending
+// delivery or held back by a failed one, queues the operations that bring the gateway in sync.
+func reconcileVpnGateway(actorUuid string, vpnGateway models.VpnGateway) (models.VpnGatewayDriftReport, error) {
+	log := logger.Default()
+	driftReport := models.VpnGatewayDriftReport{
+		VpnGatewayID: vpnGateway.ID,
+		CheckedAt:    time.Now(),
+	}
+
+	// operations queued after this point change the desired state while the peers are listed
+	var lastGatewayOperationID uint
+	err := database.DB.Unscoped().Model(&models.GatewayOperation{}).
+		Where("vpn_gateway_id = ?", vpnGateway.ID).
+		Select("COALESCE(MAX(id), 0)").
+		Scan(&lastGatewayOperationID).Error
+	if err != nil {
+		return driftReport, err
+	}
+
+	authToken, err := auth.CreateVpnGatewayToken(vpnGateway.UUID, vpnGateway.JwtSecretKey)
+	if err != nil {
+		return driftReport, err
+	}
+	actualPeers, responseStatusCode, err := externalcomms.ListPeersInVpnGateway(vpnGateway.Domain, authToken)
+	if err == nil && responseStatusCode != http.StatusOK {
+		err = fmt.Errorf("request not fulfilled, status code %d", responseStatusCode)
+	}
+	if err != nil {
+		log.Errorf("error in listing peers of vpn gateway %s : %v", vpnGateway.UUID, err)
+		driftReport.Status = models.DriftStatusUnreachable
+		driftReport.Error = err.Error()
+		return driftReport, saveVpnGatewayDriftReport(&driftReport)
+	}
+
+	// the desired state is read once the peers are listed, so that it is never older than them
+	wgServerConfig, err := GetVpnGatewayWGConfig(vpnGateway.UUID)
+	if err != nil {
+		return driftReport, err
+	}
+	var recentGatewayOperations []models.GatewayOperation
+	err = database.DB.Unscoped().
+		Where("vpn_gateway_id = ? AND id > ?", vpnGateway.ID, lastGatewayOperationID).
+		Find(&recentGatewayOperations).Error
+	if err != nil {
+		return driftReport, err
+	}
+	// peers changed while the gateway was listed may be seen before or after the change, they are
+	// left to their operations
+	changedPublicKeys := map[string]bool{}
+	for _, gatewayOperation := range recentGatewayOperations {
+		for _, peer := range gatewayOperation.Peers {
+			changedPublicKeys[peer.ClientPublicKey] = true
+		}
+	}
+
+	driftReport.MissingPeers, driftReport.OutdatedPeers, driftReport.StalePeers = diffPeers(wgServerConfig.WGServerPeerConfigs, actualPeers, changedPublicKeys)
+	driftReport.Status = models.DriftStatusInSync
+	if len(driftReport.MissingPeers) == 0 && len(driftReport.OutdatedPeers) == 0 && len(driftReport.StalePeers) == 0 {
+		return driftReport, saveVpnGatewayDriftReport(&driftReport)
+	}
+	driftReport.Status = models.DriftStatusDrifted
+
+	// pending and failed operations are expected to differ from the gateway, correcting now would race them
+	err = database.DB.Model(&models.GatewayOperation{}).
+		Where("vpn_gateway_id = ? AND status IN ?", vpnGateway.ID, []models.GatewayOperationStatusEnum{models.GatewayOperationPending, models.GatewayOperationFailed}).
+		Count(&driftReport.PendingOperations).Error
+	if err != nil {
+		return driftReport, err
+	}
+	if driftReport.PendingOperations > 0 {
+		return driftReport, saveVpnGatewayDriftReport(&driftReport)
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if len(driftReport.StalePeers) > 0 {
+			if err := enqueueGatewayOperation(tx, vpnGateway.ID, models.GatewayOperationDeletePeers, driftReport.StalePeers); err != nil {
+				return err
+			}
+		}
+		if len(driftReport.MissingPeers) > 0 {
+			if err := enqueueGatewayOperation(tx, vpnGateway.ID, models.GatewayOperationAddPeers, driftReport.MissingPeers); err != nil {
+				return err
+			}
+		}
+		if len(driftReport.OutdatedPeers) > 0 {
+			if err := enqueueGatewayOperation(tx, vpnGateway.ID, models.GatewayOperationUpdatePeers, driftReport.OutdatedPeers); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return driftReport, err
+	}
+	notifyGatewayOperationsWorker()
+	driftReport.Corrected = true
+
+	log.Infof("vpn gateway %s drifted, %d missing, %d outdated and %d stale peers queued", vpnGateway.UUID, len(driftReport.MissingPeers), len(driftReport.OutdatedPeers), len(driftReport.StalePeers))
+	recordAuditTrail(actorUuid, models.AuditTrail{
+		Action:       models.AuditActionGatewayReconcile,
+		Description:  fmt.Sprintf("vpn gateway %s reconciled, %d missing peers added, %d outdated peers updated and %d stale peers removed", vpnGateway.Name, len(driftReport.MissingPeers), len(driftReport.OutdatedPeers), len(driftReport.StalePeers)),
+		VpnGatewayID: &vpnGateway.ID,
+	})
+	return driftReport, saveVpnGatewayDriftReport(&driftReport)
+}
+
+// saveVpnGatewayDriftReport replaces the previous report of the gateway, only the latest one is kept.
+func saveVpnGatewayDriftReport(driftReport *models.VpnGatewayDriftReport) error {
+	var existingDriftReport models.VpnGatewayDriftReport
+	err := database.DB.Where("vpn_gateway_id = ?", driftReport.VpnGatewayID).First(&existingDriftReport).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	if err == nil {
+		driftReport.Model = existingDriftReport.Model
+		driftReport.UUID = existingDriftReport.UUID
+	} else {
+		driftReport.UUID = uuid.NewString()
+	}
+	return database.DB.Save(driftReport).Error
+}
+
+// diffPeers returns the desired peers missing from the gateway, the ones the gateway holds with another
+// configuration and the peers held by the gateway that are not desired. Peers with a public key in
+// skippedPublicKeys are left out.
+func diffPeers(desiredPeers, actualPeers []models.WGServerPeerConfig, skippedPublicKeys map[string]bool) ([]models.WGServerPeerConfig, []models.WGServerPeerConfig, []models.WGServerPeerConfig) {
+	actualPeersByPublicKey := make(map[string]models.WGServerPeerConfig, len(actualPeers))
+	for _, peer := range actualPeers {
+		actualPeersByPublicKey[peer.ClientPublicKey] = peer
+	}
+	desiredPublicKeys := make(map[string]bool, len(desiredPeers))
+
+	var missingPeers, outdatedPeers, stalePeers []models.WGServerPeerConfig
+	for _, peer := range desiredPeers {
+		desiredPublicKeys[peer.ClientPublicKey] = true
+		if skippedPublicKeys[peer.ClientPublicKey] {
+			continue
+		}
+		actualPeer, exists := actualPeersByPublicKey[peer.ClientPublicKey]
+		if !exists {
+			missingPeers = append(missingPeers, peer)
+		} else if !samePeerConfig(peer, actualPeer) {
+			outdatedPeers = append(outdatedPeers, peer)
+		}
+	}
+	for _, peer := range actualPeers {
+		if !desiredPublicKeys[peer.ClientPublicKey] && !skippedPublicKeys[peer.ClientPublicKey] {
+			stalePeers = append(stalePeers, peer)
+		}
+	}
+	return missingPeers, outdatedPeers, stalePeers
+}
+
+// samePeerConfig compares everything the gateway enforces for a peer, firewall rules are ordered.
+func samePeerConfig(desiredPeer, actualPeer models.WGServerPeerConfig) bool {
+	return normalizeAllowedIPs(desiredPeer.ClientAllowedIPs) == normalizeAllowedIPs(actualPeer.ClientAllowedIPs) &&
+		desiredPeer.PresharedKey == actualPeer.PresharedKey &&
+		normalizeAllowedIPs(strings.Join(desiredPeer.AllowedDestinations, ",")) == normalizeAllowedIPs(strings.Join(actualPeer.AllowedDestinations, ",")) &&
+		slices.Equal(desiredPeer.FirewallRules, actualPeer.FirewallRules)
+}
+
+// normalizeAllowedIPs turns a comma separated list of ips or cidrs into a sorted list of cidrs,
+// so that "10.0.0.2" and "10.0.0.2/32" compare equal.
+func normalizeAllowedIPs(allowedIPs string) string {
+	var normalized []string
+	for _, allowedIP := range strings.Split(allowedIPs, ",") {
+		allowedIP = strings.TrimSpace(allowedIP)
+		if allowedIP == "" {
+			continue
+		}
+		if !strings.Contains(allowedIP, "/") {
+			if ip := net.ParseIP(allowedIP); ip != nil && ip.To4() == nil {
+				allowedIP += "/128"
+			} else {
+				allowedIP += "/32"
+			}
+		}
+		if _, ipNet, err := net.ParseCIDR(allowedIP); err == nil {
+			allowedIP = ipNet.String()
+		}
+		normalized = append(normalized, allowedIP)
+	}
+	sort.Strings(normalized)
+	return strings.Join(normalized, ",")
+}
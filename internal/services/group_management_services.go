@@ -1,48 +1,138 @@
 package services
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/leetsecure/qryptic-controller/internal/database"
 	"github.com/leetsecure/qryptic-controller/internal/models"
+	"github.com/leetsecure/qryptic-controller/internal/utils/helper"
 	"github.com/leetsecure/qryptic-controller/internal/utils/logger"
+	"gorm.io/gorm"
 )
 
-func CreateGroup(name string) error {
+func CreateGroup(actorUuid, name string, routes []string, maxClientLifetime int) error {
+	routes, err := helper.NormalizeCIDRs(routes)
+	if err != nil {
+		return err
+	}
 	group := models.Group{
-		UUID: uuid.NewString(),
-		Name: name,
+		UUID:              uuid.NewString(),
+		Name:              name,
+		Routes:            routes,
+		MaxClientLifetime: maxClientLifetime,
 	}
-	err := database.DB.Save(&group).Error
+	err = database.DB.Save(&group).Error
 	if err != nil {
 		return err
 	}
+	recordAuditTrail(actorUuid, models.AuditTrail{
+		Action:      models.AuditActionGroupCreate,
+		Description: fmt.Sprintf("group %s created with routes %v and max client lifetime %d minutes", group.Name, group.Routes, group.MaxClientLifetime),
+		GroupID:     &group.ID,
+	})
 	return nil
 }
 
-func DeleteGroup(groupUuid string) error {
+func DeleteGroup(actorUuid, groupUuid string) error {
 	log := logger.Default()
-	var group models.Group
-	err := database.DB.Where("uuid = ?", groupUuid).Delete(&group).Error
+	group, exists, err := getGroupFromUuid(groupUuid)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("group with given uuid not present")
+	}
+	var clientAuditTrails []models.AuditTrail
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		clientAuditTrails, err = deleteGroup(tx, group)
+		return err
+	})
 	if err != nil {
 		log.Errorf("Error deleting group : %s", groupUuid)
 		return err
 	}
+	notifyGatewayOperationsWorker()
+	recordAuditTrail(actorUuid, models.AuditTrail{
+		Action:      models.AuditActionGroupDelete,
+		Description: fmt.Sprintf("group %s deleted", group.Name),
+		GroupID:     &group.ID,
+	})
+	for _, auditTrail := range clientAuditTrails {
+		recordAuditTrail(actorUuid, auditTrail)
+	}
 	return nil
 }
 
-func UpdateGroup(groupUuid, name string) error {
+// deleteGroup deletes the group, the policies of the group go with it and its members lose their rules
+// on the gateways. The sso group mappings to the group go as well. The clients of the members on the
+// gateways they no longer reach are revoked, their audit trails are returned.
+func deleteGroup(tx *gorm.DB, group models.Group) ([]models.AuditTrail, error) {
+	if err := tx.Where("group_id = ?", group.ID).Delete(&models.SSOGroupMapping{}).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Exec("DELETE FROM sso_config_default_groups WHERE group_id = ?", group.ID).Error; err != nil {
+		return nil, err
+	}
+	var accessPolicies []models.AccessPolicy
+	if err := tx.Where("group_id = ?", group.ID).Find(&accessPolicies).Error; err != nil {
+		return nil, err
+	}
+	var members []models.User
+	if err := tx.Model(&group).Association("Users").Find(&members); err != nil {
+		return nil, err
+	}
+	if err := tx.Delete(&group).Error; err != nil {
+		return nil, err
+	}
+	for _, accessPolicy := range accessPolicies {
+		if err := tx.Delete(&accessPolicy).Error; err != nil {
+			return nil, err
+		}
+		if err := enqueueAccessPolicyPeerUpdates(tx, accessPolicy); err != nil {
+			return nil, err
+		}
+	}
+	// the gateways of the soft deleted group are still linked to it, but it no longer grants them
+	var auditTrails []models.AuditTrail
+	for _, member := range members {
+		revokedClientAuditTrails, err := revokeClientsOfRemovedGroupMember(tx, group, member, "with its deletion")
+		if err != nil {
+			return nil, err
+		}
+		auditTrails = append(auditTrails, revokedClientAuditTrails...)
+	}
+	return auditTrails, nil
+}
+
+func UpdateGroup(actorUuid, groupUuid, name string, routes []string, maxClientLifetime *int) error {
 	var group models.Group
 	err := database.DB.Where("uuid = ?", groupUuid).First(&group).Error
 	if err != nil {
 		return err
 	}
+	oldName := group.Name
 	group.Name = name
+	if routes != nil {
+		group.Routes, err = helper.NormalizeCIDRs(routes)
+		if err != nil {
+			return err
+		}
+	}
+	if maxClientLifetime != nil {
+		group.MaxClientLifetime = *maxClientLifetime
+	}
 	err = database.DB.Save(&group).Error
 	if err != nil {
 		return err
 	}
+	recordAuditTrail(actorUuid, models.AuditTrail{
+		Action:      models.AuditActionGroupUpdate,
+		Description: fmt.Sprintf("group %s renamed to %s with routes %v and max client lifetime %d minutes", oldName, group.Name, group.Routes, group.MaxClientLifetime),
+		GroupID:     &group.ID,
+	})
 	return nil
 }
 
@@ -83,7 +173,7 @@ func ListGroups(includeUsers, includeGateways bool) ([]models.Group, error) {
 	return groups, nil
 }
 
-func AddRemoveUsersInGroup(action string, groupUuid string, userUuids []string) error {
+func AddRemoveUsersInGroup(actorUuid, action string, groupUuid string, userUuids []string) error {
 	log := logger.Default()
 	// Start a transaction
 	tx := database.DB.Begin()
@@ -98,6 +188,7 @@ func AddRemoveUsersInGroup(action string, groupUuid string, userUuids []string)
 	for _, userUuid := range userUuids {
 		userUuidMap[userUuid] = false
 	}
+	var auditTrails, clientAuditTrails []models.AuditTrail
 
 	if action == "remove" {
 		for _, groupUser := range group.Users {
@@ -107,6 +198,18 @@ func AddRemoveUsersInGroup(action string, groupUuid string, userUuids []string)
 					tx.Rollback()
 					return err
 				}
+				auditTrails = append(auditTrails, models.AuditTrail{
+					Action:      models.AuditActionGroupRemoveUser,
+					Description: fmt.Sprintf("user %s removed from group %s", groupUser.Email, group.Name),
+					UserID:      &groupUser.ID,
+					GroupID:     &group.ID,
+				})
+				revokedClientAuditTrails, err := revokeClientsOfRemovedGroupMember(tx, group, *groupUser, "by admin")
+				if err != nil {
+					tx.Rollback()
+					return err
+				}
+				clientAuditTrails = append(clientAuditTrails, revokedClientAuditTrails...)
 			}
 		}
 	} else if action == "add" {
@@ -124,17 +227,106 @@ func AddRemoveUsersInGroup(action string, groupUuid string, userUuids []string)
 				tx.Rollback()
 				return err
 			}
+			auditTrails = append(auditTrails, models.AuditTrail{
+				Action:      models.AuditActionGroupAddUser,
+				Description: fmt.Sprintf("user %s added to group %s", newVpnUser.Email, group.Name),
+				UserID:      &newVpnUser.ID,
+				GroupID:     &group.ID,
+			})
 		}
 	} else {
 		tx.Rollback()
 		return fmt.Errorf("invalid action: %s", action)
 	}
 
+	var changedUserIDs []uint
+	for _, auditTrail := range auditTrails {
+		changedUserIDs = append(changedUserIDs, *auditTrail.UserID)
+	}
+	if err := enqueueGroupMemberPeerUpdates(tx, group, changedUserIDs); err != nil {
+		tx.Rollback()
+		return err
+	}
+
 	// Commit the transaction
 	if err := tx.Commit().Error; err != nil {
 		log.Errorf("error committing transaction for group %s: %v", groupUuid, err)
 		return err
 	}
+	notifyGatewayOperationsWorker()
 
+	for _, auditTrail := range append(auditTrails, clientAuditTrails...) {
+		recordAuditTrail(actorUuid, auditTrail)
+	}
 	return nil
 }
+
+// enqueueGroupMemberPeerUpdates updates the peers of the users who joined or left the group, the access
+// policies of the group no longer apply to the same peers.
+func enqueueGroupMemberPeerUpdates(tx *gorm.DB, group models.Group, changedUserIDs []uint) error {
+	if len(changedUserIDs) == 0 {
+		return nil
+	}
+	var vpnGatewayIDs []uint
+	if err := tx.Model(&models.AccessPolicy{}).Where("group_id = ?", group.ID).Distinct().Pluck("vpn_gateway_id", &vpnGatewayIDs).Error; err != nil {
+		return err
+	}
+	for _, vpnGatewayID := range vpnGatewayIDs {
+		if err := enqueuePeerUpdates(tx, vpnGatewayID, changedUserIDs); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revokeClientsOfRemovedGroupMember revokes the active clients of the user removed from the group on the
+// gateways of the group the user no longer reaches, directly or through another group, and returns
+// the audit trails of the revoked clients.
+func revokeClientsOfRemovedGroupMember(tx *gorm.DB, group models.Group, user models.User, reason string) ([]models.AuditTrail, error) {
+	var vpnGatewayIDs []uint
+	if err := tx.Table("group_vpngateways").Where("group_id = ?", group.ID).Pluck("vpn_gateway_id", &vpnGatewayIDs).Error; err != nil {
+		return nil, err
+	}
+	if len(vpnGatewayIDs) == 0 {
+		return nil, nil
+	}
+	var clients []models.Client
+	err := tx.Where("user_id = ? AND vpn_gateway_id IN ? AND is_active = ?", user.ID, vpnGatewayIDs, true).Find(&clients).Error
+	if err != nil {
+		return nil, err
+	}
+
+	reachable := map[uint]bool{}
+	var revokedClients []models.Client
+	for _, client := range clients {
+		isReachable, checked := reachable[client.VpnGatewayID]
+		if !checked {
+			direct, groups, err := accessGrantsOfVpnGateway(tx, user, models.VpnGateway{Model: gorm.Model{ID: client.VpnGatewayID}})
+			if err != nil {
+				return nil, err
+			}
+			isReachable = direct || len(groups) > 0
+			reachable[client.VpnGatewayID] = isReachable
+		}
+		if !isReachable {
+			revokedClients = append(revokedClients, client)
+		}
+	}
+	revokedClients, err = revokeClients(tx, revokedClients)
+	if err != nil {
+		return nil, err
+	}
+
+	var auditTrails []models.AuditTrail
+	for _, client := range revokedClients {
+		auditTrails = append(auditTrails, models.AuditTrail{
+			Action:       models.AuditActionClientDelete,
+			Description:  fmt.Sprintf("client %s with ip %s deleted as user %s no longer has access to the vpn gateway after the removal from group %s %s", client.UUID, client.AllocatedIP, user.Email, group.Name, reason),
+			UserID:       &client.UserID,
+			VpnGatewayID: &client.VpnGatewayID,
+			ClientID:     &client.ID,
+			GroupID:      &group.ID,
+		})
+	}
+	return auditTrails, nil
+}
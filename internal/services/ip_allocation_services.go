@@ -0,0 +1,450 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/leetsecure/qryptic-controller/internal/database"
+	"github.com/leetsecure/qryptic-controller/internal/models"
+	"github.com/leetsecure/qryptic-controller/internal/utils/helper"
+	"github.com/leetsecure/qryptic-controller/internal/utils/logger"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	// maxIPv6PrefixLength leaves at least 256 addresses for random allocation
+	maxIPv6PrefixLength    = 120
+	ipv6AllocationAttempts = 16
+	ipAllocationAttempts   = 16
+	pgUniqueViolation      = "23505"
+	// ipAllocationLockClass keys the advisory locks of the allocations of a gateway
+	ipAllocationLockClass = 1
+)
+
+// validateVpnCIDR checks that the cidr is an ipv4 network with room for clients next to the
+// network, gateway and broadcast addresses.
+func validateVpnCIDR(vpnCidr string) error {
+	_, ipNet, err := net.ParseCIDR(vpnCidr)
+	if err != nil {
+		return err
+	}
+	if ipNet.IP.To4() == nil {
+		return errors.New("vpnCIDR must be an ipv4 cidr, use vpnCIDRv6 for ipv6")
+	}
+	if ones, _ := ipNet.Mask.Size(); ones > 30 {
+		return errors.New("no ip available for clients for this small cidr")
+	}
+	return nil
+}
+
+// normalizeReservedRanges checks that the reserved ranges lie inside the vpn cidr.
+func normalizeReservedRanges(vpnCidr string, reservedRanges []string) ([]string, error) {
+	reservedRanges, err := helper.NormalizeCIDRs(reservedRanges)
+	if err != nil {
+		return nil, err
+	}
+	_, ipNet, err := net.ParseCIDR(vpnCidr)
+	if err != nil {
+		return nil, err
+	}
+	vpnOnes, _ := ipNet.Mask.Size()
+	for _, reservedIPNet := range parseReservedRanges(reservedRanges) {
+		if ones, _ := reservedIPNet.Mask.Size(); ones < vpnOnes || !ipNet.Contains(reservedIPNet.IP) {
+			return nil, fmt.Errorf("reserved range %s is not inside vpn cidr %s", reservedIPNet, vpnCidr)
+		}
+	}
+	return reservedRanges, nil
+}
+
+// clientIPRange returns the first and last address handed out to clients, the first host
+// of the vpn cidr is taken by the gateway.
+func clientIPRange(ipNet *net.IPNet) (uint32, uint32) {
+	network := ipToUint32(ipNet.IP)
+	broadcast := ipToUint32(lastIP(ipNet))
+	return network + 2, broadcast - 1
+}
+
+func ipToUint32(ip net.IP) uint32 {
+	return binary.BigEndian.Uint32(ip.To4())
+}
+
+func uint32ToIP(n uint32) net.IP {
+	ip := make(net.IP, net.IPv4len)
+	binary.BigEndian.PutUint32(ip, n)
+	return ip
+}
+
+func parseReservedRanges(reservedRanges []string) []*net.IPNet {
+	var ipNets []*net.IPNet
+	for _, reservedRange := range reservedRanges {
+		if _, ipNet, err := net.ParseCIDR(reservedRange); err == nil {
+			ipNets = append(ipNets, ipNet)
+		}
+	}
+	return ipNets
+}
+
+func ipInNetworks(ip net.IP, ipNets []*net.IPNet) bool {
+	for _, ipNet := range ipNets {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// lockIPAllocations serializes reservations with the allocations of the gateway until the end of the
+// transaction. Allocations share the lock among themselves, the unique index settles their races.
+func lockIPAllocations(tx *gorm.DB, vpnGatewayID uint, exclusive bool) error {
+	if exclusive {
+		return tx.Exec("SELECT pg_advisory_xact_lock(?, ?)", ipAllocationLockClass, vpnGatewayID).Error
+	}
+	return tx.Exec("SELECT pg_advisory_xact_lock_shared(?, ?)", ipAllocationLockClass, vpnGatewayID).Error
+}
+
+// allocateClientIP records an address of the vpn cidr of the gateway within the transaction of the
+// client creation. Clients of a device with a reservation get its reserved address, clients of users
+// with reservations one of the addresses reserved for the user, and the others the lowest free address
+// outside of the reservations. An address taken by a concurrent allocation makes the insert a no-op
+// thanks to the unique index, and the next free address is tried.
+func allocateClientIP(tx *gorm.DB, vpnGateway models.VpnGateway, userID uint, deviceID *uint) (*models.IPAllocation, error) {
+	_, ipNet, err := net.ParseCIDR(vpnGateway.VpnCIDR)
+	if err != nil {
+		return nil, err
+	}
+	reserved := parseReservedRanges(vpnGateway.ReservedRanges)
+
+	if err := lockIPAllocations(tx, vpnGateway.ID, false); err != nil {
+		return nil, err
+	}
+	var ipReservations []models.IPReservation
+	if err := tx.Where("vpn_gateway_id = ?", vpnGateway.ID).Order("id").Find(&ipReservations).Error; err != nil {
+		return nil, err
+	}
+	reservedIPs := make(map[string]bool, len(ipReservations))
+	var deviceReservedIPs, userReservedIPs []string
+	for _, ipReservation := range ipReservations {
+		reservedIPs[ipReservation.IP] = true
+		switch {
+		case ipReservation.DeviceID != nil:
+			if deviceID != nil && *ipReservation.DeviceID == *deviceID {
+				deviceReservedIPs = append(deviceReservedIPs, ipReservation.IP)
+			}
+		case ipReservation.UserID == userID:
+			userReservedIPs = append(userReservedIPs, ipReservation.IP)
+		}
+	}
+	// the reservations of the device come before the ones of its user
+	if len(deviceReservedIPs) > 0 || len(userReservedIPs) > 0 {
+		for _, reservedIP := range append(deviceReservedIPs, userReservedIPs...) {
+			ipAllocation, err := insertIPAllocation(tx, vpnGateway.ID, reservedIP)
+			if err != nil || ipAllocation != nil {
+				return ipAllocation, err
+			}
+		}
+		return nil, errors.New("reserved ips of the user or device are in use by other clients, delete one of them first")
+	}
+
+	var allocatedIPs []string
+	if err := tx.Model(&models.IPAllocation{}).Where("vpn_gateway_id = ?", vpnGateway.ID).Pluck("ip", &allocatedIPs).Error; err != nil {
+		return nil, err
+	}
+	allocated := make(map[string]bool, len(allocatedIPs))
+	for _, allocatedIP := range allocatedIPs {
+		allocated[allocatedIP] = true
+	}
+
+	first, last := clientIPRange(ipNet)
+	attempts := 0
+	for n := first; n <= last; n++ {
+		ip := uint32ToIP(n)
+		if allocated[ip.String()] || reservedIPs[ip.String()] || ipInNetworks(ip, reserved) {
+			continue
+		}
+		ipAllocation, err := insertIPAllocation(tx, vpnGateway.ID, ip.String())
+		if err != nil || ipAllocation != nil {
+			return ipAllocation, err
+		}
+		attempts++
+		if attempts == ipAllocationAttempts {
+			return nil, errors.New("ip allocation contended, try again")
+		}
+	}
+	return nil, errors.New("new ip not available, try clearing expired clients")
+}
+
+// insertIPAllocation returns the allocation when the address was free, nil when it is taken.
+func insertIPAllocation(tx *gorm.DB, vpnGatewayID uint, ip string) (*models.IPAllocation, error) {
+	ipAllocation := models.IPAllocation{VpnGatewayID: vpnGatewayID, IP: ip}
+	result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&ipAllocation)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return nil, result.Error
+	}
+	return &ipAllocation, nil
+}
+
+// releaseClientIP makes the address allocated to the client available again. Allocations not linked to
+// their client yet are matched on the address, Client.AllocatedIP carries a prefix length while
+// IPAllocation.IP holds the bare address.
+func releaseClientIP(db *gorm.DB, client models.Client) error {
+	allocatedIP := client.AllocatedIP
+	if ip, _, err := net.ParseCIDR(client.AllocatedIP); err == nil {
+		allocatedIP = ip.String()
+	}
+	return db.Where("client_id = ? OR (client_id IS NULL AND vpn_gateway_id = ? AND ip = ?)", client.ID, client.VpnGatewayID, allocatedIP).
+		Delete(&models.IPAllocation{}).Error
+}
+
+// MigrateIPPools moves the addresses of the active clients from the ip_pools table of the previous
+// allocator to the allocation table and drops it, nothing is done once the table is gone.
+func MigrateIPPools() error {
+	if !database.DB.Migrator().HasTable("ip_pools") {
+		return nil
+	}
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`INSERT INTO ip_allocations (created_at, vpn_gateway_id, ip, client_id)
+			SELECT NOW(), vpn_gateway_id, split_part(allocated_ip, '/', 1), id FROM clients
+			WHERE is_active AND deleted_at IS NULL AND allocated_ip <> ''
+			ON CONFLICT DO NOTHING`).Error
+		if err != nil {
+			return err
+		}
+		return tx.Migrator().DropTable("ip_pools")
+	})
+}
+
+// RepairIPAllocations repairs the allocations of every gateway. It is a one-off run at startup when
+// RepairIPAllocationsOnStartup is set.
+func RepairIPAllocations() error {
+	log := logger.Default()
+	var vpnGateways []models.VpnGateway
+	if err := database.DB.Find(&vpnGateways).Error; err != nil {
+		return err
+	}
+	for _, vpnGateway := range vpnGateways {
+		if _, err := RepairVpnGatewayIPAllocations("", vpnGateway.UUID); err != nil {
+			log.Errorf("error in repairing ip allocations of vpn gateway %s : %v", vpnGateway.UUID, err)
+			return err
+		}
+	}
+	return nil
+}
+
+// RepairVpnGatewayIPAllocations links allocations without a client to the active client holding the
+// address, releases the addresses leaked by inactive or deleted clients and records the missing
+// allocations of active clients.
+func RepairVpnGatewayIPAllocations(actorUuid, vpnGatewayUuid string) (models.IPAllocationRepairReport, error) {
+	var report models.IPAllocationRepairReport
+	vpnGateway, exists, err := getVpnGatewayFromUuid(vpnGatewayUuid)
+	if err != nil {
+		return report, err
+	}
+	if !exists {
+		return report, errors.New("vpn gateway with given uuid not present")
+	}
+	report.VpnGatewayUuid = vpnGateway.UUID
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		result := tx.Exec(`UPDATE ip_allocations SET client_id = clients.id FROM clients
+			WHERE ip_allocations.vpn_gateway_id = ? AND ip_allocations.client_id IS NULL
+			AND clients.vpn_gateway_id = ip_allocations.vpn_gateway_id AND clients.is_active AND clients.deleted_at IS NULL
+			AND split_part(clients.allocated_ip, '/', 1) = ip_allocations.ip
+			AND NOT EXISTS (SELECT 1 FROM ip_allocations linked WHERE linked.client_id = clients.id)`, vpnGateway.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		report.Linked = result.RowsAffected
+
+		result = tx.Exec(`DELETE FROM ip_allocations WHERE vpn_gateway_id = ? AND NOT EXISTS (
+			SELECT 1 FROM clients WHERE clients.id = ip_allocations.client_id AND clients.is_active AND clients.deleted_at IS NULL)`, vpnGateway.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		report.Reclaimed = result.RowsAffected
+
+		result = tx.Exec(`INSERT INTO ip_allocations (created_at, vpn_gateway_id, ip, client_id)
+			SELECT NOW(), clients.vpn_gateway_id, split_part(clients.allocated_ip, '/', 1), clients.id FROM clients
+			WHERE clients.vpn_gateway_id = ? AND clients.is_active AND clients.deleted_at IS NULL AND clients.allocated_ip <> ''
+			AND NOT EXISTS (SELECT 1 FROM ip_allocations WHERE ip_allocations.client_id = clients.id)
+			ON CONFLICT DO NOTHING`, vpnGateway.ID)
+		if result.Error != nil {
+			return result.Error
+		}
+		report.Restored = result.RowsAffected
+		return nil
+	})
+	if err != nil {
+		return report, err
+	}
+
+	if report.Linked+report.Reclaimed+report.Restored > 0 {
+		recordAuditTrail(actorUuid, models.AuditTrail{
+			Action:       models.AuditActionIPAllocationRepair,
+			Description:  fmt.Sprintf("ip allocations of vpn gateway %s repaired : %d linked, %d reclaimed, %d restored", vpnGateway.Name, report.Linked, report.Reclaimed, report.Restored),
+			VpnGatewayID: &vpnGateway.ID,
+		})
+	}
+	return report, nil
+}
+
+func GetVpnGatewayIPUtilization(vpnGatewayUuid string) (models.IPUtilizationReport, error) {
+	var report models.IPUtilizationReport
+	vpnGateway, exists, err := getVpnGatewayFromUuid(vpnGatewayUuid)
+	if err != nil {
+		return report, err
+	}
+	if !exists {
+		return report, errors.New("vpn gateway with given uuid not present")
+	}
+	_, ipNet, err := net.ParseCIDR(vpnGateway.VpnCIDR)
+	if err != nil {
+		return report, err
+	}
+
+	var allocatedIPs []string
+	if err := database.DB.Model(&models.IPAllocation{}).Where("vpn_gateway_id = ?", vpnGateway.ID).Pluck("ip", &allocatedIPs).Error; err != nil {
+		return report, err
+	}
+
+	first, last := clientIPRange(ipNet)
+	reserved := parseReservedRanges(vpnGateway.ReservedRanges)
+	report.VpnGatewayUuid = vpnGateway.UUID
+	report.VpnCIDR = vpnGateway.VpnCIDR
+	report.ReservedRanges = vpnGateway.ReservedRanges
+	report.Total = int64(last) - int64(first) + 1
+	// reserved ranges never overlap once normalized, only their part inside the client range counts
+	for _, reservedRange := range reserved {
+		rangeFirst, rangeLast := ipToUint32(reservedRange.IP), ipToUint32(lastIP(reservedRange))
+		rangeFirst, rangeLast = max(rangeFirst, first), min(rangeLast, last)
+		if rangeFirst <= rangeLast {
+			report.Reserved += int64(rangeLast) - int64(rangeFirst) + 1
+		}
+	}
+	allocated := make(map[string]bool, len(allocatedIPs))
+	for _, allocatedIP := range allocatedIPs {
+		if !ipInNetworks(net.ParseIP(allocatedIP), reserved) {
+			allocated[allocatedIP] = true
+			report.Allocated++
+		}
+	}
+
+	var ipReservations []models.IPReservation
+	if err := database.DB.Where("vpn_gateway_id = ?", vpnGateway.ID).Find(&ipReservations).Error; err != nil {
+		return report, err
+	}
+	// reservations inside reserved ranges are already counted, the ones in use count as allocated
+	var freeStaticReservations int64
+	for _, ipReservation := range ipReservations {
+		if ipInNetworks(net.ParseIP(ipReservation.IP), reserved) {
+			continue
+		}
+		report.StaticReservations++
+		if !allocated[ipReservation.IP] {
+			freeStaticReservations++
+		}
+	}
+
+	report.Available = report.Total - report.Reserved - freeStaticReservations - report.Allocated
+	if assignable := report.Allocated + report.Available; assignable > 0 {
+		report.UtilizationPercent = float64(report.Allocated) * 100 / float64(assignable)
+	}
+	return report, nil
+}
+
+// validateVpnCIDRv6 checks that the cidr is an ipv6 network wide enough for random allocation.
+func validateVpnCIDRv6(vpnCidrV6 string) error {
+	ip, ipNet, err := net.ParseCIDR(vpnCidrV6)
+	if err != nil {
+		return err
+	}
+	if ip.To4() != nil {
+		return errors.New("vpnCIDRv6 must be an ipv6 cidr")
+	}
+	if ones, _ := ipNet.Mask.Size(); ones > maxIPv6PrefixLength {
+		return fmt.Errorf("vpnCIDRv6 prefix length must be at most /%d", maxIPv6PrefixLength)
+	}
+	return nil
+}
+
+// createClientWithIPv6 inserts the client within the transaction of its creation. On a dual-stack gateway
+// the random ipv6 address is picked again when a concurrent client took it between the check and the insert.
+func createClientWithIPv6(tx *gorm.DB, vpnGateway models.VpnGateway, client *models.Client) error {
+	if vpnGateway.VpnCIDRv6 == "" {
+		return tx.Create(client).Error
+	}
+	for attempt := 0; attempt < ipv6AllocationAttempts; attempt++ {
+		allocatedIPv6, err := allocateClientIPv6(tx, vpnGateway)
+		if err != nil {
+			return err
+		}
+		client.AllocatedIPv6 = allocatedIPv6
+		// a failed insert aborts the transaction, the savepoint keeps the work done before it
+		if err := tx.SavePoint("client_ipv6").Error; err != nil {
+			return err
+		}
+		err = tx.Create(client).Error
+		if !isUniqueViolation(err, "idx_clients_gateway_ipv6") {
+			return err
+		}
+		if err := tx.RollbackTo("client_ipv6").Error; err != nil {
+			return err
+		}
+	}
+	return errors.New("ipv6 allocation contended, try again")
+}
+
+func isUniqueViolation(err error, constraintName string) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation && pgErr.ConstraintName == constraintName
+}
+
+// allocateClientIPv6 picks a random free address of the ipv6 cidr of the gateway. Addresses are not
+// materialized, the unique index on the active clients settles the rare concurrent pick of the same address.
+func allocateClientIPv6(db *gorm.DB, vpnGateway models.VpnGateway) (string, error) {
+	_, ipNet, err := net.ParseCIDR(vpnGateway.VpnCIDRv6)
+	if err != nil {
+		return "", err
+	}
+
+	for attempt := 0; attempt < ipv6AllocationAttempts; attempt++ {
+		ip, err := randomIPInNetwork(ipNet)
+		if err != nil {
+			return "", err
+		}
+		// the subnet router anycast address and the first address, taken by the gateway, are skipped
+		firstIP := dupIP(ipNet.IP)
+		incIP(firstIP)
+		if ip.Equal(ipNet.IP) || ip.Equal(firstIP) {
+			continue
+		}
+
+		allocatedIPv6 := fmt.Sprintf("%s/128", ip.String())
+		var count int64
+		err = db.Model(&models.Client{}).
+			Where("vpn_gateway_id = ? AND allocated_ipv6 = ? AND is_active = ?", vpnGateway.ID, allocatedIPv6, true).
+			Count(&count).Error
+		if err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return allocatedIPv6, nil
+		}
+	}
+	return "", errors.New("new ipv6 not available, try a wider vpnCIDRv6")
+}
+
+func randomIPInNetwork(ipNet *net.IPNet) (net.IP, error) {
+	ip := make(net.IP, len(ipNet.IP))
+	if _, err := rand.Read(ip); err != nil {
+		return nil, err
+	}
+	for i := range ip {
+		ip[i] = ipNet.IP[i] | (ip[i] &^ ipNet.Mask[i])
+	}
+	return ip, nil
+}
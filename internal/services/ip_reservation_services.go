@@ -0,0 +1,166 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/google/uuid"
+	"github.com/leetsecure/qryptic-controller/internal/database"
+	"github.com/leetsecure/qryptic-controller/internal/models"
+	"gorm.io/gorm"
+)
+
+func CreateIPReservation(actorUuid, vpnGatewayUuid string, request models.IPReservationCreateRequest) (models.IPReservation, error) {
+	var ipReservation models.IPReservation
+	vpnGateway, exists, err := getVpnGatewayFromUuid(vpnGatewayUuid)
+	if err != nil {
+		return ipReservation, err
+	}
+	if !exists {
+		return ipReservation, errors.New("vpn gateway with given uuid not present")
+	}
+	var device *models.Device
+	if request.DeviceUuid != "" {
+		device = &models.Device{}
+		err := database.DB.Preload("User").Where("uuid = ? AND revoked_at IS NULL", request.DeviceUuid).First(device).Error
+		if errors.Is(err, gorm.ErrRecordNotFound) || (err == nil && device.User == nil) {
+			return ipReservation, errors.New("device with given uuid not present")
+		}
+		if err != nil {
+			return ipReservation, err
+		}
+	}
+	var user models.User
+	if request.UserUuid != "" {
+		user, exists, err = getUserFromUuid(request.UserUuid)
+		if err != nil {
+			return ipReservation, err
+		}
+		if !exists {
+			return ipReservation, errors.New("user with given uuid not present")
+		}
+		if device != nil && device.UserID != user.ID {
+			return ipReservation, errors.New("device is not registered by the user")
+		}
+	} else {
+		user = *device.User
+	}
+
+	ip := net.ParseIP(request.IP).To4()
+	if ip == nil {
+		return ipReservation, errors.New("reserved ip must be an ipv4 address")
+	}
+	_, ipNet, err := net.ParseCIDR(vpnGateway.VpnCIDR)
+	if err != nil {
+		return ipReservation, err
+	}
+	first, last := clientIPRange(ipNet)
+	if n := ipToUint32(ip); !ipNet.Contains(ip) || n < first || n > last {
+		return ipReservation, fmt.Errorf("ip %s is not a client address of vpn cidr %s", ip, vpnGateway.VpnCIDR)
+	}
+
+	ipReservation = models.IPReservation{
+		UUID:         uuid.NewString(),
+		VpnGatewayID: vpnGateway.ID,
+		IP:           ip.String(),
+		UserID:       user.ID,
+		Description:  request.Description,
+	}
+	subject := "user " + user.Email
+	if device != nil {
+		ipReservation.DeviceID = &device.ID
+		subject = fmt.Sprintf("device %s of user %s", device.Name, user.Email)
+	}
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// allocations in flight on the gateway are committed before the address is checked
+		if err := lockIPAllocations(tx, vpnGateway.ID, true); err != nil {
+			return err
+		}
+		// an address held by a client the reservation is not for would be handed to two peers
+		dbClient := tx.Model(&models.Client{}).
+			Where("vpn_gateway_id = ? AND allocated_ip = ? AND is_active = ?", vpnGateway.ID, fmt.Sprintf("%s/32", ip), true)
+		if device != nil {
+			dbClient = dbClient.Where("device_id IS DISTINCT FROM ?", device.ID)
+		} else {
+			dbClient = dbClient.Where("user_id <> ?", user.ID)
+		}
+		var count int64
+		if err := dbClient.Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return fmt.Errorf("ip %s is allocated to an active client not covered by the reservation", ip)
+		}
+		return tx.Create(&ipReservation).Error
+	})
+	if err != nil {
+		return ipReservation, err
+	}
+	ipReservation.User = &user
+	ipReservation.Device = device
+
+	recordAuditTrail(actorUuid, models.AuditTrail{
+		Action:       models.AuditActionReservationCreate,
+		Description:  fmt.Sprintf("ip %s of vpn gateway %s reserved for %s", ipReservation.IP, vpnGateway.Name, subject),
+		UserID:       &user.ID,
+		VpnGatewayID: &vpnGateway.ID,
+	})
+	return ipReservation, nil
+}
+
+func ListIPReservations(vpnGatewayUuid string) ([]models.IPReservation, error) {
+	vpnGateway, exists, err := getVpnGatewayFromUuid(vpnGatewayUuid)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, errors.New("vpn gateway with given uuid not present")
+	}
+	var ipReservations []models.IPReservation
+	err = database.DB.Preload("User").Preload("Device").
+		Where("vpn_gateway_id = ?", vpnGateway.ID).
+		Order("id").
+		Find(&ipReservations).Error
+	if err != nil {
+		return nil, err
+	}
+	return ipReservations, nil
+}
+
+// DeleteIPReservation returns the address to the general pool, a client holding it keeps it until it is deleted.
+func DeleteIPReservation(actorUuid, vpnGatewayUuid, ipReservationUuid string) error {
+	vpnGateway, exists, err := getVpnGatewayFromUuid(vpnGatewayUuid)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("vpn gateway with given uuid not present")
+	}
+	var ipReservation models.IPReservation
+	err = database.DB.Preload("User").Preload("Device").
+		Where("uuid = ? AND vpn_gateway_id = ?", ipReservationUuid, vpnGateway.ID).
+		First(&ipReservation).Error
+	if err != nil {
+		return err
+	}
+	if err := database.DB.Delete(&ipReservation).Error; err != nil {
+		return err
+	}
+
+	description := fmt.Sprintf("reservation of ip %s of vpn gateway %s deleted", ipReservation.IP, vpnGateway.Name)
+	if ipReservation.Device != nil {
+		description += " for device " + ipReservation.Device.Name
+	}
+	if ipReservation.User != nil {
+		description += " for user " + ipReservation.User.Email
+	}
+	recordAuditTrail(actorUuid, models.AuditTrail{
+		Action:       models.AuditActionReservationDelete,
+		Description:  description,
+		UserID:       &ipReservation.UserID,
+		VpnGatewayID: &vpnGateway.ID,
+	})
+	return nil
+}
@@ -0,0 +1,410 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/crewjam/saml"
+	"github.com/crewjam/saml/samlsp"
+	"github.com/google/uuid"
+	"github.com/leetsecure/qryptic-controller/internal/config"
+	"github.com/leetsecure/qryptic-controller/internal/database"
+	"github.com/leetsecure/qryptic-controller/internal/models"
+	"github.com/leetsecure/qryptic-controller/internal/utils/logger"
+)
+
+// attributes tried for the email when the sso config names none, the name id is used last
+var defaultSAMLEmailAttributes = []string{
+	"email",
+	"mail",
+	"emailAddress",
+	"urn:oid:0.9.2342.19200300.100.1.3",
+	"http://schemas.xmlsoap.org/ws/2005/05/identity/claims/emailaddress",
+}
+
+var defaultSAMLGroupAttributes = []string{
+	"groups",
+	"memberOf",
+	"http://schemas.microsoft.com/ws/2008/06/identity/claims/groups",
+	"http://schemas.xmlsoap.org/claims/Group",
+}
+
+var defaultSAMLNameAttributes = []string{
+	"name",
+	"displayName",
+	"urn:oid:2.16.840.1.113730.3.1.241",
+	"cn",
+	"urn:oid:2.5.4.3",
+	"http://schemas.xmlsoap.org/ws/2005/05/identity/claims/name",
+}
+
+func samlURL(provider, endpoint string) url.URL {
+	samlURL, _ := url.Parse(fmt.Sprintf(config.SAMLURLTemplate, config.ControllerDomain, url.PathEscape(provider), endpoint))
+	return *samlURL
+}
+
+// generateSAMLServiceProviderKeyPair creates the self-signed certificate the identity provider trusts
+// for the signed requests and the encrypted assertions of the service provider.
+func generateSAMLServiceProviderKeyPair() (string, string, error) {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return "", "", err
+	}
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return "", "", err
+	}
+	template := x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               pkix.Name{CommonName: config.ControllerDomain},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().AddDate(10, 0, 0),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+		BasicConstraintsValid: true,
+	}
+	certificate, err := x509.CreateCertificate(rand.Reader, &template, &template, &privateKey.PublicKey, privateKey)
+	if err != nil {
+		return "", "", err
+	}
+	privateKeyPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(privateKey)})
+	certificatePEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certificate})
+	return string(privateKeyPEM), string(certificatePEM), nil
+}
+
+func parseSAMLIdPMetadata(idpMetadata string) (*saml.EntityDescriptor, error) {
+	entityDescriptor, err := samlsp.ParseMetadata([]byte(idpMetadata))
+	if err != nil {
+		return nil, fmt.Errorf("invalid idp metadata: %w", err)
+	}
+	if len(entityDescriptor.IDPSSODescriptors) == 0 {
+		return nil, errors.New("invalid idp metadata: no identity provider descriptor")
+	}
+	return entityDescriptor, nil
+}
+
+func newSAMLServiceProvider(ssoConfig models.SSOConfig) (*saml.ServiceProvider, error) {
+	privateKeyBlock, _ := pem.Decode([]byte(ssoConfig.SAMLSPPrivateKey))
+	if privateKeyBlock == nil {
+		return nil, errors.New("saml service provider key missing")
+	}
+	privateKey, err := x509.ParsePKCS1PrivateKey(privateKeyBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	certificateBlock, _ := pem.Decode([]byte(ssoConfig.SAMLSPCertificate))
+	if certificateBlock == nil {
+		return nil, errors.New("saml service provider certificate missing")
+	}
+	certificate, err := x509.ParseCertificate(certificateBlock.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	idpMetadata, err := parseSAMLIdPMetadata(ssoConfig.SAMLIdPMetadata)
+	if err != nil {
+		return nil, err
+	}
+	metadataURL := samlURL(ssoConfig.Provider, "metadata")
+	return &saml.ServiceProvider{
+		EntityID:          metadataURL.String(),
+		Key:               privateKey,
+		Certificate:       certificate,
+		MetadataURL:       metadataURL,
+		AcsURL:            samlURL(ssoConfig.Provider, "acs"),
+		IDPMetadata:       idpMetadata,
+		AuthnNameIDFormat: saml.UnspecifiedNameIDFormat,
+	}, nil
+}
+
+func getSAMLSSOConfig(provider string) (models.SSOConfig, error) {
+	ssoConfigs, err := enabledSSOConfigs(provider)
+	if err != nil {
+		return models.SSOConfig{}, err
+	}
+	for _, ssoConfig := range ssoConfigs {
+		if ssoConfig.Protocol == models.SSOProtocolSAML {
+			return ssoConfig, nil
+		}
+	}
+	return models.SSOConfig{}, errors.New("saml provider not allowed")
+}
+
+// checkSSOProviderProtocol keeps a provider name to a single protocol since the routes only carry the name
+func checkSSOProviderProtocol(provider string, protocol models.SSOProtocolEnum) error {
+	var count int64
+	err := database.DB.Model(&models.SSOConfig{}).Where("provider = ? AND protocol <> ?", provider, protocol).Count(&count).Error
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("provider %s is already configured with another protocol", provider)
+	}
+	return nil
+}
+
+// GetSAMLServiceProviderMetadata returns the metadata xml to register the controller with the identity provider
+func GetSAMLServiceProviderMetadata(provider string) ([]byte, error) {
+	ssoConfig, err := getSAMLSSOConfig(provider)
+	if err != nil {
+		return nil, err
+	}
+	serviceProvider, err := newSAMLServiceProvider(ssoConfig)
+	if err != nil {
+		return nil, err
+	}
+	metadata, err := xml.MarshalIndent(serviceProvider.Metadata(), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), metadata...), nil
+}
+
+// SAMLLoginInitiate returns the redirect to the identity provider for the web login. The id of the
+// authentication request is kept as state and sent as relay state, the assertion has to answer it.
+func SAMLLoginInitiate(provider, codeChallenge string) (string, error) {
+	log := logger.Default()
+	ssoConfig, err := getSAMLSSOConfig(provider)
+	if err != nil {
+		return "", err
+	}
+	serviceProvider, err := newSAMLServiceProvider(ssoConfig)
+	if err != nil {
+		return "", err
+	}
+	authnRequest, err := serviceProvider.MakeAuthenticationRequest(serviceProvider.GetSSOBindingLocation(saml.HTTPRedirectBinding), saml.HTTPRedirectBinding, saml.HTTPPostBinding)
+	if err != nil {
+		return "", err
+	}
+	redirectURL, err := authnRequest.Redirect(authnRequest.ID, serviceProvider)
+	if err != nil {
+		return "", err
+	}
+	auth := models.Auth{
+		UUID:          uuid.NewString(),
+		Provider:      provider,
+		State:         authnRequest.ID,
+		CodeChallenge: codeChallenge,
+		ExpiryTime:    time.Now().Add(2 * time.Minute),
+	}
+	if err := database.DB.Save(&auth).Error; err != nil {
+		log.Error(err)
+		return "", err
+	}
+	return redirectURL.String(), nil
+}
+
+// SAMLAssertionConsumer validates the response posted by the identity provider and completes the web
+// login started for its relay state. Identity provider initiated logins are not accepted.
+func SAMLAssertionConsumer(provider string, req *http.Request) error {
+	log := logger.Default()
+	if err := req.ParseForm(); err != nil {
+		return err
+	}
+	state := req.PostForm.Get("RelayState")
+	if state == "" {
+		return errors.New("missing relay state")
+	}
+	var auth models.Auth
+	err := database.DB.Where("state = ? AND provider = ?", state, provider).First(&auth).Error
+	if err != nil {
+		log.Errorf("error in fetching record of state : %s from auth | error : %s", state, err)
+		return err
+	}
+	if time.Now().After(auth.ExpiryTime) {
+		log.Errorf("expired state %s", state)
+		return errors.New("expired state")
+	}
+	if auth.Authenticated {
+		return errors.New("assertion already consumed")
+	}
+
+	ssoConfig, err := getSAMLSSOConfig(provider)
+	if err != nil {
+		return err
+	}
+	assertion, err := parseSAMLAssertion(ssoConfig, req, auth.State)
+	if err != nil {
+		return err
+	}
+
+	email := samlEmail(ssoConfig, assertion)
+	if email == "" {
+		return errors.New("email missing from the saml assertion")
+	}
+	groups, groupsClaimed := samlAttributeValues(assertion, samlAttributeNames(ssoConfig.GroupClaim, defaultSAMLGroupAttributes)...)
+	// the state is marked authenticated only if no other response consumed it since it was read
+	result := database.DB.Model(&auth).Where("authenticated = ?", false).
+		Select("Authenticated", "Email", "Name", "Groups", "GroupsClaimed", "SSOConfigID", "EmailVerified").
+		Updates(models.Auth{
+			Authenticated: true,
+			Email:         email,
+			Name:          samlAttribute(assertion, samlAttributeNames(ssoConfig.NameClaim, defaultSAMLNameAttributes)...),
+			Groups:        groups,
+			GroupsClaimed: groupsClaimed,
+			SSOConfigID:   ssoConfig.ID,
+			// the assertion is signed by the identity provider, which vouches for the email
+			EmailVerified: true,
+		})
+	if result.Error != nil {
+		log.Errorf("error in saving auth details for state : %s in auth | error : %s", state, result.Error)
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("assertion already consumed")
+	}
+	return nil
+}
+
+// parseSAMLAssertion checks the signature, audience, validity and destination of the response posted
+// to the form of req and that it answers the authentication request, and returns its assertion.
+func parseSAMLAssertion(ssoConfig models.SSOConfig, req *http.Request, requestID string) (*saml.Assertion, error) {
+	log := logger.Default()
+	serviceProvider, err := newSAMLServiceProvider(ssoConfig)
+	if err != nil {
+		return nil, err
+	}
+	assertion, err := serviceProvider.ParseResponse(req, []string{requestID})
+	if err != nil {
+		var invalidResponseError *saml.InvalidResponseError
+		if errors.As(err, &invalidResponseError) {
+			log.Errorf("invalid saml response for provider %s | error : %s", ssoConfig.Provider, invalidResponseError.PrivateErr)
+		}
+		return nil, err
+	}
+	return assertion, nil
+}
+
+func samlAttributeNames(configured string, defaults []string) []string {
+	if configured != "" {
+		return []string{configured}
+	}
+	return defaults
+}
+
+func samlEmail(ssoConfig models.SSOConfig, assertion *saml.Assertion) string {
+	if email := samlAttribute(assertion, samlAttributeNames(ssoConfig.EmailClaim, defaultSAMLEmailAttributes)...); email != "" {
+		return email
+	}
+	if ssoConfig.EmailClaim == "" && assertion.Subject != nil && assertion.Subject.NameID != nil &&
+		strings.Contains(assertion.Subject.NameID.Value, "@") {
+		return assertion.Subject.NameID.Value
+	}
+	return ""
+}
+
+// samlAttribute returns the first value of the first attribute found by name or friendly name
+func samlAttribute(assertion *saml.Assertion, names ...string) string {
+	for _, name := range names {
+		for _, attributeStatement := range assertion.AttributeStatements {
+			for _, attribute := range attributeStatement.Attributes {
+				if attribute.Name != name && attribute.FriendlyName != name {
+					continue
+				}
+				for _, value := range attribute.Values {
+					if value.Value != "" {
+						return strings.TrimSpace(value.Value)
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// samlAttributeValues returns the values of the first attribute found by name or friendly name, and
+// whether any of the attributes is present in the assertion, even without values
+func samlAttributeValues(assertion *saml.Assertion, names ...string) ([]string, bool) {
+	present := false
+	for _, name := range names {
+		var values []string
+		for _, attributeStatement := range assertion.AttributeStatements {
+			for _, attribute := range attributeStatement.Attributes {
+				if attribute.Name != name && attribute.FriendlyName != name {
+					continue
+				}
+				present = true
+				for _, value := range attribute.Values {
+					if value := strings.TrimSpace(value.Value); value != "" {
+						values = append(values, value)
+					}
+				}
+			}
+		}
+		if len(values) > 0 {
+			return values, true
+		}
+	}
+	return nil, present
+}
+
+// AddSamlSsoConfig adds a SAML identity provider for the web login under the provider name
+func AddSamlSsoConfig(actorUuid, domain, provider, idpMetadata, emailAttribute, nameAttribute, groupAttribute string) error {
+	if err := checkSSOProviderProtocol(provider, models.SSOProtocolSAML); err != nil {
+		return err
+	}
+	if _, err := parseSAMLIdPMetadata(idpMetadata); err != nil {
+		return err
+	}
+	privateKey, certificate, err := generateSAMLServiceProviderKeyPair()
+	if err != nil {
+		return err
+	}
+	adminConfiguration, err := GetAdminConfiguration(false)
+	if err != nil {
+		return err
+	}
+	ssoConfig := models.SSOConfig{
+		UUID:                 uuid.NewString(),
+		Domain:               domain,
+		Provider:             provider,
+		Platform:             WebsiteSSOPlatform,
+		Protocol:             models.SSOProtocolSAML,
+		EmailClaim:           emailAttribute,
+		NameClaim:            nameAttribute,
+		GroupClaim:           groupAttribute,
+		SAMLIdPMetadata:      idpMetadata,
+		SAMLSPCertificate:    certificate,
+		SAMLSPPrivateKey:     privateKey,
+		AdminConfigurationID: adminConfiguration.ID,
+	}
+	if err := database.DB.Create(&ssoConfig).Error; err != nil {
+		return err
+	}
+	recordAuditTrail(actorUuid, models.AuditTrail{
+		Action:      models.AuditActionSSOConfigAdd,
+		Description: fmt.Sprintf("saml sso config %s added for provider %s and domain %s", ssoConfig.UUID, ssoConfig.Provider, ssoConfig.Domain),
+	})
+	return nil
+}
+
+// UpdateSamlIdpMetadata replaces the metadata of the identity provider, e.g. after its certificate rolled
+func UpdateSamlIdpMetadata(actorUuid, ssoConfigUuid, idpMetadata string) error {
+	var ssoConfig models.SSOConfig
+	if err := database.DB.Where("uuid = ?", ssoConfigUuid).First(&ssoConfig).Error; err != nil {
+		return err
+	}
+	if ssoConfig.Protocol != models.SSOProtocolSAML {
+		return errors.New("sso config is not a saml config")
+	}
+	if _, err := parseSAMLIdPMetadata(idpMetadata); err != nil {
+		return err
+	}
+	if err := database.DB.Model(&ssoConfig).Update("SAMLIdPMetadata", idpMetadata).Error; err != nil {
+		return err
+	}
+	recordAuditTrail(actorUuid, models.AuditTrail{
+		Action:      models.AuditActionSAMLMetadataUpdate,
+		Description: fmt.Sprintf("idp metadata of saml sso config %s updated for provider %s", ssoConfig.UUID, ssoConfig.Provider),
+	})
+	return nil
+}
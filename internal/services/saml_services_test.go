@@ -0,0 +1,178 @@
+package services
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"encoding/xml"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/beevik/etree"
+	"github.com/crewjam/saml"
+	"github.com/leetsecure/qryptic-controller/internal/config"
+	"github.com/leetsecure/qryptic-controller/internal/models"
+)
+
+const testSAMLRequestID = "id-test-request"
+
+// testSAMLIdentityProvider signs the responses posted to the acs of the sso config.
+type testSAMLIdentityProvider struct {
+	idp       *saml.IdentityProvider
+	ssoConfig models.SSOConfig
+}
+
+func newTestSAMLIdentityProvider(t *testing.T) *testSAMLIdentityProvider {
+	t.Helper()
+	controllerDomain := config.ControllerDomain
+	config.ControllerDomain = "controller.example.com"
+	t.Cleanup(func() { config.ControllerDomain = controllerDomain })
+
+	idpPrivateKeyPEM, idpCertificatePEM, err := generateSAMLServiceProviderKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	privateKeyBlock, _ := pem.Decode([]byte(idpPrivateKeyPEM))
+	idpPrivateKey, err := x509.ParsePKCS1PrivateKey(privateKeyBlock.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certificateBlock, _ := pem.Decode([]byte(idpCertificatePEM))
+	idpCertificate, err := x509.ParseCertificate(certificateBlock.Bytes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	metadataURL, _ := url.Parse("https://idp.example.com/metadata")
+	ssoURL, _ := url.Parse("https://idp.example.com/sso")
+	idp := &saml.IdentityProvider{
+		Key:         idpPrivateKey,
+		Signer:      idpPrivateKey,
+		Certificate: idpCertificate,
+		MetadataURL: *metadataURL,
+		SSOURL:      *ssoURL,
+	}
+	idpMetadata, err := xml.Marshal(idp.Metadata())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	spPrivateKey, spCertificate, err := generateSAMLServiceProviderKeyPair()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &testSAMLIdentityProvider{
+		idp: idp,
+		ssoConfig: models.SSOConfig{
+			Provider:          "okta",
+			Protocol:          models.SSOProtocolSAML,
+			SAMLIdPMetadata:   string(idpMetadata),
+			SAMLSPCertificate: spCertificate,
+			SAMLSPPrivateKey:  spPrivateKey,
+		},
+	}
+}
+
+// assertionRequest returns the request posting to the acs a response to the authentication request,
+// issued at now for the audience, encrypted for and signed like the identity provider does.
+func (p *testSAMLIdentityProvider) assertionRequest(t *testing.T, requestID, audience string, now time.Time) *http.Request {
+	t.Helper()
+	serviceProvider, err := newSAMLServiceProvider(p.ssoConfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spMetadata := serviceProvider.Metadata()
+	if audience != "" {
+		spMetadata.EntityID = audience
+	}
+	spSSODescriptor := &spMetadata.SPSSODescriptors[0]
+	idpRequest := &saml.IdpAuthnRequest{
+		IDP:                     p.idp,
+		HTTPRequest:             httptest.NewRequest(http.MethodPost, p.idp.SSOURL.String(), nil),
+		Request:                 saml.AuthnRequest{ID: requestID, IssueInstant: now},
+		ServiceProviderMetadata: spMetadata,
+		SPSSODescriptor:         spSSODescriptor,
+		ACSEndpoint:             &spSSODescriptor.AssertionConsumerServices[0],
+		Now:                     now,
+	}
+	err = saml.DefaultAssertionMaker{}.MakeAssertion(idpRequest, &saml.Session{
+		NameID:     "user@example.com",
+		CreateTime: now,
+		Index:      "1",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := idpRequest.MakeResponse(); err != nil {
+		t.Fatal(err)
+	}
+	document := etree.NewDocument()
+	document.SetRoot(idpRequest.ResponseEl)
+	response, err := document.WriteToBytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	form := url.Values{
+		"SAMLResponse": {base64.StdEncoding.EncodeToString(response)},
+		"RelayState":   {requestID},
+	}
+	acsURL := samlURL(p.ssoConfig.Provider, "acs")
+	req := httptest.NewRequest(http.MethodPost, acsURL.String(), strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if err := req.ParseForm(); err != nil {
+		t.Fatal(err)
+	}
+	return req
+}
+
+func TestParseSAMLAssertion(t *testing.T) {
+	p := newTestSAMLIdentityProvider(t)
+
+	tests := []struct {
+		name      string
+		requestID string
+		audience  string
+		issuedAt  time.Time
+		wantErr   string
+	}{
+		{name: "valid", requestID: testSAMLRequestID, issuedAt: time.Now()},
+		{name: "wrong audience", requestID: testSAMLRequestID, audience: "https://other.example.com/metadata", issuedAt: time.Now(), wantErr: "AudienceRestriction"},
+		{name: "wrong in response to", requestID: "id-other-request", issuedAt: time.Now(), wantErr: "InResponseTo"},
+		{name: "expired", requestID: testSAMLRequestID, issuedAt: time.Now().Add(-time.Hour), wantErr: "expired"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := p.assertionRequest(t, test.requestID, test.audience, test.issuedAt)
+			assertion, err := parseSAMLAssertion(p.ssoConfig, req, testSAMLRequestID)
+			if test.wantErr == "" {
+				if err != nil {
+					t.Fatalf("valid response rejected: %s", samlPrivateError(err))
+				}
+				if email := samlEmail(p.ssoConfig, assertion); email != "user@example.com" {
+					t.Fatalf("email %q read from the assertion", email)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("response accepted")
+			}
+			if !strings.Contains(samlPrivateError(err).Error(), test.wantErr) {
+				t.Fatalf("response rejected for another reason: %s", samlPrivateError(err))
+			}
+		})
+	}
+}
+
+// samlPrivateError returns the reason kept out of the error shown to the user
+func samlPrivateError(err error) error {
+	var invalidResponseError *saml.InvalidResponseError
+	if errors.As(err, &invalidResponseError) {
+		return invalidResponseError.PrivateErr
+	}
+	return err
+}
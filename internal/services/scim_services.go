@@ -0,0 +1,1016 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/leetsecure/qryptic-controller/internal/config"
+	"github.com/leetsecure/qryptic-controller/internal/database"
+	"github.com/leetsecure/qryptic-controller/internal/models"
+	"github.com/leetsecure/qryptic-controller/internal/utils/auth"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	defaultSCIMPageSize = 100
+	maxSCIMPageSize     = 1000
+)
+
+// The scim errors carry the scimType the handlers answer with.
+var (
+	ErrSCIMNotFound      = errors.New("resource not found")
+	ErrSCIMUniqueness    = errors.New("resource already exists")
+	ErrSCIMInvalidFilter = errors.New("invalid filter")
+	ErrSCIMInvalidPath   = errors.New("invalid path")
+	ErrSCIMInvalidValue  = errors.New("invalid value")
+)
+
+// scimFilterAttribute is the column a scim attribute is filtered on, string attributes compare case
+// insensitively unless caseExact is set.
+type scimFilterAttribute struct {
+	column    string
+	boolean   bool
+	caseExact bool
+}
+
+var scimUserFilterAttributes = map[string]scimFilterAttribute{
+	"id":           {column: "uuid", caseExact: true},
+	"externalid":   {column: "external_id", caseExact: true},
+	"username":     {column: "email"},
+	"emails":       {column: "email"},
+	"emails.value": {column: "email"},
+	"displayname":  {column: "name"},
+	"active":       {column: "is_active", boolean: true},
+}
+
+var scimGroupFilterAttributes = map[string]scimFilterAttribute{
+	"id":          {column: "uuid", caseExact: true},
+	"externalid":  {column: "external_id", caseExact: true},
+	"displayname": {column: "name"},
+}
+
+type scimFilterExpression struct {
+	attribute string
+	operator  string
+	value     string
+	quoted    bool
+}
+
+// RotateSCIMToken replaces the bearer token of the scim client and enables scim provisioning. Only the
+// hash of the token is kept, so it is returned once.
+func RotateSCIMToken(actorUuid string) (string, error) {
+	var adminConfiguration models.AdminConfiguration
+	if err := database.DB.First(&adminConfiguration).Error; err != nil {
+		return "", err
+	}
+	scimToken, scimTokenHash, err := auth.CreateSCIMToken()
+	if err != nil {
+		return "", err
+	}
+	if err := database.DB.Model(&adminConfiguration).Update("scim_token_hash", scimTokenHash).Error; err != nil {
+		return "", err
+	}
+	recordAuditTrail(actorUuid, models.AuditTrail{
+		Action:      models.AuditActionSCIMTokenRotate,
+		Description: "scim token rotated",
+	})
+	return scimToken, nil
+}
+
+// RevokeSCIMToken disables scim provisioning until a new token is issued.
+func RevokeSCIMToken(actorUuid string) error {
+	var adminConfiguration models.AdminConfiguration
+	if err := database.DB.First(&adminConfiguration).Error; err != nil {
+		return err
+	}
+	if err := database.DB.Model(&adminConfiguration).Update("scim_token_hash", "").Error; err != nil {
+		return err
+	}
+	recordAuditTrail(actorUuid, models.AuditTrail{
+		Action:      models.AuditActionSCIMTokenRevoke,
+		Description: "scim token revoked",
+	})
+	return nil
+}
+
+// SCIMServiceProviderConfig describes the scim features supported by the controller.
+func SCIMServiceProviderConfig() map[string]any {
+	return map[string]any{
+		"schemas":        []string{models.SCIMSchemaServiceProviderConfig},
+		"patch":          map[string]any{"supported": true},
+		"bulk":           map[string]any{"supported": false, "maxOperations": 0, "maxPayloadSize": 0},
+		"filter":         map[string]any{"supported": true, "maxResults": maxSCIMPageSize},
+		"changePassword": map[string]any{"supported": false},
+		"sort":           map[string]any{"supported": false},
+		"etag":           map[string]any{"supported": false},
+		"authenticationSchemes": []map[string]any{{
+			"type":        "oauthbearertoken",
+			"name":        "Bearer Token",
+			"description": "token issued from /api/v1/admin/config/scim-token",
+			"primary":     true,
+		}},
+	}
+}
+
+func ListSCIMUsers(request models.SCIMListRequest) (models.SCIMListResponse, error) {
+	var users []models.User
+	response, dbClient, err := scimListQuery(database.DB.Model(&models.User{}).Where("scim_provisioned = ?", true), request, scimUserFilterAttributes)
+	if err != nil || response.ItemsPerPage == 0 {
+		return response, err
+	}
+	if err := dbClient.Preload("Groups").Find(&users).Error; err != nil {
+		return response, err
+	}
+	for _, user := range users {
+		response.Resources = append(response.Resources, scimUserFromUser(user))
+	}
+	response.ItemsPerPage = len(users)
+	return response, nil
+}
+
+func GetSCIMUser(userUuid string) (models.SCIMUser, error) {
+	user, err := getSCIMUser(database.DB, userUuid)
+	if err != nil {
+		return models.SCIMUser{}, err
+	}
+	return scimUserFromUser(user), nil
+}
+
+// CreateSCIMUser provisions a user who signs in with sso, the userName is the email of the user.
+func CreateSCIMUser(scimUser models.SCIMUser) (models.SCIMUser, error) {
+	if scimUser.UserName == "" {
+		return scimUser, fmt.Errorf("%w: userName is required", ErrSCIMInvalidValue)
+	}
+	if ifUserEmailAlreadyPresent(scimUser.UserName) {
+		return scimUser, fmt.Errorf("%w: user %s", ErrSCIMUniqueness, scimUser.UserName)
+	}
+	user := models.User{
+		UUID:       uuid.NewString(),
+		Email:      scimUser.UserName,
+		Name:       scimUserDisplayName(scimUser),
+		ExternalID: scimUser.ExternalID,
+		Role:       models.UserRole,
+		// users created by the admin stay out of reach of scim
+		SCIMProvisioned: true,
+	}
+	active := scimUser.Active == nil || *scimUser.Active
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		// a false is left out of the insert for the default of the column to apply
+		if !active {
+			return tx.Model(&user).Update("is_active", false).Error
+		}
+		return nil
+	})
+	if err != nil {
+		return scimUser, err
+	}
+	recordSCIMAuditTrail(models.AuditTrail{
+		Action:      models.AuditActionUserCreate,
+		Description: fmt.Sprintf("user %s provisioned through scim with role %s and active %t", user.Email, user.Role, active),
+		UserID:      &user.ID,
+	})
+	return GetSCIMUser(user.UUID)
+}
+
+// ReplaceSCIMUser sets the attributes of the user to the given ones.
+func ReplaceSCIMUser(userUuid string, scimUser models.SCIMUser) (models.SCIMUser, error) {
+	if scimUser.UserName == "" {
+		return scimUser, fmt.Errorf("%w: userName is required", ErrSCIMInvalidValue)
+	}
+	return updateSCIMUser(userUuid, func(user *models.User) error {
+		user.Email = scimUser.UserName
+		user.Name = scimUserDisplayName(scimUser)
+		user.ExternalID = scimUser.ExternalID
+		user.IsActive = scimUser.Active == nil || *scimUser.Active
+		return nil
+	})
+}
+
+func PatchSCIMUser(userUuid string, patchRequest models.SCIMPatchRequest) (models.SCIMUser, error) {
+	return updateSCIMUser(userUuid, func(user *models.User) error {
+		for _, operation := range patchRequest.Operations {
+			if err := patchSCIMUser(user, operation); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// DeleteSCIMUser deprovisions the user, the active clients of the user are revoked on every gateway
+// before the user is deleted.
+func DeleteSCIMUser(userUuid string) error {
+	var revokedClients []models.Client
+	user, err := getSCIMUser(database.DB, userUuid)
+	if err != nil {
+		return err
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		revokedClients, err = revokeClientsOfUser(tx, user)
+		if err != nil {
+			return err
+		}
+		return deleteUser(tx, user)
+	})
+	if err != nil {
+		return err
+	}
+	notifyGatewayOperationsWorker()
+
+	recordSCIMAuditTrail(models.AuditTrail{
+		Action:      models.AuditActionUserDelete,
+		Description: fmt.Sprintf("user %s deprovisioned through scim with its %d active clients", user.Email, len(revokedClients)),
+		UserID:      &user.ID,
+	})
+	recordDeprovisionedClientAuditTrails(user, revokedClients)
+	return nil
+}
+
+// updateSCIMUser applies the changes to the user, a user turning inactive gets its active clients
+// revoked on every gateway.
+func updateSCIMUser(userUuid string, applyChanges func(user *models.User) error) (models.SCIMUser, error) {
+	var user models.User
+	var previousEmail string
+	var wasActive bool
+	var revokedClients []models.Client
+	// the user is locked so that changes made meanwhile by the admin or another request aren't lost,
+	// only the attributes kept for scim are written
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		user, err = getSCIMUser(tx.Clauses(clause.Locking{Strength: "UPDATE"}), userUuid)
+		if err != nil {
+			return err
+		}
+		previousEmail = user.Email
+		wasActive = user.IsActive
+		if err := applyChanges(&user); err != nil {
+			return err
+		}
+		if user.Email == "" {
+			return fmt.Errorf("%w: userName is required", ErrSCIMInvalidValue)
+		}
+		if user.Email != previousEmail && ifUserEmailAlreadyPresent(user.Email) {
+			return fmt.Errorf("%w: user %s", ErrSCIMUniqueness, user.Email)
+		}
+		err = tx.Model(&user).Select("Email", "Name", "ExternalID", "IsActive").Updates(models.User{
+			Email:      user.Email,
+			Name:       user.Name,
+			ExternalID: user.ExternalID,
+			IsActive:   user.IsActive,
+		}).Error
+		if err != nil {
+			return err
+		}
+		if wasActive && !user.IsActive {
+			revokedClients, err = revokeClientsOfUser(tx, user)
+			return err
+		}
+		return nil
+	})
+	if err != nil {
+		return models.SCIMUser{}, err
+	}
+	notifyGatewayOperationsWorker()
+
+	recordSCIMAuditTrail(models.AuditTrail{
+		Action:      models.AuditActionUserUpdate,
+		Description: fmt.Sprintf("user %s updated through scim with email %s, name %q and external id %q", previousEmail, user.Email, user.Name, user.ExternalID),
+		UserID:      &user.ID,
+	})
+	if wasActive && !user.IsActive {
+		recordSCIMAuditTrail(models.AuditTrail{
+			Action:      models.AuditActionUserDeactivate,
+			Description: fmt.Sprintf("user %s deactivated through scim with its %d active clients", user.Email, len(revokedClients)),
+			UserID:      &user.ID,
+		})
+		recordDeprovisionedClientAuditTrails(user, revokedClients)
+	}
+	if !wasActive && user.IsActive {
+		recordSCIMAuditTrail(models.AuditTrail{
+			Action:      models.AuditActionUserActivate,
+			Description: fmt.Sprintf("user %s activated through scim", user.Email),
+			UserID:      &user.ID,
+		})
+	}
+	return scimUserFromUser(user), nil
+}
+
+// patchSCIMUser applies a patch operation to the user. Attributes the controller doesn't keep are
+// ignored so that identity providers sending their whole schema are not rejected.
+func patchSCIMUser(user *models.User, operation models.SCIMPatchOperation) error {
+	op := strings.ToLower(operation.Op)
+	if op != "add" && op != "replace" && op != "remove" {
+		return fmt.Errorf("%w: unsupported op %s", ErrSCIMInvalidValue, operation.Op)
+	}
+	if operation.Path == "" {
+		if op == "remove" {
+			return fmt.Errorf("%w: remove requires a path", ErrSCIMInvalidPath)
+		}
+		values, ok := operation.Value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%w: an object is expected without a path", ErrSCIMInvalidValue)
+		}
+		for path, value := range values {
+			if err := setSCIMUserAttribute(user, scimAttributePath(path, models.SCIMSchemaUser), value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	path := scimAttributePath(operation.Path, models.SCIMSchemaUser)
+	if op == "remove" {
+		switch path {
+		case "externalid":
+			user.ExternalID = ""
+		case "displayname", "name", "name.formatted":
+			user.Name = ""
+		case "username", "active":
+			return fmt.Errorf("%w: %s can't be removed", ErrSCIMInvalidPath, operation.Path)
+		}
+		return nil
+	}
+	return setSCIMUserAttribute(user, path, operation.Value)
+}
+
+func setSCIMUserAttribute(user *models.User, path string, value any) error {
+	var err error
+	switch path {
+	case "active":
+		user.IsActive, err = scimBoolValue(value)
+	case "username":
+		user.Email, err = scimStringValue(value)
+	case "externalid":
+		user.ExternalID, err = scimStringValue(value)
+	case "displayname", "name.formatted":
+		user.Name, err = scimStringValue(value)
+	case "name":
+		var scimName models.SCIMName
+		if err = decodeSCIMValue(value, &scimName); err == nil {
+			user.Name = scimUserDisplayName(models.SCIMUser{Name: &scimName})
+		}
+	}
+	return err
+}
+
+func ListSCIMGroups(request models.SCIMListRequest) (models.SCIMListResponse, error) {
+	var groups []models.Group
+	response, dbClient, err := scimListQuery(database.DB.Model(&models.Group{}), request, scimGroupFilterAttributes)
+	if err != nil || response.ItemsPerPage == 0 {
+		return response, err
+	}
+	includeMembers := !strings.Contains(strings.ToLower(request.ExcludedAttributes), "members")
+	if includeMembers {
+		dbClient = dbClient.Preload("Users")
+	}
+	if err := dbClient.Find(&groups).Error; err != nil {
+		return response, err
+	}
+	for _, group := range groups {
+		response.Resources = append(response.Resources, scimGroupFromGroup(group))
+	}
+	response.ItemsPerPage = len(groups)
+	return response, nil
+}
+
+func GetSCIMGroup(groupUuid string) (models.SCIMGroup, error) {
+	group, err := getSCIMGroup(database.DB, groupUuid)
+	if err != nil {
+		return models.SCIMGroup{}, err
+	}
+	return scimGroupFromGroup(group), nil
+}
+
+// CreateSCIMGroup provisions a group with its members, routes and lifetime caps are left to the admin.
+func CreateSCIMGroup(scimGroup models.SCIMGroup) (models.SCIMGroup, error) {
+	if scimGroup.DisplayName == "" {
+		return scimGroup, fmt.Errorf("%w: displayName is required", ErrSCIMInvalidValue)
+	}
+	if err := checkSCIMGroupName(database.DB, scimGroup.DisplayName, 0); err != nil {
+		return scimGroup, err
+	}
+	group := models.Group{
+		UUID:       uuid.NewString(),
+		Name:       scimGroup.DisplayName,
+		ExternalID: scimGroup.ExternalID,
+	}
+	var memberAuditTrails []models.AuditTrail
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&group).Error; err != nil {
+			return err
+		}
+		var err error
+		memberAuditTrails, err = syncSCIMGroupMembers(tx, group, scimMemberUuids(scimGroup.Members))
+		return err
+	})
+	if err != nil {
+		return scimGroup, err
+	}
+	notifyGatewayOperationsWorker()
+
+	recordSCIMAuditTrail(models.AuditTrail{
+		Action:      models.AuditActionGroupCreate,
+		Description: fmt.Sprintf("group %s provisioned through scim", group.Name),
+		GroupID:     &group.ID,
+	})
+	for _, auditTrail := range memberAuditTrails {
+		recordSCIMAuditTrail(auditTrail)
+	}
+	return GetSCIMGroup(group.UUID)
+}
+
+// ReplaceSCIMGroup sets the name and the members of the group to the given ones.
+func ReplaceSCIMGroup(groupUuid string, scimGroup models.SCIMGroup) (models.SCIMGroup, error) {
+	if scimGroup.DisplayName == "" {
+		return scimGroup, fmt.Errorf("%w: displayName is required", ErrSCIMInvalidValue)
+	}
+	return updateSCIMGroup(groupUuid, func(group *models.Group, memberUuids map[string]bool) error {
+		group.Name = scimGroup.DisplayName
+		group.ExternalID = scimGroup.ExternalID
+		clear(memberUuids)
+		for _, memberUuid := range scimMemberUuids(scimGroup.Members) {
+			memberUuids[memberUuid] = true
+		}
+		return nil
+	})
+}
+
+func PatchSCIMGroup(groupUuid string, patchRequest models.SCIMPatchRequest) (models.SCIMGroup, error) {
+	return updateSCIMGroup(groupUuid, func(group *models.Group, memberUuids map[string]bool) error {
+		for _, operation := range patchRequest.Operations {
+			if err := patchSCIMGroup(group, memberUuids, operation); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func DeleteSCIMGroup(groupUuid string) error {
+	group, err := getSCIMGroup(database.DB, groupUuid)
+	if err != nil {
+		return err
+	}
+	var clientAuditTrails []models.AuditTrail
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		clientAuditTrails, err = deleteGroup(tx, group)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	notifyGatewayOperationsWorker()
+	recordSCIMAuditTrail(models.AuditTrail{
+		Action:      models.AuditActionGroupDelete,
+		Description: fmt.Sprintf("group %s deprovisioned through scim", group.Name),
+		GroupID:     &group.ID,
+	})
+	for _, auditTrail := range clientAuditTrails {
+		recordSCIMAuditTrail(auditTrail)
+	}
+	return nil
+}
+
+// updateSCIMGroup applies the changes to the group and to the set of the uuids of its members.
+func updateSCIMGroup(groupUuid string, applyChanges func(group *models.Group, memberUuids map[string]bool) error) (models.SCIMGroup, error) {
+	var group models.Group
+	var previousName string
+	var memberAuditTrails []models.AuditTrail
+	// as for users, the group is locked and only the attributes kept for scim are written
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		group, err = getSCIMGroup(tx.Clauses(clause.Locking{Strength: "UPDATE"}), groupUuid)
+		if err != nil {
+			return err
+		}
+		previousName = group.Name
+		memberUuids := map[string]bool{}
+		for _, groupUser := range group.Users {
+			memberUuids[groupUser.UUID] = true
+		}
+		if err := applyChanges(&group, memberUuids); err != nil {
+			return err
+		}
+		if group.Name == "" {
+			return fmt.Errorf("%w: displayName is required", ErrSCIMInvalidValue)
+		}
+		if group.Name != previousName {
+			if err := checkSCIMGroupName(tx, group.Name, group.ID); err != nil {
+				return err
+			}
+		}
+
+		err = tx.Model(&group).Select("Name", "ExternalID").Updates(models.Group{
+			Name:       group.Name,
+			ExternalID: group.ExternalID,
+		}).Error
+		if err != nil {
+			return err
+		}
+		var uuids []string
+		for memberUuid := range memberUuids {
+			uuids = append(uuids, memberUuid)
+		}
+		memberAuditTrails, err = syncSCIMGroupMembers(tx, group, uuids)
+		return err
+	})
+	if err != nil {
+		return models.SCIMGroup{}, err
+	}
+	notifyGatewayOperationsWorker()
+
+	if group.Name != previousName {
+		recordSCIMAuditTrail(models.AuditTrail{
+			Action:      models.AuditActionGroupUpdate,
+			Description: fmt.Sprintf("group %s renamed to %s through scim", previousName, group.Name),
+			GroupID:     &group.ID,
+		})
+	}
+	for _, auditTrail := range memberAuditTrails {
+		recordSCIMAuditTrail(auditTrail)
+	}
+	return GetSCIMGroup(group.UUID)
+}
+
+func patchSCIMGroup(group *models.Group, memberUuids map[string]bool, operation models.SCIMPatchOperation) error {
+	op := strings.ToLower(operation.Op)
+	if op != "add" && op != "replace" && op != "remove" {
+		return fmt.Errorf("%w: unsupported op %s", ErrSCIMInvalidValue, operation.Op)
+	}
+	if operation.Path == "" {
+		if op == "remove" {
+			return fmt.Errorf("%w: remove requires a path", ErrSCIMInvalidPath)
+		}
+		values, ok := operation.Value.(map[string]any)
+		if !ok {
+			return fmt.Errorf("%w: an object is expected without a path", ErrSCIMInvalidValue)
+		}
+		for path, value := range values {
+			if err := patchSCIMGroupAttribute(group, memberUuids, op, scimAttributePath(path, models.SCIMSchemaGroup), value); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	path := scimAttributePath(operation.Path, models.SCIMSchemaGroup)
+	// a single member is removed with a value filter, members[value eq "<id>"]
+	if strings.HasPrefix(path, "members[") && strings.HasSuffix(path, "]") {
+		if op != "remove" {
+			return fmt.Errorf("%w: %s", ErrSCIMInvalidPath, operation.Path)
+		}
+		valueFilter := operation.Path[strings.Index(operation.Path, "[")+1 : strings.LastIndex(operation.Path, "]")]
+		expressions, err := parseSCIMFilter(valueFilter)
+		if err != nil {
+			return err
+		}
+		if len(expressions) != 1 || strings.ToLower(expressions[0].attribute) != "value" || expressions[0].operator != "eq" {
+			return fmt.Errorf("%w: %s", ErrSCIMInvalidPath, operation.Path)
+		}
+		delete(memberUuids, expressions[0].value)
+		return nil
+	}
+	return patchSCIMGroupAttribute(group, memberUuids, op, path, operation.Value)
+}
+
+func patchSCIMGroupAttribute(group *models.Group, memberUuids map[string]bool, op, path string, value any) error {
+	var err error
+	switch path {
+	case "displayname":
+		if op == "remove" {
+			return fmt.Errorf("%w: displayName can't be removed", ErrSCIMInvalidPath)
+		}
+		group.Name, err = scimStringValue(value)
+	case "externalid":
+		group.ExternalID = ""
+		if op != "remove" {
+			group.ExternalID, err = scimStringValue(value)
+		}
+	case "members":
+		var members []models.SCIMMultiValuedAttribute
+		if value != nil {
+			if _, ok := value.(map[string]any); ok {
+				value = []any{value}
+			}
+			if err := decodeSCIMValue(value, &members); err != nil {
+				return err
+			}
+		}
+		if op == "replace" || (op == "remove" && value == nil) {
+			clear(memberUuids)
+		}
+		for _, memberUuid := range scimMemberUuids(members) {
+			if op == "remove" {
+				delete(memberUuids, memberUuid)
+			} else {
+				memberUuids[memberUuid] = true
+			}
+		}
+	}
+	return err
+}
+
+// syncSCIMGroupMembers makes the scim provisioned users with the given uuids the members of the group,
+// the members the admin added are kept. It returns the audit trails of the users added and removed and
+// of the clients revoked with the removals.
+func syncSCIMGroupMembers(tx *gorm.DB, group models.Group, memberUuids []string) ([]models.AuditTrail, error) {
+	var currentMembers []models.User
+	if err := tx.Model(&group).Association("Users").Find(&currentMembers); err != nil {
+		return nil, err
+	}
+	var members []models.User
+	if len(memberUuids) > 0 {
+		if err := tx.Where("uuid IN ? AND scim_provisioned = ?", memberUuids, true).Find(&members).Error; err != nil {
+			return nil, err
+		}
+	}
+	if len(members) != len(memberUuids) {
+		return nil, fmt.Errorf("%w: members should be ids of existing users", ErrSCIMInvalidValue)
+	}
+
+	memberUuidSet := map[string]bool{}
+	for _, memberUuid := range memberUuids {
+		memberUuidSet[memberUuid] = true
+	}
+	currentMemberUuidSet := map[string]bool{}
+	var auditTrails, clientAuditTrails []models.AuditTrail
+	for _, currentMember := range currentMembers {
+		currentMemberUuidSet[currentMember.UUID] = true
+		// members added by the admin are left to the admin
+		if memberUuidSet[currentMember.UUID] || !currentMember.SCIMProvisioned {
+			continue
+		}
+		if err := tx.Model(&group).Association("Users").Delete(&currentMember); err != nil {
+			return nil, err
+		}
+		auditTrails = append(auditTrails, models.AuditTrail{
+			Action:      models.AuditActionGroupRemoveUser,
+			Description: fmt.Sprintf("user %s removed from group %s through scim", currentMember.Email, group.Name),
+			UserID:      &currentMember.ID,
+			GroupID:     &group.ID,
+		})
+		revokedClientAuditTrails, err := revokeClientsOfRemovedGroupMember(tx, group, currentMember, "through scim")
+		if err != nil {
+			return nil, err
+		}
+		clientAuditTrails = append(clientAuditTrails, revokedClientAuditTrails...)
+	}
+	for _, member := range members {
+		if currentMemberUuidSet[member.UUID] {
+			continue
+		}
+		if err := tx.Model(&group).Association("Users").Append(&member); err != nil {
+			return nil, err
+		}
+		auditTrails = append(auditTrails, models.AuditTrail{
+			Action:      models.AuditActionGroupAddUser,
+			Description: fmt.Sprintf("user %s added to group %s through scim", member.Email, group.Name),
+			UserID:      &member.ID,
+			GroupID:     &group.ID,
+		})
+	}
+
+	var changedUserIDs []uint
+	for _, auditTrail := range auditTrails {
+		changedUserIDs = append(changedUserIDs, *auditTrail.UserID)
+	}
+	if err := enqueueGroupMemberPeerUpdates(tx, group, changedUserIDs); err != nil {
+		return nil, err
+	}
+	return append(auditTrails, clientAuditTrails...), nil
+}
+
+// revokeClientsOfUser revokes the active clients of the user on every gateway.
+func revokeClientsOfUser(tx *gorm.DB, user models.User) ([]models.Client, error) {
+	var clients []models.Client
+	if err := tx.Where("user_id = ? AND is_active = ?", user.ID, true).Find(&clients).Error; err != nil {
+		return nil, err
+	}
+	return revokeClients(tx, clients)
+}
+
+func recordDeprovisionedClientAuditTrails(user models.User, clients []models.Client) {
+	for _, client := range clients {
+		recordSCIMAuditTrail(models.AuditTrail{
+			Action:       models.AuditActionClientDelete,
+			Description:  fmt.Sprintf("client %s with ip %s deleted with the deprovisioning of user %s", client.UUID, client.AllocatedIP, user.Email),
+			UserID:       &client.UserID,
+			VpnGatewayID: &client.VpnGatewayID,
+			ClientID:     &client.ID,
+		})
+	}
+}
+
+func recordSCIMAuditTrail(auditTrail models.AuditTrail) {
+	auditTrail.ActorType = models.AuditActorSCIM
+	recordAuditTrail("", auditTrail)
+}
+
+// checkSCIMGroupName rejects a name already used by a group other than the one with the given id
+func checkSCIMGroupName(db *gorm.DB, name string, groupID uint) error {
+	var count int64
+	if err := db.Model(&models.Group{}).Where("name = ? AND id <> ?", name, groupID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return fmt.Errorf("%w: group %s", ErrSCIMUniqueness, name)
+	}
+	return nil
+}
+
+func getSCIMUser(db *gorm.DB, userUuid string) (models.User, error) {
+	var user models.User
+	err := db.Preload("Groups").Where("uuid = ? AND scim_provisioned = ?", userUuid, true).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return user, fmt.Errorf("%w: user %s", ErrSCIMNotFound, userUuid)
+	}
+	return user, err
+}
+
+func getSCIMGroup(db *gorm.DB, groupUuid string) (models.Group, error) {
+	var group models.Group
+	err := db.Preload("Users").Where("uuid = ?", groupUuid).First(&group).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return group, fmt.Errorf("%w: group %s", ErrSCIMNotFound, groupUuid)
+	}
+	return group, err
+}
+
+// scimListQuery applies the filter and the pagination of the request, the returned response holds the
+// total and the page size.
+func scimListQuery(dbClient *gorm.DB, request models.SCIMListRequest, attributes map[string]scimFilterAttribute) (models.SCIMListResponse, *gorm.DB, error) {
+	response := models.SCIMListResponse{
+		Schemas:   []string{models.SCIMSchemaListResponse},
+		Resources: []any{},
+	}
+	startIndex := request.StartIndex
+	if startIndex < 1 {
+		startIndex = 1
+	}
+	count := defaultSCIMPageSize
+	if request.Count != nil {
+		count = max(*request.Count, 0)
+	}
+	count = min(count, maxSCIMPageSize)
+
+	if request.Filter != "" {
+		expressions, err := parseSCIMFilter(request.Filter)
+		if err != nil {
+			return response, nil, err
+		}
+		for _, expression := range expressions {
+			dbClient, err = applySCIMFilterExpression(dbClient, expression, attributes)
+			if err != nil {
+				return response, nil, err
+			}
+		}
+	}
+
+	var total int64
+	if err := dbClient.Count(&total).Error; err != nil {
+		return response, nil, err
+	}
+	response.TotalResults = int(total)
+	response.StartIndex = startIndex
+	response.ItemsPerPage = count
+	return response, dbClient.Order("id").Offset(startIndex - 1).Limit(count), nil
+}
+
+// parseSCIMFilter parses attribute expressions joined by and, e.g. userName eq "jane@example.com".
+// Or, not and grouping are not supported.
+func parseSCIMFilter(filter string) ([]scimFilterExpression, error) {
+	var expressions []scimFilterExpression
+	rest := filter
+	for {
+		var expression scimFilterExpression
+		var operator string
+		expression.attribute, rest = nextSCIMFilterToken(rest)
+		operator, rest = nextSCIMFilterToken(rest)
+		expression.operator = strings.ToLower(operator)
+		if expression.attribute == "" || expression.operator == "" {
+			return nil, fmt.Errorf("%w: %s", ErrSCIMInvalidFilter, filter)
+		}
+		if expression.operator != "pr" {
+			rest = strings.TrimLeft(rest, " ")
+			if strings.HasPrefix(rest, `"`) {
+				quotedValue, err := strconv.QuotedPrefix(rest)
+				if err != nil {
+					return nil, fmt.Errorf("%w: %s", ErrSCIMInvalidFilter, filter)
+				}
+				expression.value, _ = strconv.Unquote(quotedValue)
+				expression.quoted = true
+				rest = rest[len(quotedValue):]
+			} else {
+				expression.value, rest = nextSCIMFilterToken(rest)
+				if expression.value == "" {
+					return nil, fmt.Errorf("%w: %s", ErrSCIMInvalidFilter, filter)
+				}
+			}
+		}
+		expressions = append(expressions, expression)
+
+		if strings.TrimSpace(rest) == "" {
+			return expressions, nil
+		}
+		var logicalOperator string
+		logicalOperator, rest = nextSCIMFilterToken(rest)
+		if !strings.EqualFold(logicalOperator, "and") {
+			return nil, fmt.Errorf("%w: only and is supported between expressions", ErrSCIMInvalidFilter)
+		}
+	}
+}
+
+func nextSCIMFilterToken(filter string) (string, string) {
+	filter = strings.TrimLeft(filter, " ")
+	end := strings.IndexByte(filter, ' ')
+	if end < 0 {
+		return filter, ""
+	}
+	return filter[:end], filter[end:]
+}
+
+func applySCIMFilterExpression(dbClient *gorm.DB, expression scimFilterExpression, attributes map[string]scimFilterAttribute) (*gorm.DB, error) {
+	attribute, ok := attributes[scimAttributePath(expression.attribute, models.SCIMSchemaUser, models.SCIMSchemaGroup)]
+	if !ok {
+		return nil, fmt.Errorf("%w: attribute %s can't be filtered on", ErrSCIMInvalidFilter, expression.attribute)
+	}
+	column := attribute.column
+
+	if expression.operator == "pr" {
+		if attribute.boolean {
+			return dbClient.Where(column + " IS NOT NULL"), nil
+		}
+		return dbClient.Where(column + " IS NOT NULL AND " + column + " <> ''"), nil
+	}
+	if attribute.boolean {
+		value, err := strconv.ParseBool(expression.value)
+		if err != nil || expression.quoted || (expression.operator != "eq" && expression.operator != "ne") {
+			return nil, fmt.Errorf("%w: %s expects eq or ne with true or false", ErrSCIMInvalidFilter, expression.attribute)
+		}
+		if expression.operator == "ne" {
+			value = !value
+		}
+		return dbClient.Where(column+" = ?", value), nil
+	}
+	if !expression.quoted {
+		return nil, fmt.Errorf("%w: %s expects a quoted string", ErrSCIMInvalidFilter, expression.attribute)
+	}
+
+	value := expression.value
+	if !attribute.caseExact {
+		column = "LOWER(" + column + ")"
+		value = strings.ToLower(value)
+	}
+	likeValue := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(value)
+	switch expression.operator {
+	case "eq":
+		return dbClient.Where(column+" = ?", value), nil
+	case "ne":
+		return dbClient.Where(column+" <> ?", value), nil
+	case "co":
+		return dbClient.Where(column+" LIKE ?", "%"+likeValue+"%"), nil
+	case "sw":
+		return dbClient.Where(column+" LIKE ?", likeValue+"%"), nil
+	case "ew":
+		return dbClient.Where(column+" LIKE ?", "%"+likeValue), nil
+	}
+	return nil, fmt.Errorf("%w: unsupported operator %s", ErrSCIMInvalidFilter, expression.operator)
+}
+
+// scimAttributePath lowercases the attribute path and drops the schema urn it may be prefixed with.
+func scimAttributePath(path string, schemas ...string) string {
+	path = strings.ToLower(strings.TrimSpace(path))
+	for _, schema := range schemas {
+		path = strings.TrimPrefix(path, strings.ToLower(schema)+":")
+	}
+	return path
+}
+
+func scimStringValue(value any) (string, error) {
+	stringValue, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("%w: string expected", ErrSCIMInvalidValue)
+	}
+	return stringValue, nil
+}
+
+// scimBoolValue also accepts the "True" and "False" strings some identity providers send.
+func scimBoolValue(value any) (bool, error) {
+	switch boolValue := value.(type) {
+	case bool:
+		return boolValue, nil
+	case string:
+		parsedValue, err := strconv.ParseBool(strings.ToLower(boolValue))
+		if err == nil {
+			return parsedValue, nil
+		}
+	}
+	return false, fmt.Errorf("%w: boolean expected", ErrSCIMInvalidValue)
+}
+
+func decodeSCIMValue(value any, target any) error {
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrSCIMInvalidValue, err)
+	}
+	if err := json.Unmarshal(valueJSON, target); err != nil {
+		return fmt.Errorf("%w: %v", ErrSCIMInvalidValue, err)
+	}
+	return nil
+}
+
+func scimMemberUuids(members []models.SCIMMultiValuedAttribute) []string {
+	var memberUuids []string
+	seen := map[string]bool{}
+	for _, member := range members {
+		if member.Value == "" || seen[member.Value] {
+			continue
+		}
+		seen[member.Value] = true
+		memberUuids = append(memberUuids, member.Value)
+	}
+	return memberUuids
+}
+
+// scimUserDisplayName is the name kept for the user, the displayName first and then the name.
+func scimUserDisplayName(scimUser models.SCIMUser) string {
+	if scimUser.DisplayName != "" {
+		return scimUser.DisplayName
+	}
+	if scimUser.Name == nil {
+		return ""
+	}
+	if scimUser.Name.Formatted != "" {
+		return scimUser.Name.Formatted
+	}
+	return strings.TrimSpace(scimUser.Name.GivenName + " " + scimUser.Name.FamilyName)
+}
+
+func scimUserFromUser(user models.User) models.SCIMUser {
+	active := user.IsActive
+	scimUser := models.SCIMUser{
+		Schemas:     []string{models.SCIMSchemaUser},
+		ID:          user.UUID,
+		ExternalID:  user.ExternalID,
+		UserName:    user.Email,
+		DisplayName: user.Name,
+		Emails:      []models.SCIMMultiValuedAttribute{{Value: user.Email, Type: "work", Primary: true}},
+		Active:      &active,
+		Groups:      []models.SCIMMultiValuedAttribute{},
+		Meta:        scimMeta("User", "Users", user.UUID, user.CreatedAt, user.UpdatedAt),
+	}
+	if user.Name != "" {
+		scimUser.Name = &models.SCIMName{Formatted: user.Name}
+	}
+	for _, group := range user.Groups {
+		scimUser.Groups = append(scimUser.Groups, models.SCIMMultiValuedAttribute{
+			Value:   group.UUID,
+			Display: group.Name,
+			Ref:     scimLocation("Groups", group.UUID),
+		})
+	}
+	return scimUser
+}
+
+func scimGroupFromGroup(group models.Group) models.SCIMGroup {
+	scimGroup := models.SCIMGroup{
+		Schemas:     []string{models.SCIMSchemaGroup},
+		ID:          group.UUID,
+		ExternalID:  group.ExternalID,
+		DisplayName: group.Name,
+		Members:     []models.SCIMMultiValuedAttribute{},
+		Meta:        scimMeta("Group", "Groups", group.UUID, group.CreatedAt, group.UpdatedAt),
+	}
+	for _, groupUser := range group.Users {
+		scimGroup.Members = append(scimGroup.Members, models.SCIMMultiValuedAttribute{
+			Value:   groupUser.UUID,
+			Display: groupUser.Email,
+			Ref:     scimLocation("Users", groupUser.UUID),
+		})
+	}
+	return scimGroup
+}
+
+func scimMeta(resourceType, endpoint, id string, created, lastModified time.Time) *models.SCIMMeta {
+	return &models.SCIMMeta{
+		ResourceType: resourceType,
+		Created:      created,
+		LastModified: lastModified,
+		Location:     scimLocation(endpoint, id),
+	}
+}
+
+func scimLocation(endpoint, id string) string {
+	return fmt.Sprintf(config.SCIMURLTemplate, config.ControllerDomain, endpoint, id)
+}
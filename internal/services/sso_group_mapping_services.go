@@ -0,0 +1,195 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/leetsecure/qryptic-controller/internal/database"
+	"github.com/leetsecure/qryptic-controller/internal/models"
+	"gorm.io/gorm"
+)
+
+func CreateSSOGroupMapping(actorUuid, ssoConfigUuid string, request models.SSOGroupMappingCreateRequest) (models.SSOGroupMapping, error) {
+	var ssoGroupMapping models.SSOGroupMapping
+	var ssoConfig models.SSOConfig
+	if err := database.DB.Where("uuid = ?", ssoConfigUuid).First(&ssoConfig).Error; err != nil {
+		return ssoGroupMapping, err
+	}
+	group, exists, err := getGroupFromUuid(request.GroupUuid)
+	if err != nil {
+		return ssoGroupMapping, err
+	}
+	if !exists {
+		return ssoGroupMapping, errors.New("group with given uuid not present")
+	}
+
+	var count int64
+	err = database.DB.Model(&models.SSOGroupMapping{}).
+		Where("sso_config_id = ? AND claim_value = ? AND group_id = ?", ssoConfig.ID, request.ClaimValue, group.ID).
+		Count(&count).Error
+	if err != nil {
+		return ssoGroupMapping, err
+	}
+	if count > 0 {
+		return ssoGroupMapping, fmt.Errorf("claim value %s already mapped to group %s", request.ClaimValue, group.Name)
+	}
+
+	ssoGroupMapping = models.SSOGroupMapping{
+		UUID:        uuid.NewString(),
+		SSOConfigID: ssoConfig.ID,
+		ClaimValue:  request.ClaimValue,
+		GroupID:     group.ID,
+	}
+	if err := database.DB.Create(&ssoGroupMapping).Error; err != nil {
+		return ssoGroupMapping, err
+	}
+	ssoGroupMapping.Group = &group
+
+	recordAuditTrail(actorUuid, models.AuditTrail{
+		Action:      models.AuditActionSSOGroupMappingAdd,
+		Description: fmt.Sprintf("claim value %s of sso config %s for provider %s mapped to group %s", ssoGroupMapping.ClaimValue, ssoConfig.UUID, ssoConfig.Provider, group.Name),
+		GroupID:     &group.ID,
+	})
+	return ssoGroupMapping, nil
+}
+
+func ListSSOGroupMappings(ssoConfigUuid string) ([]models.SSOGroupMapping, error) {
+	var ssoConfig models.SSOConfig
+	if err := database.DB.Where("uuid = ?", ssoConfigUuid).First(&ssoConfig).Error; err != nil {
+		return nil, err
+	}
+	var ssoGroupMappings []models.SSOGroupMapping
+	err := database.DB.Preload("Group").
+		Where("sso_config_id = ?", ssoConfig.ID).
+		Order("id").
+		Find(&ssoGroupMappings).Error
+	if err != nil {
+		return nil, err
+	}
+	return ssoGroupMappings, nil
+}
+
+// DeleteSSOGroupMapping stops syncing the group from the claim value, the current members stay in the group.
+func DeleteSSOGroupMapping(actorUuid, ssoConfigUuid, ssoGroupMappingUuid string) error {
+	var ssoConfig models.SSOConfig
+	if err := database.DB.Where("uuid = ?", ssoConfigUuid).First(&ssoConfig).Error; err != nil {
+		return err
+	}
+	var ssoGroupMapping models.SSOGroupMapping
+	err := database.DB.Preload("Group").
+		Where("uuid = ? AND sso_config_id = ?", ssoGroupMappingUuid, ssoConfig.ID).
+		First(&ssoGroupMapping).Error
+	if err != nil {
+		return err
+	}
+	if err := database.DB.Delete(&ssoGroupMapping).Error; err != nil {
+		return err
+	}
+
+	description := fmt.Sprintf("mapping of claim value %s of sso config %s for provider %s deleted", ssoGroupMapping.ClaimValue, ssoConfig.UUID, ssoConfig.Provider)
+	if ssoGroupMapping.Group != nil {
+		description += " for group " + ssoGroupMapping.Group.Name
+	}
+	recordAuditTrail(actorUuid, models.AuditTrail{
+		Action:      models.AuditActionSSOGroupMappingDelete,
+		Description: description,
+		GroupID:     &ssoGroupMapping.GroupID,
+	})
+	return nil
+}
+
+// syncSSOGroupMembers adds the user to the groups mapped from the values of its group claim and removes
+// it from the other groups mapped by the sso config, revoking its clients on the gateways it no longer
+// reaches. Groups the config doesn't map are left alone, and so are all groups when the provider left
+// the claim out.
+func syncSSOGroupMembers(user models.User, ssoIdentity models.SSOIdentity) error {
+	if ssoIdentity.SSOConfigID == 0 || !ssoIdentity.GroupsClaimed {
+		return nil
+	}
+	var ssoGroupMappings []models.SSOGroupMapping
+	err := database.DB.Preload("Group").Where("sso_config_id = ?", ssoIdentity.SSOConfigID).Find(&ssoGroupMappings).Error
+	if err != nil {
+		return err
+	}
+	if len(ssoGroupMappings) == 0 {
+		return nil
+	}
+
+	// a group mapped from several claim values keeps the user when any of them is claimed
+	mappedGroups := map[uint]models.Group{}
+	claimedGroupIDs := map[uint]bool{}
+	for _, ssoGroupMapping := range ssoGroupMappings {
+		if ssoGroupMapping.Group == nil {
+			continue
+		}
+		mappedGroups[ssoGroupMapping.GroupID] = *ssoGroupMapping.Group
+		if slices.Contains(ssoIdentity.Groups, ssoGroupMapping.ClaimValue) {
+			claimedGroupIDs[ssoGroupMapping.GroupID] = true
+		}
+	}
+	var mappedGroupIDs []uint
+	for groupID := range mappedGroups {
+		mappedGroupIDs = append(mappedGroupIDs, groupID)
+	}
+	var memberGroupIDs []uint
+	err = database.DB.Table("group_users").
+		Where("user_id = ? AND group_id IN ?", user.ID, mappedGroupIDs).
+		Pluck("group_id", &memberGroupIDs).Error
+	if err != nil {
+		return err
+	}
+
+	var auditTrails []models.AuditTrail
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		for groupID, group := range mappedGroups {
+			isMember := slices.Contains(memberGroupIDs, groupID)
+			switch {
+			case claimedGroupIDs[groupID] && !isMember:
+				if err := tx.Model(&group).Association("Users").Append(&user); err != nil {
+					return err
+				}
+				auditTrails = append(auditTrails, models.AuditTrail{
+					Action:      models.AuditActionGroupAddUser,
+					Description: fmt.Sprintf("user %s added to group %s from the group claim at sso login", user.Email, group.Name),
+					UserID:      &user.ID,
+					GroupID:     &group.ID,
+				})
+			case !claimedGroupIDs[groupID] && isMember:
+				if err := tx.Model(&group).Association("Users").Delete(&user); err != nil {
+					return err
+				}
+				auditTrails = append(auditTrails, models.AuditTrail{
+					Action:      models.AuditActionGroupRemoveUser,
+					Description: fmt.Sprintf("user %s removed from group %s missing from the group claim at sso login", user.Email, group.Name),
+					UserID:      &user.ID,
+					GroupID:     &group.ID,
+				})
+				revokedClientAuditTrails, err := revokeClientsOfRemovedGroupMember(tx, group, user, "at sso login")
+				if err != nil {
+					return err
+				}
+				auditTrails = append(auditTrails, revokedClientAuditTrails...)
+			default:
+				continue
+			}
+			if err := enqueueGroupMemberPeerUpdates(tx, group, []uint{user.ID}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	if len(auditTrails) == 0 {
+		return nil
+	}
+	notifyGatewayOperationsWorker()
+
+	for _, auditTrail := range auditTrails {
+		recordAuditTrail("", auditTrail)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/leetsecure/qryptic-controller/internal/database"
+	"github.com/leetsecure/qryptic-controller/internal/models"
+	"gorm.io/gorm"
+)
+
+// UpdateSSOJITProvisioning sets whether the sso config provisions the users of its domain on their
+// first login, and the role and groups they are given. The default groups replace the previous ones.
+// The default role can't be Admin, admins are promoted one by one.
+func UpdateSSOJITProvisioning(actorUuid, ssoConfigUuid string, request models.UpdateSSOJITProvisioningRequest) error {
+	var ssoConfig models.SSOConfig
+	if err := database.DB.Where("uuid = ?", ssoConfigUuid).First(&ssoConfig).Error; err != nil {
+		return err
+	}
+	defaultRole := request.DefaultRole
+	if defaultRole == "" {
+		defaultRole = models.UserRole
+	}
+	if defaultRole == models.AdminRole {
+		return errors.New("users provisioned just in time can't be given the Admin role")
+	}
+	defaultGroups := []*models.Group{}
+	var groupNames []string
+	for _, groupUuid := range request.DefaultGroupUuids {
+		group, exists, err := getGroupFromUuid(groupUuid)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("group with uuid %s not present", groupUuid)
+		}
+		defaultGroups = append(defaultGroups, &group)
+		groupNames = append(groupNames, group.Name)
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&ssoConfig).Select("JITProvisioning", "JITDefaultRole").Updates(models.SSOConfig{
+			JITProvisioning: *request.JITProvisioning,
+			JITDefaultRole:  defaultRole,
+		}).Error
+		if err != nil {
+			return err
+		}
+		return tx.Model(&ssoConfig).Association("JITDefaultGroups").Replace(defaultGroups)
+	})
+	if err != nil {
+		return err
+	}
+
+	recordAuditTrail(actorUuid, models.AuditTrail{
+		Action: models.AuditActionSSOJITConfigUpdate,
+		Description: fmt.Sprintf("just in time provisioning of sso config %s for provider %s and domain %s set to %t with role %s and groups [%s]",
+			ssoConfig.UUID, ssoConfig.Provider, ssoConfig.Domain, *request.JITProvisioning, defaultRole, strings.Join(groupNames, ", ")),
+	})
+	return nil
+}
+
+// provisionSSOUser creates the user signing in for the first time through an sso config provisioning
+// users just in time. The provider has to verify the email and the email has to be of the config domain,
+// google accounts also have to be managed by the domain since any account may use an address of it.
+func provisionSSOUser(ssoIdentity models.SSOIdentity) (models.User, error) {
+	var user models.User
+	var ssoConfig models.SSOConfig
+	if ssoIdentity.SSOConfigID == 0 {
+		return user, errors.New("email id not present")
+	}
+	err := database.DB.Preload("JITDefaultGroups").First(&ssoConfig, ssoIdentity.SSOConfigID).Error
+	if err != nil {
+		return user, err
+	}
+	if !ssoConfig.JITProvisioning {
+		return user, errors.New("email id not present")
+	}
+	if !ssoIdentity.EmailVerified {
+		return user, errors.New("email id not verified by the provider")
+	}
+	_, emailDomain, _ := strings.Cut(ssoIdentity.Email, "@")
+	if ssoConfig.Domain == "" || !strings.EqualFold(emailDomain, ssoConfig.Domain) {
+		return user, fmt.Errorf("email id not in the domain %s of the sso config", ssoConfig.Domain)
+	}
+	if ssoIssuerURL(ssoConfig) == googleIssuerURL && !strings.EqualFold(ssoIdentity.HostedDomain, ssoConfig.Domain) {
+		return user, fmt.Errorf("google account not managed by the domain %s of the sso config", ssoConfig.Domain)
+	}
+
+	// configs stored before the default role was restricted may still hold Admin
+	role := ssoConfig.JITDefaultRole
+	if role == "" || role == models.AdminRole {
+		role = models.UserRole
+	}
+	user = models.User{
+		UUID:  uuid.NewString(),
+		Email: ssoIdentity.Email,
+		Name:  ssoIdentity.Name,
+		Role:  role,
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			return err
+		}
+		for _, group := range ssoConfig.JITDefaultGroups {
+			if err := tx.Model(group).Association("Users").Append(&user); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return user, err
+	}
+
+	recordAuditTrail("", models.AuditTrail{
+		Action:      models.AuditActionUserJITCreate,
+		Description: fmt.Sprintf("user %s provisioned with role %s at first login through sso config %s for provider %s", user.Email, user.Role, ssoConfig.UUID, ssoConfig.Provider),
+		UserID:      &user.ID,
+	})
+	for _, group := range ssoConfig.JITDefaultGroups {
+		recordAuditTrail("", models.AuditTrail{
+			Action:      models.AuditActionGroupAddUser,
+			Description: fmt.Sprintf("user %s added to default group %s of sso config %s at first login", user.Email, group.Name, ssoConfig.UUID),
+			UserID:      &user.ID,
+			GroupID:     &group.ID,
+		})
+	}
+	return user, nil
+}
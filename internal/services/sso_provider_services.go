@@ -0,0 +1,283 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/coreos/go-oidc/v3/oidc"
+	"github.com/leetsecure/qryptic-controller/internal/database"
+	"github.com/leetsecure/qryptic-controller/internal/models"
+	"github.com/leetsecure/qryptic-controller/internal/utils/logger"
+	"golang.org/x/oauth2"
+)
+
+const googleIssuerURL = "https://accounts.google.com"
+
+// WebsiteSSOPlatform is the platform of the sso config used by the web login
+const WebsiteSSOPlatform = "Website"
+
+var defaultSSOScopes = []string{oidc.ScopeOpenID, "profile", "email"}
+
+var ssoHTTPClient = &http.Client{Timeout: 10 * time.Second}
+
+// oidcProviders caches the discovered providers by issuer url, keys are refreshed by go-oidc itself
+var oidcProviders sync.Map
+
+// ssoProvider is an OIDC provider set up from one of the sso configs of a provider name. ClientIDs holds
+// the client ids of every config of the provider, any of them is accepted as id token audience.
+type ssoProvider struct {
+	ssoConfig    models.SSOConfig
+	clientIDs    []string
+	oidcProvider *oidc.Provider
+	oauth2Config oauth2.Config
+}
+
+func ssoContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithTimeout(context.Background(), ssoHTTPClient.Timeout)
+	return oidc.ClientContext(ctx, ssoHTTPClient), cancel
+}
+
+func ssoIssuerURL(ssoConfig models.SSOConfig) string {
+	if ssoConfig.IssuerURL == "" && ssoConfig.Provider == "google" {
+		return googleIssuerURL
+	}
+	return ssoConfig.IssuerURL
+}
+
+// discoverOIDCProvider fetches the discovery document of the issuer once and caches the provider.
+func discoverOIDCProvider(issuerURL string) (*oidc.Provider, error) {
+	if issuerURL == "" {
+		return nil, errors.New("issuer url of the sso provider not set")
+	}
+	if oidcProvider, ok := oidcProviders.Load(issuerURL); ok {
+		return oidcProvider.(*oidc.Provider), nil
+	}
+	// the provider keeps the client of this context to refresh its keys
+	oidcProvider, err := oidc.NewProvider(oidc.ClientContext(context.Background(), ssoHTTPClient), issuerURL)
+	if err != nil {
+		return nil, fmt.Errorf("oidc discovery failed for %s: %w", issuerURL, err)
+	}
+	oidcProviders.Store(issuerURL, oidcProvider)
+	return oidcProvider, nil
+}
+
+// forgetOIDCProvider drops the cached provider of the issuer so that the next use discovers it again.
+func forgetOIDCProvider(issuerURL string) {
+	oidcProviders.Delete(issuerURL)
+}
+
+func enabledSSOConfigs(provider string) ([]models.SSOConfig, error) {
+	var ssoConfigs []models.SSOConfig
+	err := database.DB.Where("provider = ? AND enabled = ?", provider, "true").Order("id").Find(&ssoConfigs).Error
+	return ssoConfigs, err
+}
+
+// getSSOProvider sets up the provider from its config for the client id when given, else for the
+// platform, else from its first config.
+func getSSOProvider(provider, platform, clientID string) (*ssoProvider, error) {
+	ssoConfigs, err := enabledSSOConfigs(provider)
+	if err != nil {
+		return nil, err
+	}
+	selected := -1
+	var clientIDs []string
+	for i, ssoConfig := range ssoConfigs {
+		if ssoConfig.Protocol == models.SSOProtocolSAML {
+			continue
+		}
+		clientIDs = append(clientIDs, ssoConfig.ClientID)
+		if selected != -1 {
+			continue
+		}
+		if clientID != "" {
+			if ssoConfig.ClientID == clientID {
+				selected = i
+			}
+		} else if ssoConfig.Platform == platform {
+			selected = i
+		}
+	}
+	if len(clientIDs) == 0 {
+		return nil, errors.New("sso provider not allowed")
+	}
+	if selected == -1 {
+		if clientID != "" {
+			return nil, errors.New("client id not configured for the sso provider")
+		}
+		for i, ssoConfig := range ssoConfigs {
+			if ssoConfig.Protocol != models.SSOProtocolSAML {
+				selected = i
+				break
+			}
+		}
+	}
+	return newSSOProvider(ssoConfigs[selected], clientIDs)
+}
+
+func newSSOProvider(ssoConfig models.SSOConfig, clientIDs []string) (*ssoProvider, error) {
+	oidcProvider, err := discoverOIDCProvider(ssoIssuerURL(ssoConfig))
+	if err != nil {
+		return nil, err
+	}
+	scopes := ssoConfig.Scopes
+	if len(scopes) == 0 {
+		scopes = defaultSSOScopes
+	}
+	return &ssoProvider{
+		ssoConfig:    ssoConfig,
+		clientIDs:    clientIDs,
+		oidcProvider: oidcProvider,
+		oauth2Config: oauth2.Config{
+			ClientID:     ssoConfig.ClientID,
+			ClientSecret: ssoConfig.ClientSecret,
+			Endpoint:     oidcProvider.Endpoint(),
+			Scopes:       scopes,
+		},
+	}, nil
+}
+
+func (p *ssoProvider) authCodeURL(state, redirectURL string, opts ...oauth2.AuthCodeOption) string {
+	oauth2Config := p.oauth2Config
+	oauth2Config.RedirectURL = redirectURL
+	return oauth2Config.AuthCodeURL(state, opts...)
+}
+
+// exchangeForIdentity redeems the authorization code and returns the identity of the user, read from
+// the id token or else from the userinfo endpoint.
+func (p *ssoProvider) exchangeForIdentity(code, redirectURL, codeVerifier string) (models.SSOIdentity, error) {
+	ctx, cancel := ssoContext()
+	defer cancel()
+	oauth2Config := p.oauth2Config
+	oauth2Config.RedirectURL = redirectURL
+	var opts []oauth2.AuthCodeOption
+	if codeVerifier != "" {
+		opts = append(opts, oauth2.VerifierOption(codeVerifier))
+	}
+	token, err := oauth2Config.Exchange(ctx, code, opts...)
+	if err != nil {
+		return models.SSOIdentity{}, fmt.Errorf("failed to exchange code for tokens: %w", err)
+	}
+
+	if rawIDToken, ok := token.Extra("id_token").(string); ok && rawIDToken != "" {
+		claims, err := p.verifyIDToken(rawIDToken)
+		if err != nil {
+			return models.SSOIdentity{}, err
+		}
+		if _, ok := claims[p.emailClaim()]; ok {
+			return p.identityFromClaims(claims)
+		}
+	}
+
+	userInfo, err := p.oidcProvider.UserInfo(ctx, oauth2.StaticTokenSource(token))
+	if err != nil {
+		return models.SSOIdentity{}, fmt.Errorf("failed to fetch user info: %w", err)
+	}
+	claims := map[string]any{}
+	if err := userInfo.Claims(&claims); err != nil {
+		return models.SSOIdentity{}, err
+	}
+	return p.identityFromClaims(claims)
+}
+
+// verifyIDToken checks the signature, issuer, expiry and audience of the id token and returns its claims.
+func (p *ssoProvider) verifyIDToken(rawIDToken string) (map[string]any, error) {
+	ctx, cancel := ssoContext()
+	defer cancel()
+	// the audience is checked below against the client ids of every platform
+	verifier := p.oidcProvider.Verifier(&oidc.Config{SkipClientIDCheck: true})
+	idToken, err := verifier.Verify(ctx, rawIDToken)
+	if err != nil {
+		return nil, err
+	}
+	if !slices.ContainsFunc(idToken.Audience, func(audience string) bool {
+		return slices.Contains(p.clientIDs, audience)
+	}) {
+		return nil, errors.New("id token issued for another client")
+	}
+	claims := map[string]any{}
+	if err := idToken.Claims(&claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+func (p *ssoProvider) emailClaim() string {
+	if p.ssoConfig.EmailClaim == "" {
+		return "email"
+	}
+	return p.ssoConfig.EmailClaim
+}
+
+func (p *ssoProvider) groupClaim() string {
+	if p.ssoConfig.GroupClaim == "" {
+		return "groups"
+	}
+	return p.ssoConfig.GroupClaim
+}
+
+func (p *ssoProvider) identityFromClaims(claims map[string]any) (models.SSOIdentity, error) {
+	email, _ := claims[p.emailClaim()].(string)
+	if email == "" {
+		return models.SSOIdentity{}, fmt.Errorf("claim %s missing from the sso provider response", p.emailClaim())
+	}
+	// an explicit false is rejected, a missing claim only counts as verified when the config trusts
+	// the provider with it
+	verified, claimed := claims["email_verified"].(bool)
+	if claimed && !verified {
+		return models.SSOIdentity{}, errors.New("email not verified by the sso provider")
+	}
+	if !claimed {
+		verified = p.ssoConfig.TrustEmailClaim
+	}
+	// a missing group claim leaves the groups of the user as they are, unlike an empty one
+	groupClaim, groupsClaimed := claims[p.groupClaim()]
+	hostedDomain, _ := claims["hd"].(string)
+	name, _ := claims["name"].(string)
+	return models.SSOIdentity{
+		Email:         email,
+		Name:          name,
+		Groups:        claimValues(groupClaim),
+		GroupsClaimed: groupsClaimed,
+		HostedDomain:  hostedDomain,
+		SSOConfigID:   p.ssoConfig.ID,
+		EmailVerified: verified,
+	}, nil
+}
+
+// claimValues reads a claim holding a list of strings or a single string.
+func claimValues(claim any) []string {
+	var values []string
+	switch claim := claim.(type) {
+	case string:
+		values = append(values, claim)
+	case []any:
+		for _, value := range claim {
+			if stringValue, ok := value.(string); ok {
+				values = append(values, stringValue)
+			}
+		}
+	}
+	return values
+}
+
+// initializeSSOProviders runs the discovery of the enabled providers at startup so that
+// misconfigured issuers show up in the logs, failed providers are retried on first use.
+func initializeSSOProviders() error {
+	log := logger.Default()
+	var ssoConfigs []models.SSOConfig
+	if err := database.DB.Where("enabled = ? AND protocol = ?", "true", models.SSOProtocolOIDC).Find(&ssoConfigs).Error; err != nil {
+		return err
+	}
+	log.Infof("Number of sso configs : %d", len(ssoConfigs))
+	for _, ssoConfig := range ssoConfigs {
+		if _, err := discoverOIDCProvider(ssoIssuerURL(ssoConfig)); err != nil {
+			log.Errorf("sso provider %s for platform %s not available | error : %s", ssoConfig.Provider, ssoConfig.Platform, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,251 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/leetsecure/qryptic-controller/internal/models"
+)
+
+const testOIDCKeyID = "test-key"
+
+// testOIDCIssuer serves the discovery document and the keys of an OIDC issuer signing with key.
+type testOIDCIssuer struct {
+	server      *httptest.Server
+	key         *rsa.PrivateKey
+	discoveries atomic.Int32
+}
+
+func newTestOIDCIssuer(t *testing.T) *testOIDCIssuer {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	issuer := &testOIDCIssuer{key: key}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(w http.ResponseWriter, r *http.Request) {
+		issuer.discoveries.Add(1)
+		json.NewEncoder(w).Encode(map[string]any{
+			"issuer":                                issuer.server.URL,
+			"authorization_endpoint":                issuer.server.URL + "/auth",
+			"token_endpoint":                        issuer.server.URL + "/token",
+			"userinfo_endpoint":                     issuer.server.URL + "/userinfo",
+			"jwks_uri":                              issuer.server.URL + "/keys",
+			"id_token_signing_alg_values_supported": []string{"RS256"},
+		})
+	})
+	mux.HandleFunc("/keys", func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]any{
+			"keys": []map[string]string{{
+				"kty": "RSA",
+				"alg": "RS256",
+				"use": "sig",
+				"kid": testOIDCKeyID,
+				"n":   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+				"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+			}},
+		})
+	})
+	issuer.server = httptest.NewServer(mux)
+	t.Cleanup(func() {
+		issuer.server.Close()
+		forgetOIDCProvider(issuer.server.URL)
+	})
+	return issuer
+}
+
+// idToken returns an id token of the issuer for the audience, signed with key, extra claims
+// are added to the standard ones.
+func (issuer *testOIDCIssuer) idToken(t *testing.T, key *rsa.PrivateKey, audience string, extra map[string]any) string {
+	t.Helper()
+	claims := jwt.MapClaims{
+		"iss": issuer.server.URL,
+		"sub": "subject",
+		"aud": audience,
+		"iat": time.Now().Unix(),
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+	for name, value := range extra {
+		claims[name] = value
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = testOIDCKeyID
+	rawIDToken, err := token.SignedString(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rawIDToken
+}
+
+func (issuer *testOIDCIssuer) provider(t *testing.T, ssoConfig models.SSOConfig, clientIDs ...string) *ssoProvider {
+	t.Helper()
+	ssoConfig.IssuerURL = issuer.server.URL
+	ssoConfig.ClientID = clientIDs[0]
+	provider, err := newSSOProvider(ssoConfig, clientIDs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return provider
+}
+
+func TestVerifyIDTokenSignature(t *testing.T) {
+	issuer := newTestOIDCIssuer(t)
+	provider := issuer.provider(t, models.SSOConfig{}, "client")
+
+	if _, err := provider.verifyIDToken(issuer.idToken(t, issuer.key, "client", nil)); err != nil {
+		t.Fatalf("token signed by the issuer rejected: %s", err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.verifyIDToken(issuer.idToken(t, otherKey, "client", nil)); err == nil {
+		t.Fatal("token signed by another key accepted")
+	}
+}
+
+func TestVerifyIDTokenAudience(t *testing.T) {
+	issuer := newTestOIDCIssuer(t)
+	provider := issuer.provider(t, models.SSOConfig{}, "web-client", "mobile-client")
+
+	if _, err := provider.verifyIDToken(issuer.idToken(t, issuer.key, "mobile-client", nil)); err != nil {
+		t.Fatalf("token for a client of the provider rejected: %s", err)
+	}
+	if _, err := provider.verifyIDToken(issuer.idToken(t, issuer.key, "other-client", nil)); err == nil {
+		t.Fatal("token for another client accepted")
+	}
+}
+
+func TestIdentityFromClaimsEmailVerified(t *testing.T) {
+	issuer := newTestOIDCIssuer(t)
+
+	tests := []struct {
+		name            string
+		trustEmailClaim bool
+		claims          map[string]any
+		wantErr         bool
+		wantVerified    bool
+	}{
+		{name: "missing", claims: map[string]any{"email": "user@example.com"}},
+		{name: "missing trusted", trustEmailClaim: true, claims: map[string]any{"email": "user@example.com"}, wantVerified: true},
+		{name: "verified", claims: map[string]any{"email": "user@example.com", "email_verified": true}, wantVerified: true},
+		{name: "not verified", claims: map[string]any{"email": "user@example.com", "email_verified": false}, wantErr: true},
+		{name: "not verified trusted", trustEmailClaim: true, claims: map[string]any{"email": "user@example.com", "email_verified": false}, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			provider := issuer.provider(t, models.SSOConfig{TrustEmailClaim: test.trustEmailClaim}, "client")
+			claims, err := provider.verifyIDToken(issuer.idToken(t, issuer.key, "client", test.claims))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ssoIdentity, err := provider.identityFromClaims(claims)
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("identity accepted")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			// an identity without a verified email can't sign in
+			if ssoIdentity.EmailVerified != test.wantVerified {
+				t.Fatalf("email verified %t, want %t", ssoIdentity.EmailVerified, test.wantVerified)
+			}
+		})
+	}
+}
+
+func TestIdentityFromClaimsCustomEmailClaim(t *testing.T) {
+	issuer := newTestOIDCIssuer(t)
+	provider := issuer.provider(t, models.SSOConfig{EmailClaim: "upn"}, "client")
+
+	claims, err := provider.verifyIDToken(issuer.idToken(t, issuer.key, "client", map[string]any{
+		"email": "other@example.com",
+		"upn":   "user@example.com",
+		"name":  "Test User",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ssoIdentity, err := provider.identityFromClaims(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ssoIdentity.Email != "user@example.com" {
+		t.Fatalf("email %s read from the wrong claim", ssoIdentity.Email)
+	}
+	if ssoIdentity.Name != "Test User" {
+		t.Fatalf("name %q read from the claims", ssoIdentity.Name)
+	}
+
+	claims, err = provider.verifyIDToken(issuer.idToken(t, issuer.key, "client", map[string]any{"email": "user@example.com"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := provider.identityFromClaims(claims); err == nil {
+		t.Fatal("identity accepted without the email claim")
+	}
+}
+
+func TestIdentityFromClaimsGroups(t *testing.T) {
+	issuer := newTestOIDCIssuer(t)
+	provider := issuer.provider(t, models.SSOConfig{GroupClaim: "roles"}, "client")
+
+	tests := []struct {
+		name        string
+		claims      map[string]any
+		wantClaimed bool
+		wantGroups  int
+	}{
+		{name: "missing", claims: map[string]any{"email": "user@example.com"}},
+		{name: "empty", claims: map[string]any{"email": "user@example.com", "roles": []string{}}, wantClaimed: true},
+		{name: "present", claims: map[string]any{"email": "user@example.com", "roles": []string{"vpn", "admins"}}, wantClaimed: true, wantGroups: 2},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			claims, err := provider.verifyIDToken(issuer.idToken(t, issuer.key, "client", test.claims))
+			if err != nil {
+				t.Fatal(err)
+			}
+			ssoIdentity, err := provider.identityFromClaims(claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			// the groups of the user are only synced when the claim was sent, even empty
+			if ssoIdentity.GroupsClaimed != test.wantClaimed || len(ssoIdentity.Groups) != test.wantGroups {
+				t.Fatalf("groups %v claimed %t, want %d groups claimed %t", ssoIdentity.Groups, ssoIdentity.GroupsClaimed, test.wantGroups, test.wantClaimed)
+			}
+		})
+	}
+}
+
+func TestForgetOIDCProvider(t *testing.T) {
+	issuer := newTestOIDCIssuer(t)
+	for range 2 {
+		if _, err := discoverOIDCProvider(issuer.server.URL); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if discoveries := issuer.discoveries.Load(); discoveries != 1 {
+		t.Fatalf("issuer discovered %d times, want once", discoveries)
+	}
+	forgetOIDCProvider(issuer.server.URL)
+	if _, err := discoverOIDCProvider(issuer.server.URL); err != nil {
+		t.Fatal(err)
+	}
+	if discoveries := issuer.discoveries.Load(); discoveries != 2 {
+		t.Fatalf("issuer discovered %d times after being forgotten, want twice", discoveries)
+	}
+}
@@ -2,12 +2,14 @@ package services
 
 import (
 	"errors"
+	"fmt"
 
 	"github.com/google/uuid"
 	"github.com/leetsecure/qryptic-controller/internal/database"
 	"github.com/leetsecure/qryptic-controller/internal/models"
 	"github.com/leetsecure/qryptic-controller/internal/utils/auth"
 	"github.com/leetsecure/qryptic-controller/internal/utils/logger"
+	"gorm.io/gorm"
 )
 
 func ifUserEmailAlreadyPresent(email string) bool {
@@ -16,7 +18,7 @@ func ifUserEmailAlreadyPresent(email string) bool {
 	return result.RowsAffected > 0
 }
 
-func RegisterUser(emailID, password, role string, isPasswordSet bool) error {
+func RegisterUser(actorUuid, emailID, password, role string, isPasswordSet bool, maxClientLifetime int) error {
 	var user models.User
 	var err error
 	user.UUID = uuid.NewString()
@@ -38,34 +40,83 @@ func RegisterUser(emailID, password, role string, isPasswordSet bool) error {
 	}
 
 	user.Role = models.UserRoleEnum(role)
+	user.MaxClientLifetime = maxClientLifetime
 	err = database.DB.Save(&user).Error
 	if err != nil {
 		return err
 	}
+	recordAuditTrail(actorUuid, models.AuditTrail{
+		Action:      models.AuditActionUserCreate,
+		Description: fmt.Sprintf("user %s created with role %s and max client lifetime %d minutes", user.Email, user.Role, user.MaxClientLifetime),
+		UserID:      &user.ID,
+	})
 	return nil
 }
 
-func BulkRegisterUser(users []models.RegisterUserRequest) error {
+func BulkRegisterUser(actorUuid string, users []models.RegisterUserRequest) error {
 	var errs error
 	for _, user := range users {
-		err := RegisterUser(user.EmailId, user.Password, string(user.Role), *user.IsPasswordSet)
+		err := RegisterUser(actorUuid, user.EmailId, user.Password, string(user.Role), *user.IsPasswordSet, user.MaxClientLifetime)
 		errs = errors.Join(err)
 	}
 	return errs
 }
 
-func DeleteUser(userUuid string) error {
+func DeleteUser(actorUuid, userUuid string) error {
 	log := logger.Default()
-	var user models.User
-	err := database.DB.Where("uuid = ?", userUuid).Delete(&user).Error
+	user, exists, err := getUserFromUuid(userUuid)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("user with given uuid not present")
+	}
+	// the active clients of the user are revoked on every gateway before the user is deleted
+	var revokedClients []models.Client
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		revokedClients, err = revokeClientsOfUser(tx, user)
+		if err != nil {
+			return err
+		}
+		return deleteUser(tx, user)
+	})
 	if err != nil {
 		log.Errorf("Error in deleting user with uuid : %s", userUuid)
 		return err
 	}
+	notifyGatewayOperationsWorker()
+
+	recordAuditTrail(actorUuid, models.AuditTrail{
+		Action:      models.AuditActionUserDelete,
+		Description: fmt.Sprintf("user %s deleted with its %d active clients", user.Email, len(revokedClients)),
+		UserID:      &user.ID,
+	})
+	for _, client := range revokedClients {
+		recordAuditTrail(actorUuid, models.AuditTrail{
+			Action:       models.AuditActionClientDelete,
+			Description:  fmt.Sprintf("client %s with ip %s deleted with user %s", client.UUID, client.AllocatedIP, user.Email),
+			UserID:       &client.UserID,
+			VpnGatewayID: &client.VpnGatewayID,
+			ClientID:     &client.ID,
+		})
+	}
 	return nil
 }
 
-func UpdateUser(userUuid, emailID, newPassword, role string, isPasswordSet bool) error {
+// deleteUser deletes the user along with its access policies, the reserved addresses of the user
+// return to the pool.
+func deleteUser(tx *gorm.DB, user models.User) error {
+	if err := tx.Where("user_id = ?", user.ID).Delete(&models.IPReservation{}).Error; err != nil {
+		return err
+	}
+	if err := tx.Where("user_id = ?", user.ID).Delete(&models.AccessPolicy{}).Error; err != nil {
+		return err
+	}
+	return tx.Delete(&user).Error
+}
+
+func UpdateUser(actorUuid, userUuid, emailID, newPassword, role string, isPasswordSet bool, maxClientLifetime *int) error {
 	log := logger.Default()
 	var user models.User
 	user, exists, err := getUserFromUuid(userUuid)
@@ -89,12 +140,20 @@ func UpdateUser(userUuid, emailID, newPassword, role string, isPasswordSet bool)
 	if role != "" {
 		user.Role = models.UserRoleEnum(role)
 	}
+	if maxClientLifetime != nil {
+		user.MaxClientLifetime = *maxClientLifetime
+	}
 
 	err = database.DB.Save(&user).Error
 	if err != nil {
 		log.Errorf("Error in updating  user : %s", userUuid)
 		return err
 	}
+	recordAuditTrail(actorUuid, models.AuditTrail{
+		Action:      models.AuditActionUserUpdate,
+		Description: fmt.Sprintf("user %s updated with role %s, password set %t and max client lifetime %d minutes", user.Email, user.Role, user.IsPasswordSet, user.MaxClientLifetime),
+		UserID:      &user.ID,
+	})
 	return nil
 }
 
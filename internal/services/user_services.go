@@ -3,17 +3,18 @@ package services
 import (
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/leetsecure/qryptic-controller/internal/config"
 	"github.com/leetsecure/qryptic-controller/internal/database"
-	"github.com/leetsecure/qryptic-controller/internal/externalcomms"
 	"github.com/leetsecure/qryptic-controller/internal/models"
-	"github.com/leetsecure/qryptic-controller/internal/utils/auth"
+	"github.com/leetsecure/qryptic-controller/internal/utils/helper"
 	"github.com/leetsecure/qryptic-controller/internal/utils/logger"
 	"github.com/leetsecure/qryptic-controller/internal/utils/wireguard"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // User
@@ -74,6 +75,108 @@ func IfUsersHasAccessToGatewayV2(userUuid string, vpnGatewayUuid string) (bool,
 	return count > 0, nil
 }
 
+// defaultClientRoutes make a full tunnel on gateways without advertised routes
+var defaultClientRoutes = []string{"0.0.0.0/0"}
+var defaultDualStackClientRoutes = []string{"0.0.0.0/0", "::/0"}
+
+// accessGrantsOfVpnGateway reports whether the user was granted the gateway directly, and otherwise
+// returns the groups of the user the gateway was granted to.
+func accessGrantsOfVpnGateway(db *gorm.DB, user models.User, vpnGateway models.VpnGateway) (bool, []models.Group, error) {
+	var count int64
+	err := db.Table("user_vpngateways").
+		Where("user_id = ? AND vpn_gateway_id = ?", user.ID, vpnGateway.ID).
+		Count(&count).Error
+	if err != nil {
+		return false, nil, err
+	}
+	if count > 0 {
+		return true, nil, nil
+	}
+
+	var groups []models.Group
+	err = db.
+		Joins("JOIN group_vpngateways ON groups.id = group_vpngateways.group_id").
+		Joins("JOIN group_users ON groups.id = group_users.group_id").
+		Where("group_users.user_id = ? AND group_vpngateways.vpn_gateway_id = ?", user.ID, vpnGateway.ID).
+		Find(&groups).Error
+	if err != nil {
+		return false, nil, err
+	}
+	return false, groups, nil
+}
+
+// clientLifetime is the shortest of the caps of the gateway and the user, the cap of the groups granting
+// the gateway and the requested lifetime. The global client expiry is the default when none of the
+// caps is set. The groups are alternative grants, so the most permissive of them applies, and none
+// when the gateway was granted directly.
+func clientLifetime(user models.User, vpnGateway models.VpnGateway, requestedLifetime time.Duration) (time.Duration, error) {
+	var lifetime time.Duration
+	limitLifetime := func(maxLifetime time.Duration) {
+		if maxLifetime > 0 && (lifetime == 0 || maxLifetime < lifetime) {
+			lifetime = maxLifetime
+		}
+	}
+	limitLifetime(time.Duration(vpnGateway.MaxClientLifetime) * time.Minute)
+	limitLifetime(time.Duration(user.MaxClientLifetime) * time.Minute)
+
+	direct, groups, err := accessGrantsOfVpnGateway(database.DB, user, vpnGateway)
+	if err != nil {
+		return 0, err
+	}
+	if !direct && len(groups) > 0 {
+		var groupLifetime time.Duration
+		for _, group := range groups {
+			if group.MaxClientLifetime == 0 {
+				groupLifetime = 0
+				break
+			}
+			groupLifetime = max(groupLifetime, time.Duration(group.MaxClientLifetime)*time.Minute)
+		}
+		limitLifetime(groupLifetime)
+	}
+	if lifetime == 0 {
+		lifetime = config.ClientExpiry
+	}
+	limitLifetime(requestedLifetime)
+	return lifetime, nil
+}
+
+// clientRoutesForVpnGateway returns the routes of the gateway available to the user. Direct access grants
+// all of them, access through groups grants the union of the routes of the groups, each narrowed to the gateway.
+func clientRoutesForVpnGateway(user models.User, vpnGateway models.VpnGateway) ([]string, error) {
+	gatewayRoutes := vpnGateway.Routes
+	if len(gatewayRoutes) == 0 {
+		gatewayRoutes = defaultClientRoutes
+		if vpnGateway.VpnCIDRv6 != "" {
+			gatewayRoutes = defaultDualStackClientRoutes
+		}
+	}
+
+	direct, groups, err := accessGrantsOfVpnGateway(database.DB, user, vpnGateway)
+	if err != nil {
+		return nil, err
+	}
+	if direct {
+		return gatewayRoutes, nil
+	}
+
+	var routes []string
+	for _, group := range groups {
+		if len(group.Routes) == 0 {
+			return gatewayRoutes, nil
+		}
+		routes = append(routes, helper.IntersectCIDRs(gatewayRoutes, group.Routes)...)
+	}
+	routes, err = helper.NormalizeCIDRs(routes)
+	if err != nil {
+		return nil, err
+	}
+	if len(routes) == 0 {
+		return nil, errors.New("no routes of the vpn gateway are allowed for the groups of the user")
+	}
+	return routes, nil
+}
+
 func ListAccessibleVPNs(userUuid string) ([]models.VpnGatewayUserResponse, error) {
 	var vpnGateways []models.VpnGatewayUserResponse
 	var user models.User
@@ -182,7 +285,11 @@ func GetUserWithGatewaysAndClients(userUuid string) (models.User, error) {
 	return user, nil
 }
 
-func CreateVpnGatewayUserClient(userUuid string, vpnGatewayUuid string) (models.WGClientConfig, bool, error) {
+// CreateVpnGatewayUserClient issues a client on the gateway. Clients of a registered device use its
+// public key and the returned config carries no private key, the others get a generated key pair.
+// A requested lifetime of 0 gives the longest lifetime allowed. A preshared key is generated when the
+// gateway requires one or when the client asks for it.
+func CreateVpnGatewayUserClient(userUuid, vpnGatewayUuid, deviceUuid string, requestedLifetime time.Duration, withPresharedKey bool) (models.WGClientConfig, bool, error) {
 
 	var wgClientConfig models.WGClientConfig
 	// check if user has access for given vpn gateway
@@ -197,27 +304,48 @@ func CreateVpnGatewayUserClient(userUuid string, vpnGatewayUuid string) (models.
 	if err != nil || !exists {
 		return wgClientConfig, false, err
 	}
+	if vpnGateway.HealthStatus == models.GatewayHealthDown {
+		return wgClientConfig, false, ErrVpnGatewayDown
+	}
 
 	user, exists, err := getUserFromUuid(userUuid)
 	if err != nil || !exists {
 		return wgClientConfig, false, err
 	}
+	if !user.IsActive {
+		return wgClientConfig, false, errors.New("user is deactivated")
+	}
 
-	// look for IP from IP Pool of VPN Gateway
-	ipPool, err := getFirstAvailableIP(vpnGateway.ID)
+	routes, err := clientRoutesForVpnGateway(user, vpnGateway)
 	if err != nil {
 		return wgClientConfig, false, err
 	}
 
 	//Create new client with expiry time
-	expiryTime := time.Now().Add(config.ClientExpiry)
-
-	publicKey, privateKey, err := wireguard.GenerateWireguardPublicPrivateKeys()
+	lifetime, err := clientLifetime(user, vpnGateway, requestedLifetime)
 	if err != nil {
 		return wgClientConfig, false, err
 	}
+	expiryTime := time.Now().Add(lifetime)
 
-	allocatedIP := fmt.Sprintf("%s/32", ipPool.IP)
+	var device *models.Device
+	var publicKey, privateKey string
+	if deviceUuid == "" {
+		if config.RequireClientDevices {
+			return wgClientConfig, false, errors.New("clients are only issued to registered devices, register a device first")
+		}
+		publicKey, privateKey, err = wireguard.GenerateWireguardPublicPrivateKeys()
+		if err != nil {
+			return wgClientConfig, false, err
+		}
+	}
+	var presharedKey string
+	if withPresharedKey || vpnGateway.PresharedKeyPolicy != models.PresharedKeyOptional {
+		presharedKey, err = wireguard.GeneratePresharedKey()
+		if err != nil {
+			return wgClientConfig, false, err
+		}
+	}
 
 	client := &models.Client{
 		UUID:             uuid.NewString(),
@@ -225,65 +353,100 @@ func CreateVpnGatewayUserClient(userUuid string, vpnGatewayUuid string) (models.
 		VpnGatewayID:     vpnGateway.ID,
 		ExpiryTime:       expiryTime,
 		IsActive:         true,
-		AllocatedIP:      allocatedIP,
-		AllowedIPs:       []string{"0.0.0.0/0"}[0],
+		AllowedIPs:       strings.Join(routes, ","),
 		ClientPublicKey:  publicKey,
 		ClientPrivateKey: privateKey,
 		DnsServer:        vpnGateway.DnsServer,
-		PresharedKey:     "",
+		PresharedKey:     presharedKey,
 	}
 
-	if err := database.DB.Create(client).Error; err != nil {
+	tx := database.DB.Begin()
+	// revoked clients release their addresses before the new one is allocated
+	revokedClients, err := enforceClientLimits(tx, vpnGateway, user)
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, ErrClientLimitReached) {
+			recordClientLimitAuditTrails(user, vpnGateway, nil, err)
+		}
 		return wgClientConfig, false, err
 	}
-
-	if err := database.DB.Model(&models.IPPool{}).Where("id = ?", ipPool.ID).Update("assigned", true).Error; err != nil {
+	// the device is checked once the limits are enforced, revoking the oldest clients can free it
+	if deviceUuid != "" {
+		device, err = getDeviceOfUserForVpnGateway(tx, user, vpnGateway, deviceUuid)
+		if err != nil {
+			tx.Rollback()
+			return wgClientConfig, false, err
+		}
+		client.ClientPublicKey = device.PublicKey
+		client.DeviceID = &device.ID
+	}
+	ipAllocation, err := allocateClientIP(tx, vpnGateway, user.ID, client.DeviceID)
+	if err != nil {
+		tx.Rollback()
 		return wgClientConfig, false, err
 	}
+	client.AllocatedIP = fmt.Sprintf("%s/32", ipAllocation.IP)
+	if err := createClientWithIPv6(tx, vpnGateway, client); err != nil {
+		tx.Rollback()
+		return wgClientConfig, false, err
+	}
+	if err := tx.Model(ipAllocation).Update("client_id", client.ID).Error; err != nil {
+		tx.Rollback()
+		return wgClientConfig, false, err
+	}
+	if device != nil {
+		if err := tx.Model(device).Update("last_seen_at", time.Now()).Error; err != nil {
+			tx.Rollback()
+			return wgClientConfig, false, err
+		}
+	}
 
-	var wgServerPeerConfigs []models.WGServerPeerConfig
-
-	wgServerPeerConfigs = append(wgServerPeerConfigs, models.WGServerPeerConfig{
-		ClientAllowedIPs: ipPool.IP,
-		ClientPublicKey:  client.ClientPublicKey,
-		PresharedKey:     "",
-	})
+	wgServerPeerConfigs, err := wgServerPeerConfigsForClients(tx, vpnGateway, []*models.Client{client})
+	if err != nil {
+		tx.Rollback()
+		return wgClientConfig, false, err
+	}
 
 	//send new client to vpn gateway
-	err = addNewClientsRequestToVpnGateway(vpnGateway, wgServerPeerConfigs)
-	if err != nil {
+	if err := enqueueGatewayOperation(tx, vpnGateway.ID, models.GatewayOperationAddPeers, wgServerPeerConfigs); err != nil {
+		tx.Rollback()
 		return wgClientConfig, false, err
 	}
 
-	//allocate the IP to IP allocation table
+	if err := tx.Commit().Error; err != nil {
+		return wgClientConfig, false, err
+	}
+	notifyGatewayOperationsWorker()
+	recordClientLimitAuditTrails(user, vpnGateway, revokedClients, nil)
 
 	// send the client details to user
 
 	wgClientConfig.WGClientInterfaceConfig.ClientPrivateKey = client.ClientPrivateKey
 	wgClientConfig.WGClientInterfaceConfig.AllowedIpAddress = client.AllocatedIP
+	wgClientConfig.WGClientInterfaceConfig.IPv6Address = client.AllocatedIPv6
 	wgClientConfig.WGClientInterfaceConfig.DnsServer = client.DnsServer
-	wgClientConfig.WGClientPeerConfig.AllowedIPs = []string{client.AllowedIPs}
-	wgClientConfig.WGClientPeerConfig.PersistantAlive = 25
-	wgClientConfig.WGClientPeerConfig.PresharedKey = ""
+	wgClientConfig.WGClientPeerConfig.AllowedIPs = routes
+	wgClientConfig.WGClientPeerConfig.PersistentKeepalive = 25
+	wgClientConfig.WGClientPeerConfig.PersistantAlive = wgClientConfig.WGClientPeerConfig.PersistentKeepalive
+	wgClientConfig.WGClientPeerConfig.PresharedKey = client.PresharedKey
 	wgClientConfig.WGClientPeerConfig.ServerPublicKey = vpnGateway.ServerPublicKey
 	wgClientConfig.WGClientPeerConfig.VpnGatewayIP = vpnGateway.IpAddress
 	wgClientConfig.WGClientPeerConfig.VpnGatewayDomain = vpnGateway.Domain
 	wgClientConfig.WGClientPeerConfig.VpnGatewayPort = vpnGateway.Port
 	wgClientConfig.ExpiryTime = client.ExpiryTime
 	wgClientConfig.ClientUuid = client.UUID
-	return wgClientConfig, true, nil
-}
-
-func getFirstAvailableIP(vpnGatewayID uint) (*models.IPPool, error) {
-	var ipPool models.IPPool
-	result := database.DB.Where("vpn_gateway_id = ? AND assigned = ?", vpnGatewayID, false).First(&ipPool)
-	if result.Error != nil {
-		return nil, result.Error
-	}
-	if result.RowsAffected == 0 {
-		return nil, errors.New("new ip not available, try clearing expired clients")
+	if device != nil {
+		wgClientConfig.DeviceUuid = device.UUID
 	}
-	return &ipPool, nil
+
+	recordAuditTrail(userUuid, models.AuditTrail{
+		Action:       models.AuditActionClientCreate,
+		Description:  fmt.Sprintf("client %s created with ip %s on vpn gateway %s expiring at %s", client.UUID, clientAddresses(*client), vpnGateway.Name, client.ExpiryTime.Format(time.RFC3339)),
+		UserID:       &user.ID,
+		VpnGatewayID: &vpnGateway.ID,
+		ClientID:     &client.ID,
+	})
+	return wgClientConfig, true, nil
 }
 
 func ifUserHasAccessToClient(clientUuid string, userUuid string) (bool, error) {
@@ -310,6 +473,72 @@ func ifUserHasAccessToClient(clientUuid string, userUuid string) (bool, error) {
 	}
 }
 
+// RenewClient moves the expiry of an active client of the user within the lifetime limits, keeping
+// its key, address and peer on the gateway. The expiry is never moved earlier.
+func RenewClient(userUuid, clientUuid string, requestedLifetime time.Duration) (models.ClientRenewResponse, error) {
+	var response models.ClientRenewResponse
+	user, exists, err := getUserFromUuid(userUuid)
+	if err != nil {
+		return response, err
+	}
+	if !exists {
+		return response, errors.New("user with given uuid not present")
+	}
+	if !user.IsActive {
+		return response, errors.New("user is deactivated")
+	}
+
+	var client models.Client
+	err = database.DB.Preload("VpnGateway").Preload("Device").
+		Where("uuid = ? AND user_id = ? AND is_active = ?", clientUuid, user.ID, true).
+		First(&client).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return response, errors.New("user doesn't have access to given client uuid")
+	}
+	if err != nil {
+		return response, err
+	}
+	if client.ExpiryTime.Before(time.Now()) {
+		return response, errors.New("client expired, create a new one")
+	}
+	if client.Device != nil && client.Device.RevokedAt != nil {
+		return response, errors.New("device of the client is revoked")
+	}
+	if client.VpnGateway == nil {
+		return response, errors.New("vpn gateway of the client not present")
+	}
+	accessible, err := IfUsersHasAccessToGatewayV2(user.UUID, client.VpnGateway.UUID)
+	if err != nil {
+		return response, err
+	}
+	if !accessible {
+		return response, errors.New("user doesn't have access to the vpn gateway of the client anymore")
+	}
+
+	lifetime, err := clientLifetime(user, *client.VpnGateway, requestedLifetime)
+	if err != nil {
+		return response, err
+	}
+	response.ClientUuid = client.UUID
+	response.PreviousExpiryTime = client.ExpiryTime
+	response.ExpiryTime = client.ExpiryTime
+	if expiryTime := time.Now().Add(lifetime); expiryTime.After(client.ExpiryTime) {
+		response.ExpiryTime = expiryTime
+	}
+
+	if err := database.DB.Model(&client).Update("expiry_time", response.ExpiryTime).Error; err != nil {
+		return response, err
+	}
+	recordAuditTrail(userUuid, models.AuditTrail{
+		Action:       models.AuditActionClientRenew,
+		Description:  fmt.Sprintf("client %s on vpn gateway %s renewed from %s to %s", client.UUID, client.VpnGateway.Name, response.PreviousExpiryTime.Format(time.RFC3339), response.ExpiryTime.Format(time.RFC3339)),
+		UserID:       &user.ID,
+		VpnGatewayID: &client.VpnGatewayID,
+		ClientID:     &client.ID,
+	})
+	return response, nil
+}
+
 func DeleteClientFromUserAndVpnGatewayByUser(clientUuid string, userUuid string) error {
 	accessible, err := ifUserHasAccessToClient(clientUuid, userUuid)
 	if err != nil {
@@ -319,55 +548,104 @@ func DeleteClientFromUserAndVpnGatewayByUser(clientUuid string, userUuid string)
 		return errors.New("user doesn't have access to given client uuid")
 	}
 
-	err = DeleteClientFromUserAndVpnGateway(clientUuid)
+	err = DeleteClientFromUserAndVpnGateway(userUuid, clientUuid)
 	return err
 }
 
-// To be called by scheduler on expiryTime pass
-func DeleteExpiredClientsFromUserAndVpnGateway() error {
-	var expiredClients []models.Client
-	currentTime := time.Now()
-
-	err := database.DB.Preload("VpnGateway").Where("is_active = ? AND expiry_time < ?", true, currentTime).Find(&expiredClients).Error
+// revokeClients deactivates the clients still active, removes them from their gateways and releases
+// their addresses. Clients revoked meanwhile by a concurrent request are skipped, the revoked clients
+// are returned.
+func revokeClients(tx *gorm.DB, clients []models.Client) ([]models.Client, error) {
+	if len(clients) == 0 {
+		return nil, nil
+	}
+	var clientIDs []uint
+	for _, client := range clients {
+		clientIDs = append(clientIDs, client.ID)
+	}
+	var revokedClients []models.Client
+	err := tx.Model(&revokedClients).Clauses(clause.Returning{}).
+		Where("id IN ? AND is_active = ?", clientIDs, true).
+		Update("is_active", false).Error
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	for _, client := range expiredClients {
-		var wgServerPeerConfigs []models.WGServerPeerConfig
-		wgServerPeerConfigs = append(wgServerPeerConfigs, models.WGServerPeerConfig{
+	// group the peers by gateway so that every gateway gets a single delete request
+	wgServerPeerConfigs := map[uint][]models.WGServerPeerConfig{}
+	for _, client := range revokedClients {
+		wgServerPeerConfigs[client.VpnGatewayID] = append(wgServerPeerConfigs[client.VpnGatewayID], models.WGServerPeerConfig{
 			ClientPublicKey: client.ClientPublicKey,
 		})
-		if client.VpnGateway == nil {
-			continue
+	}
+
+	for vpnGatewayID, peers := range wgServerPeerConfigs {
+		//delete clients from vpn gateway
+		if err := enqueueGatewayOperation(tx, vpnGatewayID, models.GatewayOperationDeletePeers, peers); err != nil {
+			return nil, err
 		}
-		deleteClientsRequestFromVpnGateway(*client.VpnGateway, wgServerPeerConfigs) //delete client from vpn gateway
+	}
+
+	for _, client := range revokedClients {
 		//make IP available in IP pool
-		err = database.DB.Model(&models.IPPool{}).Where("ip = ? AND vpn_gateway_id = ?", client.AllocatedIP, client.VpnGatewayID).Update("assigned", false).Error
-		if err != nil {
-			return err
+		if err := releaseClientIP(tx, client); err != nil {
+			return nil, err
 		}
 	}
+	return revokedClients, nil
+}
+
+// DeleteExpiredClientsFromUserAndVpnGateway revokes every active client whose expiry time has passed.
+// It is run periodically by the scheduler and can also be triggered by an admin.
+func DeleteExpiredClientsFromUserAndVpnGateway(actorUuid string) error {
+	log := logger.Default()
+	var expiredClients []models.Client
+	currentTime := time.Now()
+
+	err := database.DB.Where("is_active = ? AND expiry_time < ?", true, currentTime).Find(&expiredClients).Error
+	if err != nil {
+		return err
+	}
+	if len(expiredClients) == 0 {
+		return nil
+	}
+	log.Infof("revoking %d expired clients", len(expiredClients))
 
-	err = database.DB.Model(&models.Client{}).Where("expiry_time < ?", currentTime).Update("is_active", false).Error
+	tx := database.DB.Begin()
+	expiredClients, err = revokeClients(tx, expiredClients)
 	if err != nil {
+		tx.Rollback()
 		return err
 	}
+	if err := tx.Commit().Error; err != nil {
+		return err
+	}
+	notifyGatewayOperationsWorker()
 
-	// deallocate the IP to IP allocation table
+	for _, client := range expiredClients {
+		recordAuditTrail(actorUuid, models.AuditTrail{
+			Action:       models.AuditActionClientExpire,
+			Description:  fmt.Sprintf("client %s with ip %s revoked after expiry at %s", client.UUID, client.AllocatedIP, client.ExpiryTime.Format(time.RFC3339)),
+			UserID:       &client.UserID,
+			VpnGatewayID: &client.VpnGatewayID,
+			ClientID:     &client.ID,
+		})
+	}
 	return nil
 }
 
-func DeleteClientFromUserAndVpnGateway(clientUuid string) error {
+func DeleteClientFromUserAndVpnGateway(actorUuid, clientUuid string) error {
 
 	//deactivate the client
 	var client models.Client
-	result := database.DB.Preload("VpnGateway").Where("uuid = ?", clientUuid).First(&client)
+	result := database.DB.Where("uuid = ?", clientUuid).First(&client)
 	if result.Error != nil {
 		return result.Error
 	}
-
-	client.IsActive = false
+	// the client is already revoked, its peer removal is queued
+	if !client.IsActive {
+		return nil
+	}
 
 	var wgServerPeerConfigs []models.WGServerPeerConfig
 
@@ -375,49 +653,41 @@ func DeleteClientFromUserAndVpnGateway(clientUuid string) error {
 		ClientPublicKey: client.ClientPublicKey,
 	})
 
-	deleteClientsRequestFromVpnGateway(*client.VpnGateway, wgServerPeerConfigs) //delete client from vpn gateway
-
-	database.DB.Save(&client)
-
-	//make IP available in IP pool
-	result = database.DB.Model(&models.IPPool{}).Where("ip = ? AND vpn_gateway_id = ?", client.AllocatedIP, client.VpnGatewayID).Update("assigned", false)
+	tx := database.DB.Begin()
+	// only the first of concurrent deletions deactivates the client
+	result = tx.Model(&client).Where("is_active = ?", true).Update("is_active", false)
 	if result.Error != nil {
+		tx.Rollback()
 		return result.Error
 	}
-
-	return nil
-}
-
-func addNewClientsRequestToVpnGateway(vpnGateway models.VpnGateway, wgServerPeerConfigs []models.WGServerPeerConfig) error {
-	log := logger.Default()
-	authToken, err := auth.CreateVpnGatewayToken(vpnGateway.UUID, vpnGateway.JwtSecretKey)
-	if err != nil {
-		return err
-	}
-	responseBody, responseStatusCode, err := externalcomms.AddNewPeerInVpnGateway(vpnGateway.Domain, authToken, wgServerPeerConfigs)
-	if err != nil {
+	if result.RowsAffected == 0 {
+		tx.Rollback()
 		return nil
 	}
-	log.Infof("response body of request to add peers : %s and status code is %d", responseBody, responseStatusCode)
-	if responseStatusCode != 200 {
-		return errors.New("request not fulfilled")
-	}
-	return nil
-}
 
-func deleteClientsRequestFromVpnGateway(vpnGateway models.VpnGateway, wgServerPeerConfigs []models.WGServerPeerConfig) error {
-	log := logger.Default()
-	authToken, err := auth.CreateVpnGatewayToken(vpnGateway.UUID, vpnGateway.JwtSecretKey)
-	if err != nil {
+	//delete client from vpn gateway
+	if err := enqueueGatewayOperation(tx, client.VpnGatewayID, models.GatewayOperationDeletePeers, wgServerPeerConfigs); err != nil {
+		tx.Rollback()
 		return err
 	}
-	responseBody, responseStatusCode, err := externalcomms.DeletePeerInVpnGateway(vpnGateway.Domain, authToken, wgServerPeerConfigs)
-	if err != nil {
-		return nil
+
+	//make IP available in IP pool
+	if err := releaseClientIP(tx, client); err != nil {
+		tx.Rollback()
+		return err
 	}
-	log.Infof("response body of request to delete peers : %s and status code is %d", responseBody, responseStatusCode)
-	if responseStatusCode != 200 {
-		return errors.New("request not fulfilled")
+
+	if err := tx.Commit().Error; err != nil {
+		return err
 	}
+	notifyGatewayOperationsWorker()
+
+	recordAuditTrail(actorUuid, models.AuditTrail{
+		Action:       models.AuditActionClientDelete,
+		Description:  fmt.Sprintf("client %s with ip %s deleted", client.UUID, client.AllocatedIP),
+		UserID:       &client.UserID,
+		VpnGatewayID: &client.VpnGatewayID,
+		ClientID:     &client.ID,
+	})
 	return nil
 }
@@ -4,20 +4,22 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"slices"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/leetsecure/qryptic-controller/internal/config"
 	"github.com/leetsecure/qryptic-controller/internal/database"
-	"github.com/leetsecure/qryptic-controller/internal/externalcomms"
 	"github.com/leetsecure/qryptic-controller/internal/models"
 	"github.com/leetsecure/qryptic-controller/internal/utils/auth"
+	"github.com/leetsecure/qryptic-controller/internal/utils/helper"
 	"github.com/leetsecure/qryptic-controller/internal/utils/logger"
 	"github.com/leetsecure/qryptic-controller/internal/utils/wireguard"
 	"gorm.io/gorm"
 )
 
 // Admin User
-func AddRemoveUsersInVpnGateway(action string, vpnGatewayUuid string, userUuids []string) error {
+func AddRemoveUsersInVpnGateway(actorUuid, action string, vpnGatewayUuid string, userUuids []string) error {
 	log := logger.Default()
 
 	// Start a transaction
@@ -34,6 +36,7 @@ func AddRemoveUsersInVpnGateway(action string, vpnGatewayUuid string, userUuids
 	for _, userUuid := range userUuids {
 		userUuidMap[userUuid] = false
 	}
+	var auditTrails []models.AuditTrail
 
 	if action == "remove" {
 		for _, vpnUser := range vpnGateway.Users {
@@ -43,6 +46,12 @@ func AddRemoveUsersInVpnGateway(action string, vpnGatewayUuid string, userUuids
 					tx.Rollback()
 					return err
 				}
+				auditTrails = append(auditTrails, models.AuditTrail{
+					Action:       models.AuditActionAccessRevokeUser,
+					Description:  fmt.Sprintf("user %s access to vpn gateway %s revoked", vpnUser.Email, vpnGateway.Name),
+					UserID:       &vpnUser.ID,
+					VpnGatewayID: &vpnGateway.ID,
+				})
 			}
 		}
 	} else if action == "add" {
@@ -60,6 +69,12 @@ func AddRemoveUsersInVpnGateway(action string, vpnGatewayUuid string, userUuids
 				tx.Rollback()
 				return err
 			}
+			auditTrails = append(auditTrails, models.AuditTrail{
+				Action:       models.AuditActionAccessGrantUser,
+				Description:  fmt.Sprintf("user %s granted access to vpn gateway %s", newVpnUser.Email, vpnGateway.Name),
+				UserID:       &newVpnUser.ID,
+				VpnGatewayID: &vpnGateway.ID,
+			})
 		}
 	} else {
 		tx.Rollback()
@@ -72,9 +87,12 @@ func AddRemoveUsersInVpnGateway(action string, vpnGatewayUuid string, userUuids
 		return err
 	}
 
+	for _, auditTrail := range auditTrails {
+		recordAuditTrail(actorUuid, auditTrail)
+	}
 	return nil
 }
-func AddRemoveGroupsInVpnGateway(action string, vpnGatewayUuid string, groupUuids []string) error {
+func AddRemoveGroupsInVpnGateway(actorUuid, action string, vpnGatewayUuid string, groupUuids []string) error {
 	log := logger.Default()
 
 	// Start a transaction
@@ -91,6 +109,7 @@ func AddRemoveGroupsInVpnGateway(action string, vpnGatewayUuid string, groupUuid
 	for _, groupUuid := range groupUuids {
 		groupUuidMap[groupUuid] = false
 	}
+	var auditTrails []models.AuditTrail
 
 	if action == "remove" {
 		for _, vpnGroup := range vpnGateway.Groups {
@@ -100,6 +119,12 @@ func AddRemoveGroupsInVpnGateway(action string, vpnGatewayUuid string, groupUuid
 					tx.Rollback()
 					return err
 				}
+				auditTrails = append(auditTrails, models.AuditTrail{
+					Action:       models.AuditActionAccessRevokeGroup,
+					Description:  fmt.Sprintf("group %s access to vpn gateway %s revoked", vpnGroup.Name, vpnGateway.Name),
+					GroupID:      &vpnGroup.ID,
+					VpnGatewayID: &vpnGateway.ID,
+				})
 			}
 		}
 	} else if action == "add" {
@@ -117,6 +142,12 @@ func AddRemoveGroupsInVpnGateway(action string, vpnGatewayUuid string, groupUuid
 				tx.Rollback()
 				return err
 			}
+			auditTrails = append(auditTrails, models.AuditTrail{
+				Action:       models.AuditActionAccessGrantGroup,
+				Description:  fmt.Sprintf("group %s granted access to vpn gateway %s", newVpnGroup.Name, vpnGateway.Name),
+				GroupID:      &newVpnGroup.ID,
+				VpnGatewayID: &vpnGateway.ID,
+			})
 		}
 	} else {
 		tx.Rollback()
@@ -129,30 +160,73 @@ func AddRemoveGroupsInVpnGateway(action string, vpnGatewayUuid string, groupUuid
 		return err
 	}
 
+	for _, auditTrail := range auditTrails {
+		recordAuditTrail(actorUuid, auditTrail)
+	}
 	return nil
 }
 
-func CreateVpnGateway(name, domain, ipAddress, vpnCidr string, port int, dnsServer string) error {
+func CreateVpnGateway(actorUuid string, request models.VpnGatewayCreateRequest) error {
 	log := logger.Default()
 	log.Info("start creating vpn gateway")
-	publicKey, privateKey, err := wireguard.GenerateWireguardPublicPrivateKeys()
+	var publicKey, privateKey string
+	var err error
+	if request.ServerPrivateKey != "" {
+		privateKey = request.ServerPrivateKey
+		publicKey, err = wireguard.PublicKeyFromPrivateKey(request.ServerPrivateKey)
+	} else {
+		publicKey, privateKey, err = wireguard.GenerateWireguardPublicPrivateKeys()
+	}
 	if err != nil {
 		return err
 	}
 
+	presharedKeyPolicy := request.PresharedKeyPolicy
+	if presharedKeyPolicy == "" {
+		presharedKeyPolicy = models.PresharedKeyRequired
+	}
+	clientLimitPolicy := request.ClientLimitPolicy
+	if clientLimitPolicy == "" {
+		clientLimitPolicy = models.ClientLimitReject
+	}
+	routes, err := helper.NormalizeCIDRs(request.Routes)
+	if err != nil {
+		return err
+	}
+	if err := validateVpnCIDR(request.VpnCIDR); err != nil {
+		return err
+	}
+	reservedRanges, err := normalizeReservedRanges(request.VpnCIDR, request.ReservedRanges)
+	if err != nil {
+		return err
+	}
+	if request.VpnCIDRv6 != "" {
+		if err := validateVpnCIDRv6(request.VpnCIDRv6); err != nil {
+			return err
+		}
+	}
+
 	secretKey := auth.RandomStringGenerator(32)
 	vpnGateway := models.VpnGateway{
-		UUID:             uuid.NewString(),
-		Name:             name,
-		JwtSecretKey:     secretKey,
-		JwtAlgorithm:     "HS256",
-		ServerPublicKey:  publicKey,
-		ServerPrivateKey: privateKey,
-		Domain:           domain,
-		VpnCIDR:          vpnCidr,
-		IpAddress:        ipAddress,
-		Port:             port,
-		DnsServer:        dnsServer,
+		UUID:               uuid.NewString(),
+		Name:               request.Name,
+		JwtSecretKey:       secretKey,
+		JwtAlgorithm:       "HS256",
+		ServerPublicKey:    publicKey,
+		ServerPrivateKey:   privateKey,
+		Domain:             request.Domain,
+		VpnCIDR:            request.VpnCIDR,
+		VpnCIDRv6:          request.VpnCIDRv6,
+		IpAddress:          request.IpAddress,
+		Port:               request.Port,
+		DnsServer:          request.DnsServer,
+		PresharedKeyPolicy: presharedKeyPolicy,
+		Routes:             routes,
+		ReservedRanges:     reservedRanges,
+		MaxClientLifetime:  request.MaxClientLifetime,
+		MaxClients:         request.MaxClients,
+		MaxClientsPerUser:  request.MaxClientsPerUser,
+		ClientLimitPolicy:  clientLimitPolicy,
 	}
 	tx := database.DB.Begin()
 
@@ -163,55 +237,37 @@ func CreateVpnGateway(name, domain, ipAddress, vpnCidr string, port int, dnsServ
 		return err
 	}
 
-	err = createIPPoolForGateway(tx, &vpnGateway)
-	if err != nil {
-		log.Info("issue creating vpn gateway ippool")
-		tx.Rollback()
-		return err
-	}
 	if err = tx.Commit().Error; err != nil {
 		log.Errorf("error committing transaction for gateway creation")
 		return err
 	}
-	return nil
-}
 
-func createIPPoolForGateway(tx *gorm.DB, vpnGateway *models.VpnGateway) error {
-	log := logger.Default()
-	_, ipNet, err := net.ParseCIDR(vpnGateway.VpnCIDR)
-	if err != nil {
-		log.Errorf("invalid CIDR: %v", err)
-		return err
+	// settings left to their defaults are not recorded
+	description := fmt.Sprintf("vpn gateway %s created for domain %s with cidr %s", vpnGateway.Name, vpnGateway.Domain, vpnGateway.VpnCIDR)
+	var settings []string
+	if vpnGateway.VpnCIDRv6 != "" {
+		settings = append(settings, "ipv6 cidr "+vpnGateway.VpnCIDRv6)
 	}
-	firstIP := dupIP(ipNet.IP)
-	incIP(firstIP)
-	if !ipNet.Contains(firstIP) {
-		log.Errorf("No IP available for clients for this CIDR : %s", vpnGateway.VpnCIDR)
-		return errors.New("no ip available for clients for this small cidr")
+	if len(vpnGateway.Routes) > 0 {
+		settings = append(settings, fmt.Sprintf("routes %v", vpnGateway.Routes))
 	}
-	lastIP := lastIP(ipNet)
-	var ipPools []models.IPPool
-
-	for ip := ipNet.IP.Mask(ipNet.Mask); ipNet.Contains(ip); incIP(ip) {
-		// Skip the network and broadcast addresses
-		if ip.Equal(ipNet.IP) || ip.Equal(lastIP) || ip.Equal(firstIP) {
-			continue
-		}
-		// Create an IPPool entry for each IP
-		ipPool := models.IPPool{
-			UUID:         uuid.NewString(),
-			IP:           ip.String(),
-			Assigned:     false,
-			VpnGatewayID: vpnGateway.ID,
-		}
-		ipPools = append(ipPools, ipPool)
-
+	if len(vpnGateway.ReservedRanges) > 0 {
+		settings = append(settings, fmt.Sprintf("reserved ranges %v", vpnGateway.ReservedRanges))
 	}
-	// Insert the IPPool entry into the database
-	if err := tx.Create(&ipPools).Error; err != nil {
-		log.Errorf("failed to create IPPool entry for gateway %s with CIDR %s", vpnGateway.UUID, vpnGateway.VpnCIDR)
-		return err
+	if vpnGateway.MaxClientLifetime > 0 {
+		settings = append(settings, fmt.Sprintf("max client lifetime %d minutes", vpnGateway.MaxClientLifetime))
+	}
+	if vpnGateway.MaxClients > 0 || vpnGateway.MaxClientsPerUser > 0 {
+		settings = append(settings, fmt.Sprintf("client limits %d per gateway and %d per user with policy %s", vpnGateway.MaxClients, vpnGateway.MaxClientsPerUser, vpnGateway.ClientLimitPolicy))
 	}
+	if len(settings) > 0 {
+		description += ", " + strings.Join(settings, ", ")
+	}
+	recordAuditTrail(actorUuid, models.AuditTrail{
+		Action:       models.AuditActionGatewayCreate,
+		Description:  description,
+		VpnGatewayID: &vpnGateway.ID,
+	})
 	return nil
 }
 
@@ -241,54 +297,179 @@ func dupIP(ip net.IP) net.IP {
 	return dup
 }
 
-func DeleteVpnGateway(vpnGatewayUuid string) error {
+func DeleteVpnGateway(actorUuid, vpnGatewayUuid string) error {
 	log := logger.Default()
-	var vpnGateway models.VpnGateway
 
-	err := ClearVpnGatewayClientsAndIPPool(vpnGatewayUuid)
+	err := ClearVpnGatewayClientsAndIPPool(actorUuid, vpnGatewayUuid)
 	if err != nil {
 		log.Errorf("Error in clearing Clients And IPPool : %s", vpnGatewayUuid)
 		return err
 	}
-	err = database.DB.Where("uuid = ?", vpnGatewayUuid).Delete(&vpnGateway).Error
+	vpnGateway, exists, err := getVpnGatewayFromUuid(vpnGatewayUuid)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("vpn gateway with given uuid not present")
+	}
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("vpn_gateway_id = ?", vpnGateway.ID).Delete(&models.IPReservation{}).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&vpnGateway).Error
+	})
 	if err != nil {
 		log.Errorf("Error deleting vpn gateway : %s", vpnGatewayUuid)
 		return err
 	}
+	recordAuditTrail(actorUuid, models.AuditTrail{
+		Action:       models.AuditActionGatewayDelete,
+		Description:  fmt.Sprintf("vpn gateway %s deleted", vpnGateway.Name),
+		VpnGatewayID: &vpnGateway.ID,
+	})
 	return nil
 }
 
-func UpdateVpnGateway(vpnGatewayUuid, name, domain, ipAddress string, port int, dnsServer string) error {
+func UpdateVpnGateway(actorUuid, vpnGatewayUuid string, request models.VpnGatewayUpdateRequest) error {
 	var vpnGateway models.VpnGateway
 	err := database.DB.Where("uuid = ?", vpnGatewayUuid).First(&vpnGateway).Error
 	if err != nil {
 		return err
 	}
-	if name != "" {
-		vpnGateway.Name = name
+
+	// only the settings that change are recorded
+	var changes []string
+	if request.Name != "" && request.Name != vpnGateway.Name {
+		changes = append(changes, fmt.Sprintf("name %s to %s", vpnGateway.Name, request.Name))
+		vpnGateway.Name = request.Name
+	}
+	if request.Domain != "" && request.Domain != vpnGateway.Domain {
+		changes = append(changes, fmt.Sprintf("domain %s to %s", vpnGateway.Domain, request.Domain))
+		vpnGateway.Domain = request.Domain
 	}
-	if domain != "" {
-		vpnGateway.Domain = domain
+	if request.IpAddress != "" && request.IpAddress != vpnGateway.IpAddress {
+		changes = append(changes, fmt.Sprintf("ip address %s to %s", vpnGateway.IpAddress, request.IpAddress))
+		vpnGateway.IpAddress = request.IpAddress
 	}
-	if ipAddress != "" {
-		vpnGateway.IpAddress = ipAddress
+	if request.Port != 0 && request.Port != vpnGateway.Port {
+		changes = append(changes, fmt.Sprintf("port %d to %d", vpnGateway.Port, request.Port))
+		vpnGateway.Port = request.Port
 	}
-	if port != 0 {
-		vpnGateway.Port = port
+	if request.DnsServer != "" && request.DnsServer != vpnGateway.DnsServer {
+		changes = append(changes, fmt.Sprintf("dns server %s to %s", vpnGateway.DnsServer, request.DnsServer))
+		vpnGateway.DnsServer = request.DnsServer
 	}
 
-	if dnsServer != "" {
-		vpnGateway.DnsServer = dnsServer
+	// once preshared keys are required, clients without one are left out of the gateway config
+	// and removed from the gateway by the reconciliation
+	if request.PresharedKeyPolicy != "" && request.PresharedKeyPolicy != vpnGateway.PresharedKeyPolicy {
+		changes = append(changes, fmt.Sprintf("preshared key policy %s to %s", vpnGateway.PresharedKeyPolicy, request.PresharedKeyPolicy))
+		vpnGateway.PresharedKeyPolicy = request.PresharedKeyPolicy
+	}
+	// existing clients keep the routes they were issued with
+	if request.Routes != nil {
+		routes, err := helper.NormalizeCIDRs(request.Routes)
+		if err != nil {
+			return err
+		}
+		if !slices.Equal(routes, vpnGateway.Routes) {
+			changes = append(changes, fmt.Sprintf("routes %v to %v", vpnGateway.Routes, routes))
+			vpnGateway.Routes = routes
+		}
 	}
 
-	err = database.DB.Save(&vpnGateway).Error
+	// existing clients keep their expiry
+	if request.MaxClientLifetime != nil && *request.MaxClientLifetime != vpnGateway.MaxClientLifetime {
+		changes = append(changes, fmt.Sprintf("max client lifetime %d to %d minutes", vpnGateway.MaxClientLifetime, *request.MaxClientLifetime))
+		vpnGateway.MaxClientLifetime = *request.MaxClientLifetime
+	}
+	// active clients over a lowered cap are kept, the cap applies to new clients
+	if request.MaxClients != nil && *request.MaxClients != vpnGateway.MaxClients {
+		changes = append(changes, fmt.Sprintf("max clients %d to %d", vpnGateway.MaxClients, *request.MaxClients))
+		vpnGateway.MaxClients = *request.MaxClients
+	}
+	if request.MaxClientsPerUser != nil && *request.MaxClientsPerUser != vpnGateway.MaxClientsPerUser {
+		changes = append(changes, fmt.Sprintf("max clients per user %d to %d", vpnGateway.MaxClientsPerUser, *request.MaxClientsPerUser))
+		vpnGateway.MaxClientsPerUser = *request.MaxClientsPerUser
+	}
+	if request.ClientLimitPolicy != "" && request.ClientLimitPolicy != vpnGateway.ClientLimitPolicy {
+		changes = append(changes, fmt.Sprintf("client limit policy %s to %s", vpnGateway.ClientLimitPolicy, request.ClientLimitPolicy))
+		vpnGateway.ClientLimitPolicy = request.ClientLimitPolicy
+	}
+	// addresses already allocated inside a new reserved range stay with their clients
+	if request.ReservedRanges != nil {
+		reservedRanges, err := normalizeReservedRanges(vpnGateway.VpnCIDR, request.ReservedRanges)
+		if err != nil {
+			return err
+		}
+		if !slices.Equal(reservedRanges, vpnGateway.ReservedRanges) {
+			changes = append(changes, fmt.Sprintf("reserved ranges %v to %v", vpnGateway.ReservedRanges, reservedRanges))
+			vpnGateway.ReservedRanges = reservedRanges
+		}
+	}
+
+	restartRequired := false
+	if request.VpnCIDRv6 != "" && request.VpnCIDRv6 != vpnGateway.VpnCIDRv6 {
+		if vpnGateway.VpnCIDRv6 != "" {
+			return errors.New("vpnCIDRv6 of a dual-stack gateway cannot be changed")
+		}
+		if err := validateVpnCIDRv6(request.VpnCIDRv6); err != nil {
+			return err
+		}
+		changes = append(changes, "ipv6 cidr set to "+request.VpnCIDRv6)
+		vpnGateway.VpnCIDRv6 = request.VpnCIDRv6
+		restartRequired = true
+	}
+
+	keyRotated := false
+	if request.ServerPrivateKey != "" && request.ServerPrivateKey != vpnGateway.ServerPrivateKey {
+		publicKey, err := wireguard.PublicKeyFromPrivateKey(request.ServerPrivateKey)
+		if err != nil {
+			return err
+		}
+		vpnGateway.ServerPrivateKey = request.ServerPrivateKey
+		vpnGateway.ServerPublicKey = publicKey
+		keyRotated = true
+		restartRequired = true
+	}
+	if len(changes) == 0 && !keyRotated {
+		return nil
+	}
+
+	// the gateway restarts to pick up a new key or address
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&vpnGateway).Error; err != nil {
+			return err
+		}
+		if restartRequired {
+			return enqueueGatewayOperation(tx, vpnGateway.ID, models.GatewayOperationRestart, nil)
+		}
+		return nil
+	})
 	if err != nil {
 		return err
 	}
+	if restartRequired {
+		notifyGatewayOperationsWorker()
+	}
+	if keyRotated {
+		recordAuditTrail(actorUuid, models.AuditTrail{
+			Action:       models.AuditActionGatewayKeyRotate,
+			Description:  fmt.Sprintf("vpn gateway %s key rotated, new public key %s", vpnGateway.Name, vpnGateway.ServerPublicKey),
+			VpnGatewayID: &vpnGateway.ID,
+		})
+	}
+	if len(changes) > 0 {
+		recordAuditTrail(actorUuid, models.AuditTrail{
+			Action:       models.AuditActionGatewayUpdate,
+			Description:  fmt.Sprintf("vpn gateway %s updated : %s", vpnGateway.Name, strings.Join(changes, ", ")),
+			VpnGatewayID: &vpnGateway.ID,
+		})
+	}
 	return nil
 }
 
-func ClearVpnGatewayClientsAndIPPool(vpnGatewayUuid string) error {
+func ClearVpnGatewayClientsAndIPPool(actorUuid, vpnGatewayUuid string) error {
 
 	tx := database.DB.Begin()
 
@@ -304,27 +485,30 @@ func ClearVpnGatewayClientsAndIPPool(vpnGatewayUuid string) error {
 		return fmt.Errorf("failed to deactivate clients: %w", err)
 	}
 
-	if err := tx.Model(&models.IPPool{}).Where("vpn_gateway_id = ?", vpnGateway.ID).
-		Update("assigned", false).Error; err != nil {
+	if err := tx.Where("vpn_gateway_id = ?", vpnGateway.ID).Delete(&models.IPAllocation{}).Error; err != nil {
 		tx.Rollback()
-		return fmt.Errorf("failed to unassign IPs in IPPool: %w", err)
+		return fmt.Errorf("failed to release IP allocations: %w", err)
 	}
 
-	if tx.Commit().Error != nil {
-		return tx.Commit().Error
-	}
-	authToken, err := auth.CreateVpnGatewayToken(vpnGateway.UUID, vpnGateway.JwtSecretKey)
-	if err != nil {
-		return err
+	if err := enqueueGatewayOperation(tx, vpnGateway.ID, models.GatewayOperationRestart, nil); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to queue restart of VPN Gateway: %w", err)
 	}
-	_, _, err = externalcomms.RestartVpnGateway(vpnGateway.Domain, authToken)
-	if err != nil {
+
+	if err := tx.Commit().Error; err != nil {
 		return err
 	}
+	notifyGatewayOperationsWorker()
+
+	recordAuditTrail(actorUuid, models.AuditTrail{
+		Action:       models.AuditActionGatewayReset,
+		Description:  fmt.Sprintf("vpn gateway %s clients and ip pool cleared", vpnGateway.Name),
+		VpnGatewayID: &vpnGateway.ID,
+	})
 	return nil
 }
 
-func ListVpnGateways(includeUsers, includeClients, includeUsersWithClients, includeGroups, includeIpPool bool) ([]models.VpnGateway, error) {
+func ListVpnGateways(includeUsers, includeClients, includeUsersWithClients, includeGroups, includeIpPool, includeHealthHistory bool) ([]models.VpnGateway, error) {
 	var vpnGateways []models.VpnGateway
 	dbClient := database.DB
 
@@ -338,11 +522,14 @@ func ListVpnGateways(includeUsers, includeClients, includeUsersWithClients, incl
 		dbClient = dbClient.Preload("Clients", "is_active = ?", true)
 	}
 	if includeIpPool {
-		dbClient = dbClient.Preload("IPPool")
+		dbClient = dbClient.Preload("IPAllocations")
 	}
 	if includeGroups {
 		dbClient = dbClient.Preload("Groups")
 	}
+	if includeHealthHistory {
+		dbClient = preloadVpnGatewayHealthHistory(dbClient)
+	}
 	if err := dbClient.Find(&vpnGateways).Error; err != nil {
 		return nil, err
 	}
@@ -350,7 +537,7 @@ func ListVpnGateways(includeUsers, includeClients, includeUsersWithClients, incl
 	return vpnGateways, nil
 }
 
-func GetVpnGatewayByUUID(vpnGatewayUuid string, includeUsers, includeClients, includeUsersWithClients, includeGroups, includeIpPool bool) (models.VpnGateway, error) {
+func GetVpnGatewayByUUID(vpnGatewayUuid string, includeUsers, includeClients, includeUsersWithClients, includeGroups, includeIpPool, includeHealthHistory bool) (models.VpnGateway, error) {
 	var vpnGateway models.VpnGateway
 
 	dbClient := database.DB
@@ -365,11 +552,14 @@ func GetVpnGatewayByUUID(vpnGatewayUuid string, includeUsers, includeClients, in
 		dbClient = dbClient.Preload("Clients", "is_active = ?", true)
 	}
 	if includeIpPool {
-		dbClient = dbClient.Preload("IPPool")
+		dbClient = dbClient.Preload("IPAllocations")
 	}
 	if includeGroups {
 		dbClient = dbClient.Preload("Groups")
 	}
+	if includeHealthHistory {
+		dbClient = preloadVpnGatewayHealthHistory(dbClient)
+	}
 
 	if err := dbClient.Where("uuid = ?", vpnGatewayUuid).First(&vpnGateway).Error; err != nil {
 		return models.VpnGateway{}, err
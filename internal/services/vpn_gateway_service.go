@@ -1,6 +1,12 @@
 package services
 
 import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/leetsecure/qryptic-controller/internal/database"
 	"github.com/leetsecure/qryptic-controller/internal/models"
 	"github.com/leetsecure/qryptic-controller/internal/utils/logger"
 )
@@ -9,7 +15,7 @@ func GetVpnGatewayWGConfig(vpnGatewayUuid string) (models.WGServerConfig, error)
 	log := logger.Default()
 	var wgServerConfig models.WGServerConfig
 
-	vpnGateway, err := GetVpnGatewayByUUID(vpnGatewayUuid, false, true, false, false, false)
+	vpnGateway, err := GetVpnGatewayByUUID(vpnGatewayUuid, false, true, false, false, false, false)
 	if err != nil {
 		log.Errorf("Error in fetching vpn gateway details for gateway uuid : %s", vpnGatewayUuid)
 		return wgServerConfig, err
@@ -18,17 +24,82 @@ func GetVpnGatewayWGConfig(vpnGatewayUuid string) (models.WGServerConfig, error)
 	wgServerConfig.WGServerInterfaceConfig.VpnGatewayUuid = vpnGateway.UUID
 	wgServerConfig.WGServerInterfaceConfig.DnsServer = vpnGateway.DnsServer
 	wgServerConfig.WGServerInterfaceConfig.IPAddress = vpnGateway.VpnCIDR
+	wgServerConfig.WGServerInterfaceConfig.IPv6Address = vpnGateway.VpnCIDRv6
 	wgServerConfig.WGServerInterfaceConfig.ListenPort = vpnGateway.Port
 	wgServerConfig.WGServerInterfaceConfig.PrivateKey = vpnGateway.ServerPrivateKey
 	wgServerConfig.WGServerInterfaceConfig.PublicKey = vpnGateway.ServerPublicKey
 
+	var peers []*models.Client
 	for _, peer := range vpnGateway.Clients {
-		var wgServerPeerConfig models.WGServerPeerConfig
-		wgServerPeerConfig.ClientAllowedIPs = peer.AllocatedIP
-		wgServerPeerConfig.ClientPublicKey = peer.ClientPublicKey
-		wgServerPeerConfig.PresharedKey = ""
-		wgServerConfig.WGServerPeerConfigs = append(wgServerConfig.WGServerPeerConfigs, wgServerPeerConfig)
+		if peer.PresharedKey == "" && vpnGateway.PresharedKeyPolicy == models.PresharedKeyRequired {
+			continue
+		}
+		peers = append(peers, peer)
+	}
+	wgServerConfig.WGServerPeerConfigs, err = wgServerPeerConfigsForClients(database.DB, vpnGateway, peers)
+	if err != nil {
+		return wgServerConfig, err
 	}
 
 	return wgServerConfig, nil
 }
+
+// RecordVpnGatewayHeartbeat stores the state reported by the gateway. The first heartbeat registers
+// the gateway and version changes are audited.
+func RecordVpnGatewayHeartbeat(vpnGatewayUuid string, heartbeatRequest models.VpnGatewayHeartbeatRequest) error {
+	vpnGateway, exists, err := getVpnGatewayFromUuid(vpnGatewayUuid)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return errors.New("vpn gateway with given uuid not present")
+	}
+
+	firstHeartbeat := vpnGateway.LastHeartbeatAt == nil
+	previousVersion := vpnGateway.GatewayVersion
+
+	timeNow := time.Now()
+	vpnGateway.GatewayVersion = heartbeatRequest.Version
+	vpnGateway.UptimeSeconds = heartbeatRequest.UptimeSeconds
+	vpnGateway.ReportedPublicIP = heartbeatRequest.PublicIP
+	vpnGateway.WGInterfaceName = heartbeatRequest.WGInterfaceName
+	vpnGateway.WGInterfaceUp = heartbeatRequest.WGInterfaceUp
+	vpnGateway.WGInterfacePeerCount = heartbeatRequest.WGInterfacePeerCount
+	vpnGateway.LastHeartbeatAt = &timeNow
+	vpnGateway.LastSeenAt = &timeNow
+
+	err = database.DB.Model(&vpnGateway).
+		Select("GatewayVersion", "UptimeSeconds", "ReportedPublicIP", "WGInterfaceName", "WGInterfaceUp", "WGInterfacePeerCount", "LastHeartbeatAt", "LastSeenAt").
+		Updates(&vpnGateway).Error
+	if err != nil {
+		return err
+	}
+
+	if firstHeartbeat {
+		recordAuditTrail(vpnGateway.UUID, models.AuditTrail{
+			ActorType:    models.AuditActorGateway,
+			Action:       models.AuditActionGatewayRegister,
+			Description:  fmt.Sprintf("vpn gateway %s registered with version %s", vpnGateway.Name, vpnGateway.GatewayVersion),
+			VpnGatewayID: &vpnGateway.ID,
+		})
+	} else if previousVersion != vpnGateway.GatewayVersion {
+		recordAuditTrail(vpnGateway.UUID, models.AuditTrail{
+			ActorType:    models.AuditActorGateway,
+			Action:       models.AuditActionGatewayVersionChange,
+			Description:  fmt.Sprintf("vpn gateway %s version changed from %s to %s", vpnGateway.Name, previousVersion, vpnGateway.GatewayVersion),
+			VpnGatewayID: &vpnGateway.ID,
+		})
+	}
+	return nil
+}
+
+// clientAllowedIPs splits the comma separated routes stored on the client.
+func clientAllowedIPs(client models.Client) []string {
+	var allowedIPs []string
+	for _, allowedIP := range strings.Split(client.AllowedIPs, ",") {
+		if allowedIP = strings.TrimSpace(allowedIP); allowedIP != "" {
+			allowedIPs = append(allowedIPs, allowedIP)
+		}
+	}
+	return allowedIPs
+}
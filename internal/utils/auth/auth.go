@@ -1,7 +1,12 @@
 package auth
 
 import (
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
 	"encoding/base64"
+	"encoding/hex"
+	"errors"
 	"math/rand"
 	"strings"
 	"time"
@@ -85,7 +90,8 @@ func VerifyVpnGatewayAuthToken(vpnGatewayAuthToken string, jwtVpnGatewayAuthSecr
 	return vpnGatewayUuid, nil
 }
 
-func VerifyUserAuthToken(userAuthToken string) (string, models.UserRoleEnum, error) {
+// VerifyUserAuthToken returns the user uuid, the role and the issue time of the token.
+func VerifyUserAuthToken(userAuthToken string) (string, models.UserRoleEnum, time.Time, error) {
 	log := logger.Default()
 	var jwtUserAuthSecretKey = []byte(config.UserAuthJwtSecretKey)
 	token, err := jwt.Parse(userAuthToken, func(token *jwt.Token) (interface{}, error) {
@@ -95,26 +101,31 @@ func VerifyUserAuthToken(userAuthToken string) (string, models.UserRoleEnum, err
 	// Check for verification errors
 	if err != nil {
 		log.Error("Error in parsing and verifying user auth token")
-		return "", models.DefaultRole, err
+		return "", models.DefaultRole, time.Time{}, err
 	}
 
 	// Check if the token is valid
 	if !token.Valid {
 		log.Info("Invalid user auth token")
-		return "", models.DefaultRole, err
+		return "", models.DefaultRole, time.Time{}, err
 	}
 
 	userUuid, err := token.Claims.GetSubject()
 	if err != nil {
 		log.Error("Error in getting user uuid from token")
-		return "", models.DefaultRole, err
+		return "", models.DefaultRole, time.Time{}, err
 	}
 	userRole, err := token.Claims.GetAudience()
 	if err != nil {
 		log.Error("Error in getting user role from token")
-		return "", models.DefaultRole, err
+		return "", models.DefaultRole, time.Time{}, err
 	}
-	return userUuid, models.UserRoleEnum(userRole[0]), nil
+	issuedAt, err := token.Claims.GetIssuedAt()
+	if err != nil || issuedAt == nil {
+		log.Error("Error in getting issue time from user auth token")
+		return "", models.DefaultRole, time.Time{}, errors.New("issue time missing in user auth token")
+	}
+	return userUuid, models.UserRoleEnum(userRole[0]), issuedAt.Time, nil
 }
 
 func VerifyPassword(password, hashedPassword string) error {
@@ -151,3 +162,28 @@ func Base64URLEncode(input []byte) string {
 	encoded = strings.ReplaceAll(encoded, "/", "_") // Replace '/' with '_'
 	return encoded
 }
+
+// CreateSCIMToken returns a random bearer token for the scim client and its hash, only the hash is stored.
+func CreateSCIMToken() (string, string, error) {
+	tokenBytes := make([]byte, 32)
+	if _, err := cryptorand.Read(tokenBytes); err != nil {
+		return "", "", err
+	}
+	token := Base64URLEncode(tokenBytes)
+	return token, hashSCIMToken(token), nil
+}
+
+func VerifySCIMToken(scimToken string, scimTokenHash string) error {
+	if scimTokenHash == "" {
+		return errors.New("scim provisioning not enabled")
+	}
+	if subtle.ConstantTimeCompare([]byte(hashSCIMToken(scimToken)), []byte(scimTokenHash)) != 1 {
+		return errors.New("invalid scim token")
+	}
+	return nil
+}
+
+func hashSCIMToken(scimToken string) string {
+	sum := sha256.Sum256([]byte(scimToken))
+	return hex.EncodeToString(sum[:])
+}
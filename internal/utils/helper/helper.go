@@ -1 +1,85 @@
 package helper
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// NormalizeCIDRs parses the cidrs and returns them in canonical form, sorted, without duplicates
+// and without the cidrs already covered by a wider one.
+func NormalizeCIDRs(cidrs []string) ([]string, error) {
+	var ipNets []*net.IPNet
+	for _, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(strings.TrimSpace(cidr))
+		if err != nil {
+			return nil, fmt.Errorf("invalid cidr %q", cidr)
+		}
+		ipNets = append(ipNets, ipNet)
+	}
+
+	// wider networks first, so that covered ones are seen after the network covering them
+	sort.SliceStable(ipNets, func(i, j int) bool {
+		onesI, _ := ipNets[i].Mask.Size()
+		onesJ, _ := ipNets[j].Mask.Size()
+		if onesI != onesJ {
+			return onesI < onesJ
+		}
+		return bytes.Compare(ipNets[i].IP, ipNets[j].IP) < 0
+	})
+
+	var kept []*net.IPNet
+	for _, ipNet := range ipNets {
+		covered := false
+		for _, keptIPNet := range kept {
+			if cidrContains(keptIPNet, ipNet) {
+				covered = true
+				break
+			}
+		}
+		if !covered {
+			kept = append(kept, ipNet)
+		}
+	}
+
+	normalized := make([]string, 0, len(kept))
+	for _, ipNet := range kept {
+		normalized = append(normalized, ipNet.String())
+	}
+	sort.Strings(normalized)
+	return normalized, nil
+}
+
+// IntersectCIDRs returns the address space present in both lists. Two cidrs either nest or are
+// disjoint, so the intersection of a pair is the narrower one or nothing.
+func IntersectCIDRs(cidrs, otherCidrs []string) []string {
+	var intersection []string
+	for _, cidr := range cidrs {
+		_, ipNet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			continue
+		}
+		for _, otherCidr := range otherCidrs {
+			_, otherIPNet, err := net.ParseCIDR(otherCidr)
+			if err != nil {
+				continue
+			}
+			if cidrContains(ipNet, otherIPNet) {
+				intersection = append(intersection, otherIPNet.String())
+			} else if cidrContains(otherIPNet, ipNet) {
+				intersection = append(intersection, ipNet.String())
+			}
+		}
+	}
+	intersection, _ = NormalizeCIDRs(intersection)
+	return intersection
+}
+
+// cidrContains reports whether the network covers the whole of the other network.
+func cidrContains(ipNet, otherIPNet *net.IPNet) bool {
+	ones, bits := ipNet.Mask.Size()
+	otherOnes, otherBits := otherIPNet.Mask.Size()
+	return bits == otherBits && ones <= otherOnes && ipNet.Contains(otherIPNet.IP)
+}
@@ -1,61 +1,121 @@
 package wireguard
 
 import (
+	"crypto/ecdh"
+	"crypto/rand"
+	"encoding/base64"
 	"errors"
 	"fmt"
-	"io"
-
-	"os/exec"
+	"strings"
 
 	"github.com/leetsecure/qryptic-controller/internal/models"
 )
 
-func GenerateWireguardPublicPrivateKeys() (string, string, error) {
+const wireguardKeyLength = 32
 
-	cmd := exec.Command("wg", "genkey")
-	privateKey, err := cmd.Output()
-	if err != nil {
+// GenerateWireguardPublicPrivateKeys returns a base64 encoded curve25519 key pair, same as wg genkey and wg pubkey.
+func GenerateWireguardPublicPrivateKeys() (string, string, error) {
+	privateKey := make([]byte, wireguardKeyLength)
+	if _, err := rand.Read(privateKey); err != nil {
 		return "", "", errors.New("could not generate private key")
 	}
+	// clamp the scalar the way wg genkey does
+	privateKey[0] &= 248
+	privateKey[31] = (privateKey[31] & 127) | 64
 
-	cmd = exec.Command("wg", "pubkey")
-	stdin, err := cmd.StdinPipe()
+	publicKey, err := publicKeyFromPrivateKeyBytes(privateKey)
 	if err != nil {
-		return "", "", errors.New("could not get stdin of pubkey command")
+		return "", "", errors.New("could not generate public key")
 	}
+	return publicKey, base64.StdEncoding.EncodeToString(privateKey), nil
+}
 
-	go func() {
-		defer stdin.Close()
-		io.WriteString(stdin, string(privateKey))
-	}()
+// GeneratePresharedKey returns a base64 encoded random symmetric key, same as wg genpsk.
+func GeneratePresharedKey() (string, error) {
+	presharedKey := make([]byte, wireguardKeyLength)
+	if _, err := rand.Read(presharedKey); err != nil {
+		return "", errors.New("could not generate preshared key")
+	}
+	return base64.StdEncoding.EncodeToString(presharedKey), nil
+}
 
-	publicKey, err := cmd.Output()
+// PublicKeyFromPrivateKey derives the base64 encoded public key of a base64 encoded private key.
+func PublicKeyFromPrivateKey(privateKey string) (string, error) {
+	privateKeyBytes, err := parseKey(privateKey)
 	if err != nil {
-		return "", "", errors.New("could not generate public key")
+		return "", err
 	}
-	publicKeyString := string(publicKey)
-	publicKeyString = publicKeyString[:len(publicKeyString)-1] // removing \n from end
+	return publicKeyFromPrivateKeyBytes(privateKeyBytes)
+}
 
-	privateKeyString := string(privateKey)
-	privateKeyString = privateKeyString[:len(privateKeyString)-1] // removing \n from end
+// ValidatePublicKey checks that the key is a base64 encoded curve25519 public key usable as a peer.
+func ValidatePublicKey(publicKey string) error {
+	publicKeyBytes, err := parseKey(publicKey)
+	if err != nil {
+		return err
+	}
+	// an all zero key is the result of a low order point and is rejected by wireguard
+	for _, b := range publicKeyBytes {
+		if b != 0 {
+			return nil
+		}
+	}
+	return errors.New("invalid wireguard key: all zero key")
+}
 
-	return publicKeyString, privateKeyString, nil
+// ValidatePrivateKey checks that the key is a base64 encoded curve25519 private key.
+func ValidatePrivateKey(privateKey string) error {
+	_, err := PublicKeyFromPrivateKey(privateKey)
+	return err
 }
 
+func parseKey(key string) ([]byte, error) {
+	keyBytes, err := base64.StdEncoding.DecodeString(key)
+	if err != nil {
+		return nil, errors.New("invalid wireguard key: not base64 encoded")
+	}
+	if len(keyBytes) != wireguardKeyLength {
+		return nil, fmt.Errorf("invalid wireguard key: %d bytes instead of %d", len(keyBytes), wireguardKeyLength)
+	}
+	return keyBytes, nil
+}
+
+func publicKeyFromPrivateKeyBytes(privateKey []byte) (string, error) {
+	ecdhPrivateKey, err := ecdh.X25519().NewPrivateKey(privateKey)
+	if err != nil {
+		return "", err
+	}
+	return base64.StdEncoding.EncodeToString(ecdhPrivateKey.PublicKey().Bytes()), nil
+}
+
+// CreateClientConfig renders the client config in wg-quick format.
 func CreateClientConfig(vpnClientConfig models.VpnClientConfig) string {
+	var clientConfig strings.Builder
+
+	clientConfig.WriteString("[Interface]\n")
+	if vpnClientConfig.ClientPrivateKey != "" {
+		fmt.Fprintf(&clientConfig, "PrivateKey = %s\n", vpnClientConfig.ClientPrivateKey)
+	} else {
+		// clients of registered devices use the private key kept on the device
+		clientConfig.WriteString("# PrivateKey = <private key of the device>\n")
+	}
+	fmt.Fprintf(&clientConfig, "Address = %s\n", vpnClientConfig.ClientAddress)
+	if vpnClientConfig.ClientDNS != "" {
+		fmt.Fprintf(&clientConfig, "DNS = %s\n", vpnClientConfig.ClientDNS)
+	}
 
-	client_config_format := `
-	[Interface]
-	Address = %s
-	PrivateKey = %s
-	DNS = %s
+	clientConfig.WriteString("\n[Peer]\n")
+	fmt.Fprintf(&clientConfig, "PublicKey = %s\n", vpnClientConfig.VpnGatewayPublicKey)
+	if vpnClientConfig.PresharedKey != "" {
+		fmt.Fprintf(&clientConfig, "PresharedKey = %s\n", vpnClientConfig.PresharedKey)
+	}
+	fmt.Fprintf(&clientConfig, "Endpoint = %s\n", vpnClientConfig.VpnGatewayEndpoint)
+	fmt.Fprintf(&clientConfig, "AllowedIPs = %s\n", vpnClientConfig.VpnGatewayAllowedIPs)
+	if vpnClientConfig.PersistentKeepalive > 0 {
+		fmt.Fprintf(&clientConfig, "PersistentKeepalive = %d\n", vpnClientConfig.PersistentKeepalive)
+	}
 
-	[Peer]
-	PublicKey = %s
-	Endpoint = %s
-	AllowedIPs = %s	
-	`
-	return fmt.Sprintf(client_config_format, vpnClientConfig.ClientAddress, vpnClientConfig.ClientPrivateKey, vpnClientConfig.ClientDNS, vpnClientConfig.VpnGatewayPublicKey, vpnClientConfig.VpnGatewayEndpoint, vpnClientConfig.VpnGatewayAllowedIPs)
+	return clientConfig.String()
 }
 
 func CreateVpnGatewayConfig(vpnGatewayConfig models.VpnGatewayConfig) string {
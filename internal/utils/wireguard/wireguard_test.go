@@ -0,0 +1,63 @@
+package wireguard
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+func TestPublicKeyFromPrivateKey(t *testing.T) {
+	generatedPublicKey, generatedPrivateKey, err := GenerateWireguardPublicPrivateKeys()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		privateKey string
+		publicKey  string
+		wantErr    bool
+	}{
+		// key pair of alice from RFC 7748 section 6.1
+		{name: "rfc 7748", privateKey: "dwdtCnMYpX08FsFyUbJmRd9ML4frwJkqsXf7pR25LCo=", publicKey: "hSDwCYkwp1R0i33ctD73Wg2/Og0mOBr066SpjqqbTmo="},
+		{name: "generated", privateKey: generatedPrivateKey, publicKey: generatedPublicKey},
+		{name: "not base64", privateKey: "not a key", wantErr: true},
+		{name: "short", privateKey: base64.StdEncoding.EncodeToString(make([]byte, 16)), wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			publicKey, err := PublicKeyFromPrivateKey(test.privateKey)
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("invalid private key accepted")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if publicKey != test.publicKey {
+				t.Fatalf("public key %s, want %s", publicKey, test.publicKey)
+			}
+			if err := ValidatePublicKey(publicKey); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestGeneratePresharedKey(t *testing.T) {
+	presharedKey, err := GeneratePresharedKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := parseKey(presharedKey); err != nil {
+		t.Fatal(err)
+	}
+	otherPresharedKey, err := GeneratePresharedKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if presharedKey == otherPresharedKey {
+		t.Fatal("same preshared key generated twice")
+	}
+}